@@ -1,123 +1,63 @@
 package main
 
 import (
-	"context"
-	"log/slog"
-	"net/http"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 	"todo_list_service/internal/config"
-	"todo_list_service/internal/http-server/handlers"
-	"todo_list_service/internal/http-server/middleware/auth"
-	mwLogger "todo_list_service/internal/http-server/middleware/logger"
-	"todo_list_service/internal/metrics"
-	"todo_list_service/internal/storage/postgres"
 
-	"github.com/gorilla/sessions"
-
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-	_ "github.com/lib/pq"
+	// user time zones must resolve even where the system has no zoneinfo
+	_ "time/tzdata"
 )
 
-func main() {
-	cfg := config.MustLoad()
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-
-	logger.Info(
-		"starting todo_list service",
-		slog.Any("config", *cfg),
-		slog.String("address", cfg.HTTPServer.Address()),
-	)
-
-	metrics.StartMetricsServer(&cfg.MetricsConfig)
-	storage, err := postgres.New(&cfg.PgConfig)
-
-	logger.Info("created postgres storage")
-
-	if err != nil {
-		logger.Error("failed to setup storage", slog.String("error", err.Error()))
-		panic("cannot setup storage")
-	}
-
-	store := sessions.NewCookieStore([]byte(cfg.Session.SecretKey))
-	store.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   cfg.HTTPServer.Session.MaxAge,
-		HttpOnly: true,
-		Secure:   cfg.HTTPServer.Session.Secure,
-		SameSite: http.SameSiteLaxMode,
-	}
-
-	router := chi.NewRouter()
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
-	router.Use(mwLogger.New(logger))
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
-
-	handlerCtx := &handlers.HandlerContext{
-		Log:     logger,
-		Storage: storage,
-		Store:   store,
-	}
-
-	router.Post("/sign_up", handlers.NewSignUp(handlerCtx))
-	router.Post("/sign_in", handlers.NewSignIn(handlerCtx))
-
-	authMiddleware := auth.NewAuthMiddleware(store)
-
-	router.Group(func(r chi.Router) {
-		r.Use(authMiddleware.Middleware)
+const usage = `usage: todo_list_service <command> [arguments]
 
-		r.Post("/logout", handlers.NewLogout(handlerCtx))
-		r.Get("/get_tasks", handlers.NewGetTasks(handlerCtx))
-		r.Get("/get_task", handlers.NewGetTask(handlerCtx))
-		r.Post("/create_task", handlers.NewCreateTask(handlerCtx))
-		r.Post("/update_task", handlers.NewUpdateTask(handlerCtx))
-		r.Post("/update_priority", handlers.NewUpdatePriority(handlerCtx))
-	})
+commands:
+  serve                              start the http server (default)
+  migrate up                         apply pending migrations
+  migrate down [-steps N]            roll back the last N migrations
+  migrate status                     list migrations and whether they are applied
+  user create -username U [-email E] [-password P]
+  user reset-password -username U [-password P]
+  user list
+  tasks export -user U [-format json|csv] [-output FILE]
 
-	logger.Info("starting server", slog.String("address", cfg.HTTPServer.Address()))
+Passwords not given with -password are read from the first line of stdin.
+`
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-	srv := &http.Server{
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.Timeout,
-		WriteTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			logger.Error("got error, server stopped")
-			os.Exit(0)
-		}
-	}()
-
-	logger.Info("server started")
-
-	<-done
-	logger.Info("stopping server")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	cfg := config.MustLoad()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("failed to stop server", slog.String("error", err.Error()))
-		return
+	var err error
+	switch args[0] {
+	case "serve":
+		runServe(cfg)
+	case "migrate":
+		err = runMigrate(cfg, args[1:])
+	case "user":
+		err = runUser(cfg, args[1:])
+	case "tasks":
+		err = runTasks(cfg, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		err = fmt.Errorf("unknown command [%s]\n\n%s", args[0], usage)
 	}
 
-	err = storage.Close()
-
 	if err != nil {
-		logger.Error("failed to close storage", slog.String("error", err.Error()))
-		return
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+}
 
-	logger.Info("server stopped")
+// subcommand splits "user create -username x" into "create" and its flags.
+func subcommand(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("missing subcommand\n\n%s", usage)
+	}
+	return args[0], args[1:], nil
 }
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	"todo_list_service/internal/archive"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/health"
+	"todo_list_service/internal/http-server/apierror"
+	"todo_list_service/internal/http-server/handlers"
+	"todo_list_service/internal/http-server/middleware/auth"
+	mwLogger "todo_list_service/internal/http-server/middleware/logger"
+	"todo_list_service/internal/logging"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/traced"
+	"todo_list_service/internal/tracing"
+
+	"github.com/gorilla/sessions"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func runServe(cfg *config.Config) {
+	logger, closeLog, err := logging.New(&cfg.LogConfig)
+	if err != nil {
+		log.Fatalf("cannot setup logging: %s", err)
+	}
+	defer closeLog()
+
+	logger.Info(
+		"starting todo_list service",
+		slog.Any("config", *cfg),
+		slog.String("address", cfg.HTTPServer.Address()),
+	)
+
+	closePolicy, err := storage.ParseClosePolicy(cfg.TasksConfig.SubtaskClosePolicy)
+	if err != nil {
+		logger.Error("invalid tasks config", slog.String("error", err.Error()))
+		panic("invalid subtask close policy")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.TracingConfig, cfg.Env)
+	if err != nil {
+		logger.Error("failed to setup tracing", slog.String("error", err.Error()))
+		panic("cannot setup tracing")
+	}
+
+	metricsSrv := metrics.StartMetricsServer(&cfg.MetricsConfig, logger)
+
+	// the server listens while migrations run so that probes can tell a
+	// booting instance from a dead one, the api is let in once it is built
+	probe := health.NewProbe(logger, cfg.HTTPServer.ReadyTimeout)
+	api := &health.Gate{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", probe.Live)
+	mux.HandleFunc("GET /readyz", probe.Ready)
+	mux.Handle("/", api)
+
+	logger.Info("starting server", slog.String("address", cfg.HTTPServer.Address()))
+
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	srv := &http.Server{
+		Addr:         cfg.HTTPServer.Address(),
+		Handler:      mux,
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	}
+
+	go func() {
+		// Shutdown makes ListenAndServe return at once, the rest of the
+		// shutdown still has to run
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("got error, server stopped", slog.String("error", err.Error()))
+			os.Exit(0)
+		}
+	}()
+
+	logger.Info("server started")
+
+	storage, err := newStorage(cfg)
+	if err != nil {
+		logger.Error("failed to setup storage", slog.String("error", err.Error()))
+		panic("cannot setup storage")
+	}
+
+	logger.Info("created storage", slog.String("driver", cfg.StorageDriver))
+
+	if pool, ok := storage.(pooled); ok {
+		if err := metrics.RegisterDBStats(pool.DB(), cfg.StorageDriver); err != nil {
+			logger.Error("failed to register db stats", slog.String("error", err.Error()))
+		}
+	}
+	// readiness pings the database itself, not through the tracing wrapper
+	readyCheck := func(context.Context) error { return nil }
+	if db, ok := storage.(pingable); ok {
+		readyCheck = db.Ping
+	}
+	storage = traced.New(storage, cfg.StorageDriver)
+
+	store := sessions.NewCookieStore([]byte(cfg.Session.SecretKey))
+	store.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   cfg.HTTPServer.Session.MaxAge,
+		HttpOnly: true,
+		Secure:   cfg.HTTPServer.Session.Secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(tracing.Middleware)
+	router.Use(metrics.Middleware)
+	router.Use(mwLogger.New(logger))
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.URLFormat)
+
+	router.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "Not found", nil)
+	})
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed", nil)
+	})
+
+	handlerCtx := &handlers.HandlerContext{
+		Log:         logger,
+		Storage:     storage,
+		Store:       store,
+		ClosePolicy: closePolicy,
+	}
+
+	router.Group(func(r chi.Router) {
+		r.Use(tracing.Handler)
+
+		r.Post("/sign_up", handlers.NewSignUp(handlerCtx))
+		r.Post("/sign_in", handlers.NewSignIn(handlerCtx))
+	})
+
+	authMiddleware := auth.NewAuthMiddleware(store)
+
+	router.Group(func(r chi.Router) {
+		r.Use(authMiddleware.Middleware)
+		r.Use(tracing.Handler)
+
+		r.Post("/logout", handlers.NewLogout(handlerCtx))
+		r.Get("/get_tasks", handlers.NewGetTasks(handlerCtx))
+		r.Get("/get_task", handlers.NewGetTask(handlerCtx))
+		r.Post("/create_task", handlers.NewCreateTask(handlerCtx))
+		r.Post("/quick_add", handlers.NewQuickAdd(handlerCtx))
+		r.Post("/update_task", handlers.NewUpdateTask(handlerCtx))
+		r.Post("/update_priority", handlers.NewUpdatePriority(handlerCtx))
+		r.Post("/archive_task", handlers.NewArchiveTask(handlerCtx))
+		r.Post("/restore_task", handlers.NewRestoreTask(handlerCtx))
+		r.Post("/delete_task", handlers.NewDeleteTask(handlerCtx))
+		r.Get("/get_archived_tasks", handlers.NewGetArchivedTasks(handlerCtx))
+		r.Get("/get_task_history", handlers.NewGetTaskHistory(handlerCtx))
+		r.Post("/undo_task", handlers.NewUndoTask(handlerCtx))
+		r.Post("/redo_task", handlers.NewRedoTask(handlerCtx))
+		r.Get("/get_due_tasks", handlers.NewGetDueTasks(handlerCtx))
+		r.Post("/set_time_zone", handlers.NewSetTimeZone(handlerCtx))
+		r.Get("/get_tags", handlers.NewGetTags(handlerCtx))
+		r.Post("/create_tag", handlers.NewCreateTag(handlerCtx))
+		r.Post("/update_tag", handlers.NewUpdateTag(handlerCtx))
+		r.Post("/delete_tag", handlers.NewDeleteTag(handlerCtx))
+		r.Post("/attach_tag", handlers.NewAttachTag(handlerCtx))
+		r.Post("/detach_tag", handlers.NewDetachTag(handlerCtx))
+		r.Get("/get_projects", handlers.NewGetProjects(handlerCtx))
+		r.Post("/create_project", handlers.NewCreateProject(handlerCtx))
+		r.Post("/update_project", handlers.NewUpdateProject(handlerCtx))
+		r.Post("/delete_project", handlers.NewDeleteProject(handlerCtx))
+		r.Post("/move_task", handlers.NewMoveTask(handlerCtx))
+		r.Post("/set_task_parent", handlers.NewSetTaskParent(handlerCtx))
+		r.Get("/get_board", handlers.NewGetBoard(handlerCtx))
+		r.Get("/search_tasks", handlers.NewSearchTasks(handlerCtx))
+		r.Get("/get_views", handlers.NewGetViews(handlerCtx))
+		r.Get("/get_view", handlers.NewGetView(handlerCtx))
+		r.Post("/create_view", handlers.NewCreateView(handlerCtx))
+		r.Post("/update_view", handlers.NewUpdateView(handlerCtx))
+		r.Post("/delete_view", handlers.NewDeleteView(handlerCtx))
+		r.Get("/get_view_tasks", handlers.NewGetViewTasks(handlerCtx))
+	})
+
+	purgerCtx, stopPurger := context.WithCancel(context.Background())
+	defer stopPurger()
+	go archive.RunPurger(purgerCtx, logger, storage, &cfg.ArchiveConfig)
+
+	api.Open(router)
+	probe.SetReady(readyCheck)
+	logger.Info("service ready")
+
+	<-done
+	logger.Info("stopping server")
+
+	// load balancers see the instance drop out before it stops listening
+	probe.Drain()
+	time.Sleep(cfg.HTTPServer.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stopPurger()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("failed to stop server", slog.String("error", err.Error()))
+		return
+	}
+
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("failed to stop metrics server", slog.String("error", err.Error()))
+		}
+	}
+
+	err = storage.Close()
+
+	if err != nil {
+		logger.Error("failed to close storage", slog.String("error", err.Error()))
+		return
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("failed to flush traces", slog.String("error", err.Error()))
+	}
+
+	logger.Info("server stopped")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/memory"
+	"todo_list_service/internal/storage/migrate"
+	"todo_list_service/internal/storage/postgres"
+	"todo_list_service/internal/storage/sqlite"
+)
+
+// migratable is implemented by the sql backends.
+type migratable interface {
+	Migrator() (*migrate.Migrator, error)
+}
+
+// pooled is implemented by the sql backends.
+type pooled interface {
+	DB() *sql.DB
+}
+
+// pingable is implemented by the storages that depend on a database
+// server.
+type pingable interface {
+	Ping(ctx context.Context) error
+}
+
+// newStorage returns the configured storage with all migrations applied.
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case config.StorageDriverPostgres:
+		return postgres.New(&cfg.PgConfig)
+	case config.StorageDriverSqlite:
+		return sqlite.New(&cfg.SqliteConfig)
+	case config.StorageDriverMemory:
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver [%s]", cfg.StorageDriver)
+	}
+}
+
+// newMigrator connects to the configured database without migrating it.
+func newMigrator(cfg *config.Config) (*migrate.Migrator, func() error, error) {
+	var (
+		s   storage.Storage
+		err error
+	)
+
+	switch cfg.StorageDriver {
+	case config.StorageDriverPostgres:
+		s, err = postgres.Open(&cfg.PgConfig)
+	case config.StorageDriverSqlite:
+		s, err = sqlite.Open(&cfg.SqliteConfig)
+	default:
+		return nil, nil, fmt.Errorf("storage driver [%s] does not support migrations", cfg.StorageDriver)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := s.(migratable).Migrator()
+	if err != nil {
+		_ = s.Close()
+		return nil, nil, err
+	}
+
+	return migrator, s.Close, nil
+}
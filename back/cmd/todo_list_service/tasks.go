@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+)
+
+func runTasks(cfg *config.Config, args []string) error {
+	cmd, args, err := subcommand(args)
+	if err != nil {
+		return err
+	}
+	if cmd != "export" {
+		return fmt.Errorf("unknown tasks subcommand [%s]\n\n%s", cmd, usage)
+	}
+
+	flags := flag.NewFlagSet("tasks export", flag.ContinueOnError)
+	username := flags.String("user", "", "username whose tasks are exported")
+	format := flags.String("format", "json", "output format: json or csv")
+	output := flags.String("output", "", "output file, stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" {
+		return fmt.Errorf("-user is required")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format [%s]", *format)
+	}
+
+	s, err := newStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+
+	user, err := s.GetUserByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := s.GetTasks(ctx, user.ID, storage.MaxInt)
+	if err != nil {
+		return err
+	}
+
+	// archived tasks are the user's too, they follow the active ones
+	archived, err := s.GetArchivedTasks(ctx, user.ID, storage.MaxInt)
+	if err != nil {
+		return err
+	}
+	tasks = append(tasks, archived...)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		return exportTasksCSV(w, tasks)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{"tasks": tasks})
+}
+
+func exportTasksCSV(w io.Writer, tasks []storage.Task) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"id", "title", "description", "status", "priority", "creation_ts", "start_ts", "due_ts", "archived_ts"}); err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		record := []string{
+			strconv.Itoa(task.ID),
+			task.Title,
+			task.Description,
+			strconv.Itoa(int(task.Status)),
+			strconv.Itoa(task.Priority),
+			task.CreationTs.Format(time.RFC3339),
+			formatOptionalTs(task.StartTs),
+			formatOptionalTs(task.DueTs),
+			formatOptionalTs(task.ArchivedTs),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatOptionalTs(ts *time.Time) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.Format(time.RFC3339)
+}
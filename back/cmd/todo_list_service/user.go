@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"todo_list_service/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func runUser(cfg *config.Config, args []string) error {
+	cmd, args, err := subcommand(args)
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("user "+cmd, flag.ContinueOnError)
+	username := flags.String("username", "", "username")
+	email := flags.String("email", "", "email")
+	password := flags.String("password", "", "password, read from stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if cmd != "list" && *username == "" {
+		return fmt.Errorf("-username is required")
+	}
+
+	storage, err := newStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	ctx := context.Background()
+
+	switch cmd {
+	case "create":
+		hashedPassword, err := hashPassword(*password)
+		if err != nil {
+			return err
+		}
+
+		userID, err := storage.CreateUser(ctx, *username, hashedPassword, *email)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user [%s] with id [%d]\n", *username, userID)
+	case "reset-password":
+		user, err := storage.GetUserByUsername(ctx, *username)
+		if err != nil {
+			return err
+		}
+
+		hashedPassword, err := hashPassword(*password)
+		if err != nil {
+			return err
+		}
+
+		if err := storage.UpdateUserPassword(ctx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+		fmt.Printf("password of user [%s] updated\n", *username)
+	case "list":
+		users, err := storage.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tCREATED AT")
+		for _, user := range users {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", user.ID, user.Username, user.Email, user.CreationTs.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown user subcommand [%s]\n\n%s", cmd, usage)
+	}
+
+	return nil
+}
+
+// hashPassword hashes the given password or the first line of stdin.
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return "", fmt.Errorf("password must not be empty")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return string(hashedPassword), nil
+}
@@ -0,0 +1,45 @@
+package archive
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+)
+
+// RunPurger periodically deletes tasks archived longer than the configured
+// retention ago. It blocks until ctx is cancelled, a zero retention disables it.
+func RunPurger(ctx context.Context, log *slog.Logger, tasks storage.TaskRepository, cfg *config.ArchiveConfig) {
+	log = log.With(slog.String("component", "archive/purger"))
+
+	if cfg.Retention <= 0 || cfg.PurgeInterval <= 0 {
+		log.Info("archive purger disabled")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purge(ctx, log, tasks, cfg.Retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purge(ctx context.Context, log *slog.Logger, tasks storage.TaskRepository, retention time.Duration) {
+	purged, err := tasks.PurgeArchivedTasks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		log.Error("failed to purge archived tasks", slog.String("error", err.Error()))
+		return
+	}
+
+	if purged > 0 {
+		log.Info("purged archived tasks", slog.Int("count", purged))
+	}
+}
@@ -9,11 +9,23 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverSqlite   = "sqlite"
+	StorageDriverMemory   = "memory"
+)
+
 type Config struct {
 	Env           string `yaml:"env" env-default:"local"`
+	StorageDriver string `yaml:"storage_driver" env:"STORAGE_DRIVER" env-default:"postgres"`
 	HTTPServer    `yaml:"http_server"`
 	PgConfig      `yaml:"pg_config"`
+	SqliteConfig  `yaml:"sqlite_config"`
 	MetricsConfig `yaml:"metrics_config"`
+	TracingConfig `yaml:"tracing_config"`
+	LogConfig     `yaml:"log_config"`
+	ArchiveConfig `yaml:"archive_config"`
+	TasksConfig   `yaml:"tasks_config"`
 }
 
 func (server *HTTPServer) Address() string {
@@ -25,11 +37,16 @@ type HTTPServer struct {
 	Port        int           `yaml:"port" env:"APP_PORT" env-default:"80"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"30s"`
-	Session     Session       `yaml:"session"`
+	// ReadyTimeout bounds the storage check of the /readyz probe.
+	ReadyTimeout time.Duration `yaml:"ready_timeout" env-default:"2s"`
+	// DrainDelay keeps serving after a shutdown signal with /readyz failing,
+	// giving load balancers time to notice before the listener closes.
+	DrainDelay time.Duration `yaml:"drain_delay" env:"DRAIN_DELAY" env-default:"0s"`
+	Session    Session       `yaml:"session"`
 }
 
 type Session struct {
-	SecretKey string `yaml:"secret_key" env-default:"secret_key"`
+	SecretKey string `yaml:"secret_key" env-default:"secret_key" secret:"true"`
 	Secure    bool   `yaml:"secure" env-default:"false"`
 	MaxAge    int    `yaml:"max_age" env-default:"604800"`
 }
@@ -37,12 +54,65 @@ type PgConfig struct {
 	Host          string `yaml:"host" env:"PG_HOST" env-default:"localhost"`
 	Port          int    `yaml:"port" env:"PG_PORT" env-default:"5432"`
 	User          string `yaml:"user" env:"PG_USER" env-default:"todo_list"`
-	Password      string `yaml:"password" env:"PG_PASSWORD" env-default:"pg"`
+	Password      string `yaml:"password" env:"PG_PASSWORD" env-default:"pg" secret:"true"`
 	DBName        string `yaml:"db_name" env:"PG_DB_NAME" env-default:"todo_list"`
-	MigrationsDir string `yaml:"migrations_dir" env:"PG_MIGRATIONS_DIR" env-default:"/app/migrations"`
+	MigrationsDir string `yaml:"migrations_dir" env:"PG_MIGRATIONS_DIR"` // overrides the embedded migrations
+}
+
+type SqliteConfig struct {
+	Path string `yaml:"path" env:"SQLITE_PATH" env-default:"todo_list.db"`
+}
+
+type ArchiveConfig struct {
+	Retention     time.Duration `yaml:"retention" env:"ARCHIVE_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+type TasksConfig struct {
+	// SubtaskClosePolicy is what closing a task does to its open subtasks:
+	// block, cascade or ignore.
+	SubtaskClosePolicy string `yaml:"subtask_close_policy" env:"SUBTASK_CLOSE_POLICY" env-default:"block"`
 }
 
+// MetricsConfig is the listener serving Prometheus metrics on /metrics,
+// kept apart from the public API so that it can stay internal.
 type MetricsConfig struct {
+	Enabled bool          `yaml:"enabled" env:"METRICS_ENABLED" env-default:"true"`
+	Address string        `yaml:"address" env:"METRICS_ADDRESS" env-default:"0.0.0.0:9090"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// TracingConfig selects where OpenTelemetry spans go: "otlp" sends them
+// over OTLP/HTTP to Endpoint, "stdout" and "file" write them as JSON to
+// stdout or FilePath. An empty exporter turns tracing off.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"http://localhost:4318"`
+	FilePath    string  `yaml:"file_path" env:"TRACING_FILE_PATH" env-default:"traces.json"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"todo_list_service"`
+}
+
+// LogConfig sets up the service log. Format is "text" or "json", Levels
+// overrides Level for the loggers tagged with a component, e.g.
+// "middleware/logger" or "http-server/handlers". With File.Path set logs
+// go to that file, rotated, instead of stdout.
+//
+// Config fields and request fields tagged secret:"true" are redacted
+// wherever they are logged.
+type LogConfig struct {
+	Format string            `yaml:"format" env:"LOG_FORMAT" env-default:"text"`
+	Level  string            `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	Levels map[string]string `yaml:"levels"`
+	File   LogFile           `yaml:"file"`
+}
+
+type LogFile struct {
+	Path       string `yaml:"path" env:"LOG_FILE_PATH"`
+	MaxSizeMB  int    `yaml:"max_size_mb" env-default:"100"`
+	MaxBackups int    `yaml:"max_backups" env-default:"5"`
+	MaxAgeDays int    `yaml:"max_age_days" env-default:"30"`
+	Compress   bool   `yaml:"compress" env-default:"false"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,105 @@
+// Package health answers the orchestrator's probes: /healthz tells that the
+// process is alive, /readyz whether it should get traffic.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+	"todo_list_service/internal/http-server/apierror"
+)
+
+// Check reports whether a dependency the service needs is reachable.
+type Check func(ctx context.Context) error
+
+// Probe tracks the state of the service. It starts out not ready, becomes
+// ready once SetReady is called after the storage is set up and migrated,
+// and stays not ready for good after Drain.
+type Probe struct {
+	log      *slog.Logger
+	timeout  time.Duration
+	check    atomic.Pointer[Check]
+	draining atomic.Bool
+}
+
+func NewProbe(log *slog.Logger, timeout time.Duration) *Probe {
+	return &Probe{log: log, timeout: timeout}
+}
+
+// SetReady marks the service as booted, check is run on every readiness
+// probe from then on.
+func (p *Probe) SetReady(check Check) {
+	p.check.Store(&check)
+}
+
+// Drain marks the service as shutting down so that load balancers stop
+// sending it requests.
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+// Live answers the liveness probe, a process able to answer is alive.
+func (p *Probe) Live(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok", "")
+}
+
+// Ready answers the readiness probe, running the dependency check with the
+// probe's timeout.
+func (p *Probe) Ready(w http.ResponseWriter, r *http.Request) {
+	if p.draining.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "shutting down")
+		return
+	}
+
+	check := p.check.Load()
+	if check == nil {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "starting")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), p.timeout)
+	defer cancel()
+
+	// the error may name hosts and users, it is only logged
+	if err := (*check)(ctx); err != nil {
+		p.log.Error("readiness check failed", slog.String("error", err.Error()))
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "storage is not reachable")
+		return
+	}
+	writeStatus(w, http.StatusOK, "ok", "")
+}
+
+func writeStatus(w http.ResponseWriter, code int, status, reason string) {
+	resp := map[string]string{"status": status}
+	if reason != "" {
+		resp["reason"] = reason
+	}
+	resultJSON, _ := json.Marshal(resp)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	w.Write(resultJSON)
+}
+
+// Gate lets the server listen, and answer probes, while the service boots:
+// it passes requests on once Open is called and answers 503 before.
+type Gate struct {
+	next atomic.Pointer[http.Handler]
+}
+
+func (g *Gate) Open(next http.Handler) {
+	g.next.Store(&next)
+}
+
+func (g *Gate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	next := g.next.Load()
+	if next == nil {
+		apierror.Write(w, r, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Service is starting", nil)
+		return
+	}
+	(*next).ServeHTTP(w, r)
+}
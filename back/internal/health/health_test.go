@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo_list_service/internal/http-server/apierror"
+)
+
+// probe runs handler and checks the answer has status code and body.
+func probe(t *testing.T, handler http.HandlerFunc, code int, body map[string]string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != code {
+		t.Errorf("status %d, want %d", w.Code, code)
+	}
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+		t.Errorf("Cache-Control %q, want no-store", cacheControl)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if !maps.Equal(got, body) {
+		t.Errorf("body %v, want %v", got, body)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	p := NewProbe(slog.New(slog.NewTextHandler(io.Discard, nil)), 50*time.Millisecond)
+
+	ok := map[string]string{"status": "ok"}
+	unavailable := func(reason string) map[string]string {
+		return map[string]string{"status": "unavailable", "reason": reason}
+	}
+
+	probe(t, p.Live, http.StatusOK, ok)
+	probe(t, p.Ready, http.StatusServiceUnavailable, unavailable("starting"))
+
+	var checkErr error
+	p.SetReady(func(ctx context.Context) error { return checkErr })
+	probe(t, p.Ready, http.StatusOK, ok)
+
+	checkErr = errors.New("dial tcp db.internal:5432: connection refused")
+	probe(t, p.Ready, http.StatusServiceUnavailable, unavailable("storage is not reachable"))
+
+	// a check that hangs is given up after the timeout
+	p.SetReady(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	probe(t, p.Ready, http.StatusServiceUnavailable, unavailable("storage is not reachable"))
+
+	p.SetReady(func(ctx context.Context) error { return nil })
+	probe(t, p.Ready, http.StatusOK, ok)
+
+	p.Drain()
+	probe(t, p.Ready, http.StatusServiceUnavailable, unavailable("shutting down"))
+	probe(t, p.Live, http.StatusOK, ok)
+}
+
+func TestGate(t *testing.T) {
+	gate := &Gate{}
+
+	w := httptest.NewRecorder()
+	gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("closed gate answered %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	var resp apierror.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if resp.Error.Code != apierror.CodeUnavailable {
+		t.Errorf("closed gate answered code %q, want %q", resp.Error.Code, apierror.CodeUnavailable)
+	}
+
+	gate.Open(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w = httptest.NewRecorder()
+	gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("open gate answered %d, want the handler's %d", w.Code, http.StatusTeapot)
+	}
+}
@@ -0,0 +1,56 @@
+// Package apierror writes the body of failed requests. Handlers and
+// middlewares all answer with the same envelope:
+//
+//	{"error": {"code": "invalid_request", "message": "Incorrect request",
+//	  "fields": {"color": "must be a hex color like #1a2b3c"},
+//	  "request_id": "host/abc-000001"}}
+//
+// code is meant for programs and message for people, fields names the
+// invalid request fields, when known, and request_id is the chi request id
+// to quote when reporting a problem.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeAlreadyExists    = "already_exists"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal"
+	CodeUnavailable      = "unavailable"
+)
+
+type Response struct {
+	Error Error `json:"error"`
+}
+
+type Error struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// Write answers the request with status and the error envelope.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, message string, fields map[string]string) {
+	resultJSON, _ := json.Marshal(Response{Error: Error{
+		Code:      code,
+		Message:   message,
+		Fields:    fields,
+		RequestID: middleware.GetReqID(r.Context()),
+	}})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(resultJSON)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+type DeleteProjectRequest struct {
+	ProjectID int `json:"project_id"`
+}
+
+func NewDeleteProject(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewDeleteProject", r)
+
+		var req DeleteProjectRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		if err := handlerCtx.Storage.DeleteProject(r.Context(), req.ProjectID, userID); err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to delete project [%d]", req.ProjectID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"project_id": req.ProjectID}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
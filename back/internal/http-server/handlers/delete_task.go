@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+type DeleteTaskRequest struct {
+	TaskID int `json:"task_id"`
+}
+
+func NewDeleteTask(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewDeleteTask", r)
+
+		var req DeleteTaskRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		if err := handlerCtx.Storage.DeleteTask(r.Context(), req.TaskID, userID); err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to delete task [%d]", req.TaskID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"task_id": req.TaskID}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+type DetachTagRequest struct {
+	TaskID int `json:"task_id"`
+	TagID  int `json:"tag_id"`
+}
+
+func NewDetachTag(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewDetachTag", r)
+
+		var req DetachTagRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		task, err := handlerCtx.Storage.DetachTag(r.Context(), req.TaskID, req.TagID, userID)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to detach tag [%d] from task [%d]", req.TagID, req.TaskID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"task": *task}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
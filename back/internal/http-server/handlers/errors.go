@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"todo_list_service/internal/http-server/apierror"
+	"todo_list_service/internal/storage"
+)
+
+// errorResponses maps the errors of the storage to responses. The first
+// match wins, so the errors of single operations come before the kinds of
+// error they belong to.
+var errorResponses = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{storage.ErrOpenSubtasks, http.StatusConflict, "open_subtasks", "Task has open subtasks"},
+	{storage.ErrTaskCycle, http.StatusConflict, "task_cycle", "Task cannot be nested under its own subtask"},
+	{storage.ErrInvalidTransition, http.StatusConflict, "invalid_transition", "Status transition is not allowed"},
+	{storage.ErrTaskDone, http.StatusConflict, "task_done", "Done tasks cannot be reordered"},
+	{storage.ErrNothingToUndo, http.StatusConflict, "nothing_to_undo", "Nothing to undo"},
+	{storage.ErrNothingToRedo, http.StatusConflict, "nothing_to_redo", "Nothing to redo"},
+	{storage.ErrUnknownViewName, http.StatusConflict, "unknown_view_name", "View names a missing tag or project"},
+	{storage.ErrInvalidAnchor, http.StatusBadRequest, apierror.CodeInvalidRequest, "Anchor is not another open task of the same project"},
+	{storage.ErrInvalidCursor, http.StatusBadRequest, apierror.CodeInvalidRequest, "Cursor does not belong to this listing"},
+	{storage.ErrNotFound, http.StatusNotFound, apierror.CodeNotFound, "Not found"},
+	{storage.ErrAlreadyExists, http.StatusConflict, apierror.CodeAlreadyExists, "Already exists"},
+	{storage.ErrForbidden, http.StatusForbidden, apierror.CodeForbidden, "Forbidden"},
+	{storage.ErrConflict, http.StatusConflict, apierror.CodeConflict, "Not allowed in the current state"},
+}
+
+// writeError logs msg with err and answers with the response err maps to.
+// Errors the client caused are logged at info level, any other error is an
+// internal server error.
+func writeError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, msg string, err error) {
+	for _, resp := range errorResponses {
+		if errors.Is(err, resp.err) {
+			logger.Info(msg, slog.String("error", err.Error()))
+			apierror.Write(w, r, resp.status, resp.code, resp.message, nil)
+			return
+		}
+	}
+
+	logger.Error(msg, slog.String("error", err.Error()))
+	internalError(w, r)
+}
+
+// writeNameError is writeError for operations that name something. A name
+// that is already taken is answered with message, naming field in the
+// response.
+func writeNameError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, msg string, err error, field, message string) {
+	if errors.Is(err, storage.ErrAlreadyExists) {
+		logger.Info(msg, slog.String("error", err.Error()))
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, message, map[string]string{field: "is already taken"})
+		return
+	}
+
+	writeError(w, r, logger, msg, err)
+}
+
+// badRequest answers a request that failed validation, fields maps the
+// invalid request fields to what is wrong with them.
+func badRequest(w http.ResponseWriter, r *http.Request, message string, fields map[string]string) {
+	apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, message, fields)
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized", nil)
+}
+
+func internalError(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error", nil)
+}
+
+func handleDecodeError(err error, w http.ResponseWriter, r *http.Request, logger *slog.Logger) {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		logger.Error("request body is empty")
+		badRequest(w, r, "Empty request", nil)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		logger.Error("failed to decode request body", slog.String("error", err.Error()))
+		badRequest(w, r, "Failed to decode request", map[string]string{typeErr.Field: "must be " + jsonKind(typeErr.Type)})
+	default:
+		logger.Error("failed to decode request body", slog.String("error", err.Error()))
+		badRequest(w, r, "Failed to decode request", nil)
+	}
+}
+
+// jsonKind names the JSON value a Go type is decoded from.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a " + t.String()
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo_list_service/internal/http-server/apierror"
+	"todo_list_service/internal/storage"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// serveError answers a request behind the request id middleware with
+// write and returns the decoded envelope along with the status.
+func serveError(t *testing.T, write func(w http.ResponseWriter, r *http.Request)) (int, apierror.Error) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	middleware.RequestID(http.HandlerFunc(write)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("content type %q, want application/json", contentType)
+	}
+
+	var resp apierror.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if resp.Error.RequestID == "" {
+		t.Error("error has no request id")
+	}
+	return w.Code, resp.Error
+}
+
+func TestWriteError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{storage.ErrOpenSubtasks, http.StatusConflict, "open_subtasks", "Task has open subtasks"},
+		{storage.ErrTaskCycle, http.StatusConflict, "task_cycle", "Task cannot be nested under its own subtask"},
+		{storage.ErrInvalidTransition, http.StatusConflict, "invalid_transition", "Status transition is not allowed"},
+		{storage.ErrTaskDone, http.StatusConflict, "task_done", "Done tasks cannot be reordered"},
+		{storage.ErrNothingToUndo, http.StatusConflict, "nothing_to_undo", "Nothing to undo"},
+		{storage.ErrNothingToRedo, http.StatusConflict, "nothing_to_redo", "Nothing to redo"},
+		{storage.ErrUnknownViewName, http.StatusConflict, "unknown_view_name", "View names a missing tag or project"},
+		{storage.ErrInvalidAnchor, http.StatusBadRequest, "invalid_request", "Anchor is not another open task of the same project"},
+		{storage.ErrInvalidCursor, http.StatusBadRequest, "invalid_request", "Cursor does not belong to this listing"},
+		{storage.ErrNotFound, http.StatusNotFound, "not_found", "Not found"},
+		{storage.ErrAlreadyExists, http.StatusConflict, "already_exists", "Already exists"},
+		{storage.ErrForbidden, http.StatusForbidden, "forbidden", "Forbidden"},
+		{storage.ErrConflict, http.StatusConflict, "conflict", "Not allowed in the current state"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "internal", "Internal server error"},
+	}
+
+	tested := map[error]bool{}
+	for _, tt := range tests {
+		tested[tt.err] = true
+	}
+	for _, resp := range errorResponses {
+		if !tested[resp.err] {
+			t.Errorf("no case for error %q", resp.err)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			// the storage wraps its errors with the operation
+			err := fmt.Errorf("'storage.memory.Op: task [1]: %w'", tt.err)
+			status, got := serveError(t, func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, logger, "failed", err)
+			})
+
+			if status != tt.status || got.Code != tt.code || got.Message != tt.message {
+				t.Errorf("got %d %q %q, want %d %q %q", status, got.Code, got.Message, tt.status, tt.code, tt.message)
+			}
+			if got.Fields != nil {
+				t.Errorf("fields %v, want none", got.Fields)
+			}
+		})
+	}
+}
+
+func TestWriteNameError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	err := fmt.Errorf("'storage.memory.CreateTag: tag with name [home]: %w'", storage.ErrAlreadyExists)
+	status, got := serveError(t, func(w http.ResponseWriter, r *http.Request) {
+		writeNameError(w, r, logger, "failed", err, "tag.name", "Tag name is already taken")
+	})
+	if status != http.StatusConflict || got.Code != apierror.CodeAlreadyExists || got.Message != "Tag name is already taken" {
+		t.Errorf("got %d %q %q", status, got.Code, got.Message)
+	}
+	if got.Fields["tag.name"] != "is already taken" {
+		t.Errorf("fields %v do not name tag.name", got.Fields)
+	}
+
+	// other errors are left to writeError
+	status, got = serveError(t, func(w http.ResponseWriter, r *http.Request) {
+		writeNameError(w, r, logger, "failed", storage.ErrNotFound, "tag.name", "Tag name is already taken")
+	})
+	if status != http.StatusNotFound || got.Code != apierror.CodeNotFound || got.Fields != nil {
+		t.Errorf("got %d %q %v", status, got.Code, got.Fields)
+	}
+}
+
+func TestHandleDecodeError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name    string
+		body    string
+		message string
+		fields  map[string]string
+	}{
+		{"empty", ``, "Empty request", nil},
+		{"malformed", `{"task":`, "Failed to decode request", nil},
+		{"string for number", `{"task_id": "one"}`, "Failed to decode request", map[string]string{"task_id": "must be a number"}},
+		{"number for string", `{"task": {"title": 1}}`, "Failed to decode request", map[string]string{"task.title": "must be a string"}},
+		{"object for array", `{"task": {"tag_ids": {}}}`, "Failed to decode request", map[string]string{"task.tag_ids": "must be an array"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				TaskID int          `json:"task_id"`
+				Task   storage.Task `json:"task"`
+			}
+			err := decodeRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), &req)
+			if err == nil {
+				t.Fatalf("decoded %q", tt.body)
+			}
+
+			status, got := serveError(t, func(w http.ResponseWriter, r *http.Request) {
+				handleDecodeError(err, w, r, logger)
+			})
+			if status != http.StatusBadRequest || got.Code != apierror.CodeInvalidRequest || got.Message != tt.message {
+				t.Errorf("got %d %q %q, want %d %q %q", status, got.Code, got.Message, http.StatusBadRequest, apierror.CodeInvalidRequest, tt.message)
+			}
+			if !maps.Equal(got.Fields, tt.fields) {
+				t.Errorf("fields %v, want %v", got.Fields, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+func NewGetArchivedTasks(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetArchivedTasks", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		tasks, err := handlerCtx.Storage.GetArchivedTasks(r.Context(), userID, storage.MaxInt)
+		if err != nil {
+			writeError(w, r, logger, "failed to get archived tasks from db", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"tasks": tasks}
+		tasksJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(tasksJSON)
+	}
+}
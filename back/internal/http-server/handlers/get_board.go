@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+func NewGetBoard(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetBoard", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		// without project_id the board of the inbox is shown
+		projectID := 0
+		if rawID := r.URL.Query().Get("project_id"); rawID != "" {
+			id, err := strconv.Atoi(rawID)
+			if err != nil || id <= 0 {
+				logger.Error("invalid project id", slog.String("project_id", rawID))
+				badRequest(w, r, "Incorrect request", map[string]string{"project_id": "must be a positive integer"})
+				return
+			}
+			projectID = id
+		}
+
+		projects, err := handlerCtx.Storage.GetProjects(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to get projects from db", err)
+			return
+		}
+
+		var project *storage.Project
+		for i := range projects {
+			if projects[i].ID == projectID || (projectID == 0 && projects[i].IsInbox) {
+				project = &projects[i]
+				break
+			}
+		}
+		if project == nil {
+			writeError(w, r, logger, "failed to get board", fmt.Errorf("project [%d]: %w", projectID, storage.ErrNotFound))
+			return
+		}
+
+		tasks, err := handlerCtx.Storage.ListTasks(r.Context(), userID, storage.TaskFilter{ProjectID: project.ID}, storage.MaxInt)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get tasks of project [%d]", project.ID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{
+			"project": *project,
+			"columns": storage.GroupByStatus(project.Workflow, tasks),
+		}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
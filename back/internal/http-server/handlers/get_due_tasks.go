@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+type GetDueTasksRequest struct {
+	// Window is one of "overdue", "today" or "week".
+	Window string `json:"window"`
+}
+
+func NewGetDueTasks(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetDueTasks", r)
+
+		var req GetDueTasksRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		user, err := handlerCtx.Storage.GetUserByID(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to get user from db", err)
+			return
+		}
+
+		loc, err := storage.LoadTimeZone(user.TimeZone)
+		if err != nil {
+			writeError(w, r, logger, "failed to load user time zone", err)
+			return
+		}
+
+		from, to, err := storage.DueRange(req.Window, time.Now(), loc)
+		if err != nil {
+			logger.Error("invalid due window", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"window": err.Error()})
+			return
+		}
+
+		tasks, err := handlerCtx.Storage.GetDueTasks(r.Context(), userID, from, to, storage.MaxInt)
+		if err != nil {
+			writeError(w, r, logger, "failed to get due tasks from db", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"tasks": tasks, "time_zone": loc.String()}
+		tasksJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(tasksJSON)
+	}
+}
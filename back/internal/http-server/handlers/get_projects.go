@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+func NewGetProjects(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetProjects", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		projects, err := handlerCtx.Storage.GetProjects(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to get projects from db", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"projects": projects}
+		projectsJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(projectsJSON)
+	}
+}
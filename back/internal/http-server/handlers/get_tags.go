@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+func NewGetTags(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetTags", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		tags, err := handlerCtx.Storage.GetTags(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to get tags from db", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"tags": tags}
+		tagsJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(tagsJSON)
+	}
+}
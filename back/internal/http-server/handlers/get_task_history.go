@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+type GetTaskHistoryRequest struct {
+	TaskID int `json:"task_id"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+func NewGetTaskHistory(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetTaskHistory", r)
+
+		var req GetTaskHistoryRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		if req.Limit < 0 || req.Limit > maxHistoryLimit || req.Offset < 0 {
+			logger.Error("invalid history page", slog.Int("limit", req.Limit), slog.Int("offset", req.Offset))
+			badRequest(w, r, "Incorrect request", map[string]string{"limit": fmt.Sprintf("must be between 0 and %d", maxHistoryLimit), "offset": "must not be negative"})
+			return
+		}
+		if req.Limit == 0 {
+			req.Limit = defaultHistoryLimit
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		// one extra action tells whether there is a next page
+		history, err := handlerCtx.Storage.GetTaskHistory(r.Context(), req.TaskID, userID, req.Limit+1, req.Offset)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get history of task [%d] from db", req.TaskID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"history": history}
+		if len(history) > req.Limit {
+			respMap["history"] = history[:req.Limit]
+			respMap["next_offset"] = req.Offset + req.Limit
+		}
+
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
@@ -2,37 +2,159 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 	"todo_list_service/internal/http-server/middleware/auth"
 	"todo_list_service/internal/storage"
+)
 
-	"github.com/go-chi/chi/v5/middleware"
+const (
+	defaultTasksLimit = 100
+	maxTasksLimit     = 500
 )
 
+// parseTaskFilter reads the optional listing filter from the query string:
+// project_id narrows the listing to one project, tag_ids is a comma separated
+// list of tag ids, tag_match is "any" (default) or "all", status is a comma
+// separated list of statuses, created_from and created_to are RFC 3339 times
+// bounding the creation time, sort is "priority" (default), "creation" or
+// "due" and cursor is the next_cursor of the previous page.
+func parseTaskFilter(query url.Values) (storage.TaskFilter, error) {
+	var filter storage.TaskFilter
+
+	if projectID := query.Get("project_id"); projectID != "" {
+		id, err := strconv.Atoi(projectID)
+		if err != nil || id <= 0 {
+			return filter, fmt.Errorf("invalid project id [%s]", projectID)
+		}
+		filter.ProjectID = id
+	}
+
+	if tagIDs := query.Get("tag_ids"); tagIDs != "" {
+		for _, rawID := range strings.Split(tagIDs, ",") {
+			tagID, err := strconv.Atoi(strings.TrimSpace(rawID))
+			if err != nil {
+				return filter, fmt.Errorf("invalid tag id [%s]", rawID)
+			}
+			filter.TagIDs = append(filter.TagIDs, tagID)
+		}
+	}
+
+	switch query.Get("tag_match") {
+	case "", "any":
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		return filter, fmt.Errorf("invalid tag_match [%s]", query.Get("tag_match"))
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, rawStatus := range strings.Split(statuses, ",") {
+			status, err := strconv.ParseInt(strings.TrimSpace(rawStatus), 10, 8)
+			if err != nil {
+				return filter, fmt.Errorf("invalid status [%s]", rawStatus)
+			}
+			filter.Statuses = append(filter.Statuses, int8(status))
+		}
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseQueryTs(query, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseQueryTs(query, "created_to"); err != nil {
+		return filter, err
+	}
+
+	sort, err := storage.ParseTaskSort(query.Get("sort"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		filter.After, err = storage.ParseTaskCursor(cursor, sort)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// parseQueryTs reads an optional RFC 3339 time from the query string.
+func parseQueryTs(query url.Values, param string) (*time.Time, error) {
+	rawTs := query.Get(param)
+	if rawTs == "" {
+		return nil, nil
+	}
+
+	ts, err := time.Parse(time.RFC3339, rawTs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s [%s]", param, rawTs)
+	}
+	return &ts, nil
+}
+
+// parseLimit reads the page size from the query string.
+func parseLimit(query url.Values, defaultLimit, maxLimit int) (int, error) {
+	rawLimit := query.Get("limit")
+	if rawLimit == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit <= 0 || limit > maxLimit {
+		return 0, fmt.Errorf("invalid limit [%s]", rawLimit)
+	}
+	return limit, nil
+}
+
 func NewGetTasks(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetTasks", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetTasks", r)
 
 		userID, ok := r.Context().Value(auth.ContextUserID).(int)
 		if !ok {
 			logger.Error("cannot get [user_id] from session")
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
+			unauthorized(w, r)
+			return
+		}
+
+		filter, err := parseTaskFilter(r.URL.Query())
+		if err != nil {
+			logger.Error("invalid task filter", slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid task filter", nil)
 			return
 		}
 
-		tasks, err := handlerCtx.Storage.GetTasks(userID, storage.MaxInt)
+		limit, err := parseLimit(r.URL.Query(), defaultTasksLimit, maxTasksLimit)
 		if err != nil {
-			logger.Error("failed to get tasks from db", slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			logger.Error("invalid tasks page", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"limit": err.Error()})
+			return
+		}
+
+		// one extra task tells whether there is a next page
+		tasks, err := handlerCtx.Storage.ListTasks(r.Context(), userID, filter, limit+1)
+		if err != nil {
+			writeError(w, r, logger, "failed to get tasks from db", err)
 			return
 		}
 
 		respMap := map[string]interface{}{"tasks": tasks}
+		if len(tasks) > limit {
+			respMap["tasks"] = tasks[:limit]
+			respMap["next_cursor"] = storage.CursorAfter(filter.OrderBy(), &tasks[limit-1]).String()
+		}
 		tasksJSON, err := json.Marshal(respMap)
 		if err != nil {
-			logger.Error("cannot serialize response", slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, r, logger, "cannot serialize response", err)
 			return
 		}
 
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+func NewGetView(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetView", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		viewID, err := parseViewID(r.URL.Query())
+		if err != nil {
+			logger.Error("invalid view id", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"id": err.Error()})
+			return
+		}
+
+		view, err := handlerCtx.Storage.GetSavedView(r.Context(), viewID, userID)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get view [%d] from db", viewID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"view": *view}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
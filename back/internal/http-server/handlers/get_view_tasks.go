@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+func NewGetViewTasks(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewGetViewTasks", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		viewID, err := parseViewID(r.URL.Query())
+		if err != nil {
+			logger.Error("invalid view id", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"id": err.Error()})
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query(), defaultTasksLimit, maxTasksLimit)
+		if err != nil {
+			logger.Error("invalid tasks page", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"limit": err.Error()})
+			return
+		}
+
+		view, err := handlerCtx.Storage.GetSavedView(r.Context(), viewID, userID)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get view [%d] from db", viewID), err)
+			return
+		}
+
+		scope, err := viewScope(r.Context(), handlerCtx.Storage, userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to load view scope", err)
+			return
+		}
+
+		// tags and projects may have been renamed or deleted since the view was saved
+		filter, err := storage.CompileView(view.Query, scope)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to compile view [%d]", viewID), err)
+			return
+		}
+
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			filter.After, err = storage.ParseTaskCursor(cursor, filter.OrderBy())
+			if err != nil {
+				logger.Error("invalid cursor", slog.String("error", err.Error()))
+				badRequest(w, r, "Incorrect request", map[string]string{"cursor": "is not a cursor of this listing"})
+				return
+			}
+		}
+
+		// one extra task tells whether there is a next page
+		tasks, err := handlerCtx.Storage.ListTasks(r.Context(), userID, filter, limit+1)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get tasks of view [%d] from db", viewID), err)
+			return
+		}
+
+		respMap := map[string]interface{}{"view": *view, "tasks": tasks}
+		if len(tasks) > limit {
+			respMap["tasks"] = tasks[:limit]
+			respMap["next_cursor"] = storage.CursorAfter(filter.OrderBy(), &tasks[limit-1]).String()
+		}
+
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
@@ -1,39 +1,36 @@
 package handlers
 
 import (
-	"errors"
-	"io"
 	"log/slog"
 	"net/http"
-	"todo_list_service/internal/storage/postgres"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/gorilla/sessions"
 )
 
 type HandlerContext struct {
 	Log     *slog.Logger
-	Storage *postgres.Storage
+	Storage storage.Storage
 	Store   *sessions.CookieStore
+	// ClosePolicy is applied to the open subtasks of tasks being closed.
+	ClosePolicy storage.ClosePolicy
 }
 
-func getLogger(log *slog.Logger, op, reqID string) *slog.Logger {
-	return log.With(
+// getLogger returns the logger of a handler, tagged with the chi request id
+// and, behind the auth middleware, the signed in user.
+func getLogger(log *slog.Logger, op string, r *http.Request) *slog.Logger {
+	log = log.With(
+		slog.String("component", "http-server/handlers"),
 		slog.String("op", op),
-		slog.String("request_id", reqID),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
 	)
-}
-
-func handleDecodeError(err error, w http.ResponseWriter, r *http.Request, logger *slog.Logger) {
-	if err != nil {
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
-			render.JSON(w, r, "Empty request")
-		} else {
-			logger.Error("failed to decode request body", slog.String("error", err.Error()))
-			render.JSON(w, r, "Failed to decode request")
-		}
+	if userID, ok := r.Context().Value(auth.ContextUserID).(int); ok {
+		log = log.With(slog.Int("user_id", userID))
 	}
+	return log
 }
 
 func decodeRequest(r *http.Request, req interface{}) error {
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"todo_list_service/internal/http-server/apierror"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/memory"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// newTestContext returns handlers backed by an empty in-memory storage and
+// a signed up user.
+func newTestContext(t *testing.T) (*HandlerContext, int) {
+	t.Helper()
+
+	s := memory.New()
+	userID, err := s.CreateUser(context.Background(), "user", "hashed", "user@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	return &HandlerContext{
+		Log:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Storage:     s,
+		ClosePolicy: storage.ClosePolicyIgnore,
+	}, userID
+}
+
+// serve runs handler on a request with body behind the request id
+// middleware, signed in as userID unless it is 0.
+func serve(handler http.HandlerFunc, userID int, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if userID != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), auth.ContextUserID, userID))
+	}
+
+	w := httptest.NewRecorder()
+	middleware.RequestID(handler).ServeHTTP(w, r)
+	return w
+}
+
+type taskResponse struct {
+	Task     storage.Task   `json:"task"`
+	Subtasks []storage.Task `json:"subtasks"`
+}
+
+func decodeTask(t *testing.T, w *httptest.ResponseRecorder) taskResponse {
+	t.Helper()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var resp taskResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return resp
+}
+
+// checkError checks that w holds an error envelope with status and code,
+// quoting the request id.
+func checkError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) apierror.Error {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+
+	var resp apierror.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if resp.Error.Code != code {
+		t.Errorf("code %q, want %q", resp.Error.Code, code)
+	}
+	if resp.Error.Message == "" {
+		t.Error("error has no message")
+	}
+	if resp.Error.RequestID == "" {
+		t.Error("error has no request id")
+	}
+	return resp.Error
+}
+
+func TestCreateTask(t *testing.T) {
+	handlerCtx, userID := newTestContext(t)
+	handler := NewCreateTask(handlerCtx)
+
+	resp := decodeTask(t, serve(handler, userID, `{"task": {"title": "write tests", "description": "handlers"}}`))
+	if resp.Task.ID <= 0 || resp.Task.UserID != userID || resp.Task.Title != "write tests" || resp.Task.Status != storage.TaskStatusOpened {
+		t.Errorf("created %+v", resp.Task)
+	}
+
+	if _, err := handlerCtx.Storage.GetTask(context.Background(), resp.Task.ID, userID); err != nil {
+		t.Errorf("GetTask of created task: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		userID int
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"empty body", userID, ``, http.StatusBadRequest, apierror.CodeInvalidRequest, ""},
+		{"wrong type", userID, `{"task": {"title": 1}}`, http.StatusBadRequest, apierror.CodeInvalidRequest, "task.title"},
+		{"due before start", userID, `{"task": {"title": "t", "start_ts": "2024-05-02T00:00:00Z", "due_ts": "2024-05-01T00:00:00Z"}}`, http.StatusBadRequest, apierror.CodeInvalidRequest, "task.due_ts"},
+		{"bad recurrence", userID, `{"task": {"title": "t", "recurrence": "every blue moon"}}`, http.StatusBadRequest, apierror.CodeInvalidRequest, "task.recurrence"},
+		{"signed out", 0, `{"task": {"title": "t"}}`, http.StatusUnauthorized, apierror.CodeUnauthorized, ""},
+		{"unknown project", userID, `{"task": {"title": "t", "project_id": 1000}}`, http.StatusNotFound, apierror.CodeNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkError(t, serve(handler, tt.userID, tt.body), tt.status, tt.code)
+			if tt.field != "" && got.Fields[tt.field] == "" {
+				t.Errorf("fields %v do not name %q", got.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	handlerCtx, userID := newTestContext(t)
+	ctx := context.Background()
+
+	parent, err := handlerCtx.Storage.CreateTask(ctx, &storage.Task{Title: "parent", UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	child, err := handlerCtx.Storage.CreateTask(ctx, &storage.Task{Title: "child", UserID: userID, ParentID: &parent.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	otherID, err := handlerCtx.Storage.CreateUser(ctx, "other", "hashed", "other@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	handler := NewGetTask(handlerCtx)
+
+	resp := decodeTask(t, serve(handler, userID, `{"task_id": `+strconv.Itoa(parent.ID)+`}`))
+	if resp.Task.ID != parent.ID || resp.Task.Title != "parent" {
+		t.Errorf("got task %+v", resp.Task)
+	}
+	if len(resp.Subtasks) != 1 || resp.Subtasks[0].ID != child.ID {
+		t.Errorf("got subtasks %+v, want task %d", resp.Subtasks, child.ID)
+	}
+
+	checkError(t, serve(handler, userID, `{"task_id": 1000}`), http.StatusNotFound, apierror.CodeNotFound)
+	checkError(t, serve(handler, otherID, `{"task_id": `+strconv.Itoa(parent.ID)+`}`), http.StatusNotFound, apierror.CodeNotFound)
+	checkError(t, serve(handler, 0, `{"task_id": `+strconv.Itoa(parent.ID)+`}`), http.StatusUnauthorized, apierror.CodeUnauthorized)
+	checkError(t, serve(handler, userID, `{"task_id": "one"}`), http.StatusBadRequest, apierror.CodeInvalidRequest)
+}
+
+func TestUpdateTask(t *testing.T) {
+	handlerCtx, userID := newTestContext(t)
+
+	task, err := handlerCtx.Storage.CreateTask(context.Background(), &storage.Task{Title: "draft", UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	handler := NewUpdateTask(handlerCtx)
+	body := func(title string, status int8) string {
+		return `{"task": {"id": ` + strconv.Itoa(task.ID) + `, "title": "` + title + `", "status": ` + strconv.Itoa(int(status)) + `}}`
+	}
+
+	checkError(t, serve(handler, userID, body("final", storage.TaskStatusReview)), http.StatusConflict, "invalid_transition")
+
+	resp := decodeTask(t, serve(handler, userID, body("final", storage.TaskStatusInProgress)))
+	if resp.Task.ID != task.ID || resp.Task.Title != "final" || resp.Task.Status != storage.TaskStatusInProgress {
+		t.Errorf("updated %+v", resp.Task)
+	}
+
+	got, err := handlerCtx.Storage.GetTask(context.Background(), task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Title != "final" {
+		t.Errorf("stored title %q, want %q", got.Title, "final")
+	}
+
+	checkError(t, serve(handler, userID, `{"task": {"id": 1000, "title": "gone", "status": 1}}`), http.StatusNotFound, apierror.CodeNotFound)
+	checkError(t, serve(handler, 0, body("final", storage.TaskStatusInProgress)), http.StatusUnauthorized, apierror.CodeUnauthorized)
+	checkError(t, serve(handler, userID, ``), http.StatusBadRequest, apierror.CodeInvalidRequest)
+}
+
+func TestUpdatePriority(t *testing.T) {
+	handlerCtx, userID := newTestContext(t)
+	ctx := context.Background()
+
+	var ids []int
+	for _, title := range []string{"third", "second", "first"} {
+		task, err := handlerCtx.Storage.CreateTask(ctx, &storage.Task{Title: title, UserID: userID})
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+	third, second, first := ids[0], ids[1], ids[2]
+
+	handler := NewUpdatePriority(handlerCtx)
+
+	resp := decodeTask(t, serve(handler, userID, `{"task_id": `+strconv.Itoa(third)+`, "position": "before", "anchor_id": `+strconv.Itoa(second)+`}`))
+	if resp.Task.ID != third {
+		t.Errorf("moved task %d, want %d", resp.Task.ID, third)
+	}
+
+	tasks, err := handlerCtx.Storage.GetTasks(ctx, userID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	var order []int
+	for _, task := range tasks {
+		order = append(order, task.ID)
+	}
+	if want := []int{first, third, second}; !slices.Equal(order, want) {
+		t.Errorf("order %v, want %v", order, want)
+	}
+
+	done, err := handlerCtx.Storage.UpdateTask(ctx, &storage.Task{ID: first, UserID: userID, Title: "first", Status: storage.TaskStatusClosed}, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		userID int
+		body   string
+		status int
+		code   string
+	}{
+		{"unknown position", userID, `{"task_id": ` + strconv.Itoa(second) + `, "position": "middle"}`, http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"missing anchor", userID, `{"task_id": ` + strconv.Itoa(second) + `, "position": "after"}`, http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"anchor is the task", userID, `{"task_id": ` + strconv.Itoa(second) + `, "position": "after", "anchor_id": ` + strconv.Itoa(second) + `}`, http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"done task", userID, `{"task_id": ` + strconv.Itoa(done.ID) + `, "position": "top"}`, http.StatusConflict, "task_done"},
+		{"unknown task", userID, `{"task_id": 1000, "position": "top"}`, http.StatusNotFound, apierror.CodeNotFound},
+		{"signed out", 0, `{"task_id": ` + strconv.Itoa(second) + `, "position": "top"}`, http.StatusUnauthorized, apierror.CodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, serve(handler, tt.userID, tt.body), tt.status, tt.code)
+		})
+	}
+}
@@ -2,38 +2,32 @@ package handlers
 
 import (
 	"fmt"
-	"log/slog"
 	"net/http"
 	"todo_list_service/internal/http-server/middleware/auth"
-
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 func NewLogout(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "handlers.NewLogout", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "handlers.NewLogout", r)
 
 		session, err := handlerCtx.Store.Get(r, auth.SessionName)
 		if err != nil {
-			logger.Error("failed to get session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to get session", err)
 			return
 		}
 
 		userID := session.Values[string(auth.ContextUserID)]
 
-		user, err := handlerCtx.Storage.GetUserByID(userID.(int))
+		user, err := handlerCtx.Storage.GetUserByID(r.Context(), userID.(int))
 		if err != nil {
-			logger.Error("failed to get user from db", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to get user from db", err)
 			return
 		}
 
 		delete(session.Values, string(auth.ContextUserID))
 
 		if err := session.Save(r, w); err != nil {
-			logger.Error("failed to save session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to save session", err)
 			return
 		}
 
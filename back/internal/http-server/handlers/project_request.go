@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"strings"
+	"todo_list_service/internal/storage"
+	"unicode/utf8"
+)
+
+const maxProjectNameLength = 128
+
+// validProjectName trims the project name and reports whether it is
+// acceptable.
+func validProjectName(name *string) bool {
+	*name = strings.TrimSpace(*name)
+	return *name != "" && utf8.RuneCountInString(*name) <= maxProjectNameLength
+}
+
+// validateWorkflow checks a workflow sent with a project. A workflow without
+// statuses is accepted, it leaves the project on its current workflow.
+func validateWorkflow(workflow storage.Workflow) error {
+	if len(workflow.Statuses) == 0 {
+		return nil
+	}
+	return workflow.Validate()
+}
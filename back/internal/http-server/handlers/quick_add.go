@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/quickadd"
+	"todo_list_service/internal/storage"
+)
+
+// QuickAddRequest creates a task from a line such as "Call bank tomorrow
+// 10am !low #finance every monday". With Preview set nothing is saved, the
+// parsed task is returned along with the tags saving it would create.
+type QuickAddRequest struct {
+	Text      string `json:"text"`
+	ProjectID int    `json:"project_id,omitempty"`
+	Preview   bool   `json:"preview,omitempty"`
+}
+
+func NewQuickAdd(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewQuickAdd", r)
+
+		var req QuickAddRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		user, err := handlerCtx.Storage.GetUserByID(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get user [%d] from db", userID), err)
+			return
+		}
+
+		loc, err := storage.LoadTimeZone(user.TimeZone)
+		if err != nil {
+			logger.Error("invalid user time zone", slog.String("time_zone", user.TimeZone), slog.String("error", err.Error()))
+			internalError(w, r)
+			return
+		}
+
+		parsed, err := quickadd.Parse(req.Text, time.Now(), loc)
+		if err != nil {
+			logger.Error("invalid quick add text", slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid quick add text", map[string]string{"text": err.Error()})
+			return
+		}
+		for i := range parsed.Tags {
+			if !validTag(&parsed.Tags[i], "") {
+				logger.Error("invalid tag", slog.String("name", parsed.Tags[i]))
+				badRequest(w, r, "Invalid quick add text", map[string]string{"text": fmt.Sprintf("tag #%s is not a valid tag name", parsed.Tags[i])})
+				return
+			}
+		}
+
+		tags, err := handlerCtx.Storage.GetTags(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, logger, fmt.Sprintf("failed to get tags of user [%d] from db", userID), err)
+			return
+		}
+
+		task := storage.Task{
+			Title:      parsed.Title,
+			UserID:     userID,
+			ProjectID:  req.ProjectID,
+			DueTs:      parsed.Due,
+			Recurrence: parsed.Recurrence,
+		}
+		if err := normalizeRecurrence(&task); err != nil {
+			logger.Error("invalid recurrence", slog.String("recurrence", task.Recurrence), slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid quick add text", map[string]string{"text": err.Error()})
+			return
+		}
+
+		// tags the user does not have yet are created when the task is saved
+		var newTags []string
+		for _, name := range parsed.Tags {
+			if tagID := tagIDByName(tags, name); tagID != 0 {
+				task.TagIDs = append(task.TagIDs, tagID)
+			} else {
+				newTags = append(newTags, name)
+			}
+		}
+
+		respMap := map[string]interface{}{"task": task, "new_tags": newTags, "priority": parsed.Priority}
+		if !req.Preview {
+			// new tasks go on top, !high leaves them there and !low puts them
+			// at the bottom
+			position := storage.TaskPosition{Place: storage.PlaceTop}
+			if parsed.Priority == quickadd.PriorityLow {
+				position.Place = storage.PlaceBottom
+			}
+
+			created, err := handlerCtx.Storage.CreateTaskWithTags(r.Context(), &task, newTags, position)
+			if err != nil {
+				writeError(w, r, logger, fmt.Sprintf("failed to create task [%s]", task.Title), err)
+				return
+			}
+			metrics.TasksCreated.Inc()
+			respMap = map[string]interface{}{"task": *created}
+		}
+
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
+
+func tagIDByName(tags []storage.Tag, name string) int {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag.ID
+		}
+	}
+	return 0
+}
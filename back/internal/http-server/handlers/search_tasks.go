@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"todo_list_service/internal/http-server/middleware/auth"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func NewSearchTasks(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewSearchTasks", r)
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			logger.Error("empty search query")
+			badRequest(w, r, "Incorrect request", map[string]string{"q": "must not be empty"})
+			return
+		}
+
+		limit, err := parseLimit(r.URL.Query(), defaultSearchLimit, maxSearchLimit)
+		if err != nil {
+			logger.Error("invalid search page", slog.String("error", err.Error()))
+			badRequest(w, r, "Incorrect request", map[string]string{"limit": err.Error()})
+			return
+		}
+
+		matches, err := handlerCtx.Storage.SearchTasks(r.Context(), userID, query, limit)
+		if err != nil {
+			writeError(w, r, logger, "failed to search tasks in db", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"results": matches}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
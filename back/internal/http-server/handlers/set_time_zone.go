@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+type SetTimeZoneRequest struct {
+	TimeZone string `json:"time_zone"`
+}
+
+func NewSetTimeZone(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewSetTimeZone", r)
+
+		var req SetTimeZoneRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		if _, err := storage.LoadTimeZone(req.TimeZone); err != nil || req.TimeZone == "" {
+			logger.Error("invalid time zone", slog.String("time_zone", req.TimeZone))
+			badRequest(w, r, "Incorrect request", map[string]string{"time_zone": "must be an IANA time zone name"})
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+
+		if err := handlerCtx.Storage.UpdateUserTimeZone(r.Context(), userID, req.TimeZone); err != nil {
+			writeError(w, r, logger, "failed to update user time zone", err)
+			return
+		}
+
+		respMap := map[string]interface{}{"time_zone": req.TimeZone}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
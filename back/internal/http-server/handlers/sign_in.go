@@ -1,56 +1,65 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"todo_list_service/internal/http-server/apierror"
 	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
 
-	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type SignInRequest struct {
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 }
 
 func NewSignIn(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "handlers.NewSignIn", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "handlers.NewSignIn", r)
 
 		var req SignInRequest
 		if err := decodeRequest(r, &req); err != nil {
 			handleDecodeError(err, w, r, logger)
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
 			return
 		}
 
 		logger.Debug("request body decoded", slog.Any("request", req))
 
-		user, err := handlerCtx.Storage.GetUserByUsername(req.Username)
+		// an unknown username gets the same answer as a wrong password, so
+		// that sign in does not tell which usernames are taken
+		user, err := handlerCtx.Storage.GetUserByUsername(r.Context(), req.Username)
+		if errors.Is(err, storage.ErrNotFound) {
+			logger.Info("unknown username")
+			metrics.FailedSignIns.Inc()
+			invalidCredentials(w, r)
+			return
+		}
 		if err != nil {
-			logger.Error("failed to get user from db", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to get user from db", err)
 			return
 		}
 
 		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
-			logger.Error("invalid password")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			logger.Info("invalid password")
+			metrics.FailedSignIns.Inc()
+			invalidCredentials(w, r)
+			return
 		}
 
 		session, err := handlerCtx.Store.Get(r, auth.SessionName)
 		if err != nil {
-			logger.Error("failed to get session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusUnauthorized)
+			writeError(w, r, logger, "failed to get session", err)
 			return
 		}
 
 		session.Values[string(auth.ContextUserID)] = user.ID
 		if err := session.Save(r, w); err != nil {
-			logger.Error("failed to save session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to save session", err)
 			return
 		}
 		logger.Info(fmt.Sprintf("saved user_id [%d] to cookie", user.ID))
@@ -61,3 +70,7 @@ func NewSignIn(handlerCtx *HandlerContext) http.HandlerFunc {
 		w.Write([]byte(fmt.Sprintf(`User '%s' signed up successfully at '%s'`, user.Username, creationTime)))
 	}
 }
+
+func invalidCredentials(w http.ResponseWriter, r *http.Request) {
+	apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid username or password", nil)
+}
@@ -5,26 +5,24 @@ import (
 	"log/slog"
 	"net/http"
 	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/metrics"
 
-	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type SignUpRequest struct {
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password string `json:"password" secret:"true"`
 	Email    string `json:"email"`
 }
 
 func NewSignUp(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "handlers.NewSignUp", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "handlers.NewSignUp", r)
 
 		var req SignUpRequest
 		if err := decodeRequest(r, &req); err != nil {
 			handleDecodeError(err, w, r, logger)
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
 			return
 		}
 
@@ -32,34 +30,27 @@ func NewSignUp(handlerCtx *HandlerContext) http.HandlerFunc {
 
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 		if err != nil {
-			logger.Error("failed to hash password", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to hash password", err)
 			return
 		}
 
-		userID, err := handlerCtx.Storage.CreateUser(req.Username, string(hashedPassword), req.Email)
+		userID, err := handlerCtx.Storage.CreateUser(r.Context(), req.Username, string(hashedPassword), req.Email)
 		if err != nil {
-			logger.Error("failed to create user", slog.String("error", err.Error()))
-			if userID < 0 {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			} else {
-				render.JSON(w, r, fmt.Sprintf("user with name [%s] already exists", req.Username))
-				http.Error(w, "Incorrect request", http.StatusBadRequest)
-			}
+			writeNameError(w, r, logger, fmt.Sprintf("failed to create user [%s]", req.Username), err, "username", "Username is already taken")
 			return
 		}
 
+		metrics.SignUps.Inc()
+
 		session, err := handlerCtx.Store.Get(r, auth.SessionName)
 		if err != nil {
-			logger.Error("failed to get session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to get session", err)
 			return
 		}
 
 		session.Values[string(auth.ContextUserID)] = userID
 		if err := session.Save(r, w); err != nil {
-			logger.Error("failed to save session", slog.String("error", err.Error()))
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, logger, "failed to save session", err)
 			return
 		}
 
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxTagNameLength = 64
+
+var tagColorRegexp = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validTag trims the tag name and reports whether name and colour are
+// acceptable. An empty colour leaves the tag uncoloured.
+func validTag(name *string, color string) bool {
+	*name = strings.TrimSpace(*name)
+	if *name == "" || utf8.RuneCountInString(*name) > maxTagNameLength {
+		return false
+	}
+	return color == "" || tagColorRegexp.MatchString(color)
+}
+
+// tagFieldErrors tells what validTag found wrong with a tag.
+func tagFieldErrors(name, color string) map[string]string {
+	fields := map[string]string{}
+	if length := utf8.RuneCountInString(strings.TrimSpace(name)); length == 0 || length > maxTagNameLength {
+		fields["tag.name"] = fmt.Sprintf("must be 1 to %d characters", maxTagNameLength)
+	}
+	if color != "" && !tagColorRegexp.MatchString(color) {
+		fields["tag.color"] = "must be a hex color like #1a2b3c"
+	}
+	return fields
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"fmt"
+	"todo_list_service/internal/recurrence"
+	"todo_list_service/internal/storage"
+)
+
+// normalizeRecurrence checks the task's recurrence rule and rewrites it in
+// canonical form. A recurring task needs a due date to schedule from.
+func normalizeRecurrence(task *storage.Task) error {
+	if task.Recurrence == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return err
+	}
+	if task.DueTs == nil {
+		return fmt.Errorf("recurring task has no due date")
+	}
+
+	task.Recurrence = rule.String()
+	return nil
+}
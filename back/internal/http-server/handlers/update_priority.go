@@ -7,53 +7,50 @@ import (
 	"net/http"
 	"todo_list_service/internal/http-server/middleware/auth"
 	"todo_list_service/internal/storage"
-
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 type UpdatePriorityRequest struct {
-	TargetTask       storage.Task `json:"target_task"`
-	PrevTaskPriority int          `json:"prev_task_priority"`
-	NextTaskPriority int          `json:"next_task_priority"`
+	TaskID   int    `json:"task_id"`
+	Position string `json:"position"`
+	AnchorID int    `json:"anchor_id,omitempty"`
 }
 
 func NewUpdatePriority(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateTask", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdatePriority", r)
 
 		var req UpdatePriorityRequest
 		if err := decodeRequest(r, &req); err != nil {
 			handleDecodeError(err, w, r, logger)
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
 			return
 		}
 
 		userID, ok := r.Context().Value(auth.ContextUserID).(int)
-		if !ok || req.TargetTask.UserID != userID {
-			logger.Error("failed to get or got incorrect [user_id] from session")
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
+		if !ok {
+			logger.Error("cannot get [user_id] from session")
+			unauthorized(w, r)
 			return
 		}
-		if req.PrevTaskPriority == storage.MaxInt {
-			req.TargetTask.Priority = req.NextTaskPriority + storage.TaskPriorityDelta
-		} else if req.PrevTaskPriority == storage.MinInt {
-			req.TargetTask.Priority = req.PrevTaskPriority - storage.TaskPriorityDelta
-		} else {
-			req.TargetTask.Priority = (req.PrevTaskPriority + req.NextTaskPriority) / 2
+
+		position, err := storage.NewTaskPosition(req.Position, req.AnchorID)
+		if err != nil || req.TaskID <= 0 {
+			logger.Error("invalid move", slog.Int("task_id", req.TaskID), slog.String("position", req.Position), slog.Int("anchor_id", req.AnchorID))
+			badRequest(w, r, "Incorrect request", map[string]string{"position": "must be top, bottom, or before or after an anchor_id"})
+			return
 		}
 
-		task, err := handlerCtx.Storage.UpdateTaskPriority(req.TargetTask.ID, req.TargetTask.UserID, req.TargetTask.Priority)
+		// the storage works out the priority from the current order of the
+		// project, so concurrent moves cannot leave stale neighbours behind
+		task, err := handlerCtx.Storage.UpdateTaskPriority(r.Context(), req.TaskID, userID, position)
 		if err != nil {
-			logger.Error(fmt.Sprintf("failed to update task [%d]", req.TargetTask.ID), slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, r, logger, fmt.Sprintf("failed to update task [%d]", req.TaskID), err)
 			return
 		}
 
 		respMap := map[string]interface{}{"task": *task}
 		resultJSON, err := json.Marshal(respMap)
 		if err != nil {
-			logger.Error("cannot serialize response", slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, r, logger, "cannot serialize response", err)
 			return
 		}
 
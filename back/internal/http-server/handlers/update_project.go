@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+type UpdateProjectRequest struct {
+	Project storage.Project `json:"project"`
+}
+
+func NewUpdateProject(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateProject", r)
+
+		var req UpdateProjectRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		if !validProjectName(&req.Project.Name) {
+			logger.Error("invalid project name", slog.String("name", req.Project.Name))
+			badRequest(w, r, "Invalid project", map[string]string{"project.name": fmt.Sprintf("must be 1 to %d characters", maxProjectNameLength)})
+			return
+		}
+
+		if err := validateWorkflow(req.Project.Workflow); err != nil {
+			logger.Error("invalid project workflow", slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid project", map[string]string{"project.workflow": err.Error()})
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+		req.Project.UserID = userID
+
+		project, err := handlerCtx.Storage.UpdateProject(r.Context(), &req.Project)
+		if err != nil {
+			writeNameError(w, r, logger, fmt.Sprintf("failed to update project [%s]", req.Project.Name), err, "project.name", "Project name is already taken")
+			return
+		}
+
+		respMap := map[string]interface{}{"project": *project}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
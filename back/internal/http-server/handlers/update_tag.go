@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+type UpdateTagRequest struct {
+	Tag storage.Tag `json:"tag"`
+}
+
+func NewUpdateTag(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateTag", r)
+
+		var req UpdateTagRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		if !validTag(&req.Tag.Name, req.Tag.Color) {
+			logger.Error("invalid tag", slog.String("name", req.Tag.Name), slog.String("color", req.Tag.Color))
+			badRequest(w, r, "Invalid tag", tagFieldErrors(req.Tag.Name, req.Tag.Color))
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+		req.Tag.UserID = userID
+
+		tag, err := handlerCtx.Storage.UpdateTag(r.Context(), &req.Tag)
+		if err != nil {
+			writeNameError(w, r, logger, fmt.Sprintf("failed to update tag [%s]", req.Tag.Name), err, "tag.name", "Tag name is already taken")
+			return
+		}
+
+		respMap := map[string]interface{}{"tag": *tag}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"net/http"
 	"todo_list_service/internal/http-server/middleware/auth"
 	"todo_list_service/internal/storage"
-
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 type UpdateTaskRequest struct {
@@ -17,12 +15,23 @@ type UpdateTaskRequest struct {
 
 func NewUpdateTask(handlerCtx *HandlerContext) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateTask", middleware.GetReqID(r.Context()))
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateTask", r)
 
 		var req UpdateTaskRequest
 		if err := decodeRequest(r, &req); err != nil {
 			handleDecodeError(err, w, r, logger)
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
+			return
+		}
+
+		if req.Task.StartTs != nil && req.Task.DueTs != nil && req.Task.DueTs.Before(*req.Task.StartTs) {
+			logger.Error("task is due before it starts")
+			badRequest(w, r, "Invalid task", map[string]string{"task.due_ts": "must not be before start_ts"})
+			return
+		}
+
+		if err := normalizeRecurrence(&req.Task); err != nil {
+			logger.Error("invalid recurrence", slog.String("recurrence", req.Task.Recurrence), slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid task", map[string]string{"task.recurrence": err.Error()})
 			return
 		}
 
@@ -31,24 +40,22 @@ func NewUpdateTask(handlerCtx *HandlerContext) http.HandlerFunc {
 		userID, ok := r.Context().Value(auth.ContextUserID).(int)
 		if !ok {
 			logger.Error("failed to get [user_id] from session")
-			http.Error(w, "Incorrect request", http.StatusBadRequest)
+			unauthorized(w, r)
 			return
 		}
 
 		updatedTask.UserID = userID
 
-		task, err := handlerCtx.Storage.UpdateTask(updatedTask)
+		task, err := handlerCtx.Storage.UpdateTask(r.Context(), updatedTask, handlerCtx.ClosePolicy)
 		if err != nil {
-			logger.Error(fmt.Sprintf("failed to update task [%d]", req.Task.ID), slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, r, logger, fmt.Sprintf("failed to update task [%d]", req.Task.ID), err)
 			return
 		}
 
 		respMap := map[string]interface{}{"task": *task}
 		resultJSON, err := json.Marshal(respMap)
 		if err != nil {
-			logger.Error("cannot serialize response", slog.String("error", err.Error()))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			writeError(w, r, logger, "cannot serialize response", err)
 			return
 		}
 
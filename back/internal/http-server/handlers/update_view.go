@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/http-server/middleware/auth"
+	"todo_list_service/internal/storage"
+)
+
+type UpdateViewRequest struct {
+	View storage.SavedView `json:"view"`
+}
+
+func NewUpdateView(handlerCtx *HandlerContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := getLogger(handlerCtx.Log, "http-server.handlers.NewUpdateView", r)
+
+		var req UpdateViewRequest
+		if err := decodeRequest(r, &req); err != nil {
+			handleDecodeError(err, w, r, logger)
+			return
+		}
+
+		if !validViewName(&req.View.Name) {
+			logger.Error("invalid view name", slog.String("name", req.View.Name))
+			badRequest(w, r, "Invalid view", map[string]string{"view.name": fmt.Sprintf("must be 1 to %d characters", maxViewNameLength)})
+			return
+		}
+
+		userID, ok := r.Context().Value(auth.ContextUserID).(int)
+		if !ok {
+			logger.Error("failed to get [user_id] from session")
+			unauthorized(w, r)
+			return
+		}
+		req.View.UserID = userID
+
+		scope, err := viewScope(r.Context(), handlerCtx.Storage, userID)
+		if err != nil {
+			writeError(w, r, logger, "failed to load view scope", err)
+			return
+		}
+		if _, err := storage.CompileView(req.View.Query, scope); err != nil {
+			logger.Error("invalid view query", slog.String("query", req.View.Query), slog.String("error", err.Error()))
+			badRequest(w, r, "Invalid view", map[string]string{"view.query": err.Error()})
+			return
+		}
+
+		view, err := handlerCtx.Storage.UpdateSavedView(r.Context(), &req.View)
+		if err != nil {
+			writeNameError(w, r, logger, fmt.Sprintf("failed to update view [%d]", req.View.ID), err, "view.name", "View name is already taken")
+			return
+		}
+
+		respMap := map[string]interface{}{"view": *view}
+		resultJSON, err := json.Marshal(respMap)
+		if err != nil {
+			writeError(w, r, logger, "cannot serialize response", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultJSON)
+	}
+}
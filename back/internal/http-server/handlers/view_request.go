@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"todo_list_service/internal/storage"
+	"unicode/utf8"
+)
+
+const maxViewNameLength = 128
+
+// validViewName trims the view name and reports whether it is acceptable.
+func validViewName(name *string) bool {
+	*name = strings.TrimSpace(*name)
+	return *name != "" && utf8.RuneCountInString(*name) <= maxViewNameLength
+}
+
+// viewScope loads what the user's view queries are compiled against.
+func viewScope(ctx context.Context, s storage.Storage, userID int) (storage.ViewScope, error) {
+	var scope storage.ViewScope
+
+	tags, err := s.GetTags(ctx, userID)
+	if err != nil {
+		return scope, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	projects, err := s.GetProjects(ctx, userID)
+	if err != nil {
+		return scope, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return scope, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	loc, err := storage.LoadTimeZone(user.TimeZone)
+	if err != nil {
+		return scope, err
+	}
+
+	return storage.ViewScope{Tags: tags, Projects: projects, Location: loc, Now: time.Now()}, nil
+}
+
+// parseViewID reads the required view_id from the query string.
+func parseViewID(query url.Values) (int, error) {
+	rawID := query.Get("view_id")
+	viewID, err := strconv.Atoi(rawID)
+	if err != nil || viewID <= 0 {
+		return 0, fmt.Errorf("invalid view id [%s]", rawID)
+	}
+	return viewID, nil
+}
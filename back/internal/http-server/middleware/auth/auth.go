@@ -3,8 +3,12 @@ package auth
 import (
 	"context"
 	"net/http"
+	"todo_list_service/internal/http-server/apierror"
+	"todo_list_service/internal/tracing"
 
 	"github.com/gorilla/sessions"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type contextKey string
@@ -26,18 +30,22 @@ func NewAuthMiddleware(store *sessions.CookieStore) *AuthMiddleware {
 
 func (am *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := otel.Tracer("todo_list_service/internal/http-server/middleware/auth").Start(r.Context(), "auth.session")
 		session, err := am.Store.Get(r, SessionName)
+		span.End()
 		if err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error", nil)
 			return
 		}
 
 		userID, ok := session.Values[string(ContextUserID)].(int)
 		if !ok || userID == 0 {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Unauthorized", nil)
 			return
 		}
 
+		trace.SpanFromContext(r.Context()).SetAttributes(tracing.UserID(userID))
+
 		ctx := context.WithValue(r.Context(), ContextUserID, userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,121 @@
+// Package logging builds the service logger from config.LogConfig: the
+// output format, the levels by component, the optional rotated file and
+// the redaction of secrets.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"todo_list_service/internal/config"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	// ComponentKey is the attribute loggers are tagged with, see
+	// config.LogConfig.Levels.
+	ComponentKey = "component"
+)
+
+// New returns the logger described by cfg. The returned function closes
+// the log file, if any, and has to be called on shutdown.
+func New(cfg *config.LogConfig) (*slog.Logger, func() error, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levels := make(map[string]slog.Level, len(cfg.Levels))
+	minLevel := level
+	for component, name := range cfg.Levels {
+		componentLevel, err := parseLevel(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("component [%s]: %w", component, err)
+		}
+		levels[component] = componentLevel
+		minLevel = min(minLevel, componentLevel)
+	}
+
+	var out io.Writer = os.Stdout
+	closeOutput := func() error { return nil }
+	if cfg.File.Path != "" {
+		file := &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSizeMB,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAgeDays,
+			Compress:   cfg.File.Compress,
+		}
+		out, closeOutput = file, file.Close
+	}
+
+	// the output handler lets everything through, levels are checked by
+	// componentHandler
+	opts := &slog.HandlerOptions{Level: minLevel, ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, nil, fmt.Errorf("unknown log format [%s]", cfg.Format)
+	}
+
+	return slog.New(&componentHandler{
+		next:   handler,
+		level:  level,
+		levels: levels,
+	}), closeOutput, nil
+}
+
+func parseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level [%s]", name)
+	}
+	return level, nil
+}
+
+// componentHandler applies the level of the component a logger is tagged
+// with through With, and the default level to the others.
+type componentHandler struct {
+	next   slog.Handler
+	level  slog.Level
+	levels map[string]slog.Level
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler := *h
+	handler.next = h.next.WithAttrs(attrs)
+	for _, attr := range attrs {
+		if attr.Key != ComponentKey {
+			continue
+		}
+		if level, ok := h.levels[attr.Value.String()]; ok {
+			handler.level = level
+		}
+	}
+	return &handler
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	handler := *h
+	handler.next = h.next.WithGroup(name)
+	return &handler
+}
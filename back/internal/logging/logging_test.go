@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/http-server/handlers"
+)
+
+// newFileLogger builds a logger through New writing to a file of the test,
+// the returned function reads what was logged so far.
+func newFileLogger(t *testing.T, cfg config.LogConfig) (*slog.Logger, func() string) {
+	t.Helper()
+
+	cfg.File.Path = filepath.Join(t.TempDir(), "service.log")
+	logger, closeLog, err := New(&cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { closeLog() })
+
+	return logger, func() string {
+		t.Helper()
+
+		data, err := os.ReadFile(cfg.File.Path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	const (
+		pgPassword   = "pg-password-1234"
+		sessionKey   = "session-key-5678"
+		userPassword = "hunter2-sign-in"
+	)
+
+	cfg := &config.Config{Env: "test"}
+	cfg.PgConfig.Host = "db.internal"
+	cfg.PgConfig.Password = pgPassword
+	cfg.HTTPServer.Session.SecretKey = sessionKey
+
+	for _, format := range []string{FormatText, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			logger, output := newFileLogger(t, config.LogConfig{Format: format, Level: "info"})
+
+			logger.Info("loaded config", slog.Any("config", cfg))
+			logger.Info("sign in", slog.Any("request", &handlers.SignInRequest{Username: "alice", Password: userPassword}))
+			logger.Info("empty sign in", slog.Any("request", handlers.SignInRequest{Username: "bob"}))
+
+			got := output()
+			for _, secret := range []string{pgPassword, sessionKey, userPassword} {
+				if strings.Contains(got, secret) {
+					t.Errorf("log holds secret %q:\n%s", secret, got)
+				}
+			}
+			if n := strings.Count(got, redacted); n != 3 {
+				t.Errorf("log holds %s %d times, want 3:\n%s", redacted, n, got)
+			}
+			// the other fields are still there
+			for _, value := range []string{"db.internal", "alice", "bob"} {
+				if !strings.Contains(got, value) {
+					t.Errorf("log misses %q:\n%s", value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	logger, output := newFileLogger(t, config.LogConfig{
+		Format: FormatJSON,
+		Level:  "info",
+		Levels: map[string]string{"storage": "debug", "http": "error"},
+	})
+
+	logger.Debug("default debug")
+	logger.Info("default info")
+	storage := logger.With(slog.String(ComponentKey, "storage"))
+	storage.Debug("storage debug")
+	http := logger.With(slog.String(ComponentKey, "http"))
+	http.Warn("http warn")
+	http.Error("http error")
+	logger.With(slog.String(ComponentKey, "other")).Debug("other debug")
+	// the component level sticks through groups
+	storage.WithGroup("query").Debug("grouped debug")
+
+	got := output()
+	for _, msg := range []string{"default info", "storage debug", "http error", "grouped debug"} {
+		if !strings.Contains(got, `"msg":"`+msg+`"`) {
+			t.Errorf("log misses %q:\n%s", msg, got)
+		}
+	}
+	for _, msg := range []string{"default debug", "http warn", "other debug"} {
+		if strings.Contains(got, `"msg":"`+msg+`"`) {
+			t.Errorf("log holds %q:\n%s", msg, got)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, cfg := range []config.LogConfig{
+		{Format: "xml", Level: "info"},
+		{Format: FormatText, Level: "verbose"},
+		{Format: FormatText, Level: "info", Levels: map[string]string{"storage": "loud"}},
+	} {
+		if _, _, err := New(&cfg); err == nil {
+			t.Errorf("New(%+v) returned no error", cfg)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+)
+
+// redacted replaces the value of struct fields tagged secret:"true".
+const redacted = "[REDACTED]"
+
+// secretTypes caches whether a type holds a secret field, a bool by
+// reflect.Type.
+var secretTypes sync.Map
+
+// redactAttr is the ReplaceAttr of the output handlers. Values of types
+// holding secret fields are logged as a group of their exported fields
+// with the secrets redacted, other values are left alone.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	v := reflect.ValueOf(a.Value.Any())
+	if !v.IsValid() || !hasSecrets(v.Type()) {
+		return a
+	}
+
+	a.Value = redactValue(v)
+	return a
+}
+
+func redactValue(v reflect.Value) slog.Value {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		return redactValue(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		attrs := make([]slog.Attr, 0, t.NumField())
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			value := v.Field(i)
+			switch {
+			case field.Tag.Get("secret") == "true":
+				// an unset secret is worth knowing about
+				if value.IsZero() {
+					attrs = append(attrs, slog.String(field.Name, ""))
+				} else {
+					attrs = append(attrs, slog.String(field.Name, redacted))
+				}
+			case hasSecrets(field.Type):
+				attrs = append(attrs, slog.Attr{Key: field.Name, Value: redactValue(value)})
+			default:
+				attrs = append(attrs, slog.Any(field.Name, value.Interface()))
+			}
+		}
+		return slog.GroupValue(attrs...)
+	}
+
+	// slices and maps of secrets are not worth walking
+	return slog.StringValue(redacted)
+}
+
+func hasSecrets(t reflect.Type) bool {
+	if cached, ok := secretTypes.Load(t); ok {
+		return cached.(bool)
+	}
+
+	found := findSecrets(t, make(map[reflect.Type]bool))
+	secretTypes.Store(t, found)
+	return found
+}
+
+func findSecrets(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return findSecrets(t.Elem(), seen)
+	case reflect.Struct:
+		// recursive types are walked once
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" || findSecrets(field.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,7 +1,108 @@
+// Package metrics exposes the service's Prometheus metrics on a listener of
+// its own, apart from the public API.
 package metrics
 
-import "todo_list_service/internal/config"
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"todo_list_service/internal/config"
 
-func StartMetricsServer(metricsConfig *config.MetricsConfig) {
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
 
+const namespace = "todo_list"
+
+// registry holds every metric of the service, the default Prometheus
+// registry is left alone so that libraries cannot add to it unnoticed.
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	TasksCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_created_total",
+		Help:      "Tasks created by users.",
+	})
+
+	TasksClosed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_closed_total",
+		Help:      "Tasks moved to a done status, by updates, undo and redo or along with their parent task.",
+	})
+
+	SignUps = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sign_ups_total",
+		Help:      "Users signed up.",
+	})
+
+	FailedSignIns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failed_sign_ins_total",
+		Help:      "Sign ins rejected for a wrong password.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpRequestDuration,
+		TasksCreated,
+		TasksClosed,
+		SignUps,
+		FailedSignIns,
+	)
+}
+
+// RegisterDBStats exports the connection pool stats of db labelled with
+// the storage driver name.
+func RegisterDBStats(db *sql.DB, driver string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, driver))
+}
+
+// StartMetricsServer serves the metrics in Prometheus text format on
+// /metrics at the configured address. It returns nil when metrics are
+// turned off, the returned server is to be shut down by the caller.
+func StartMetricsServer(metricsConfig *config.MetricsConfig, logger *slog.Logger) *http.Server {
+	if !metricsConfig.Enabled {
+		logger.Info("metrics server disabled")
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry}))
+
+	srv := &http.Server{
+		Addr:         metricsConfig.Address,
+		Handler:      mux,
+		ReadTimeout:  metricsConfig.Timeout,
+		WriteTimeout: metricsConfig.Timeout,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics server stopped", slog.String("error", err.Error()))
+		}
+	}()
+
+	logger.Info("metrics server started", slog.String("address", metricsConfig.Address))
+	return srv
 }
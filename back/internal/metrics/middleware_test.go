@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/tasks/{task_id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/tasks/{task_id}/undo", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/tasks/1"},
+		{http.MethodGet, "/tasks/2"},
+		{http.MethodPost, "/tasks/3/undo"},
+		{http.MethodGet, "/wp-login.php"},
+		{http.MethodGet, "/.env"},
+		{http.MethodDelete, "/tasks/4"},
+	}
+	for _, req := range requests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	tests := []struct {
+		route  string
+		method string
+		status string
+		want   float64
+	}{
+		// requests are labelled by pattern rather than path, a handler
+		// that writes nothing answered 200
+		{"/tasks/{task_id}", http.MethodGet, "200", 2},
+		{"/tasks/{task_id}/undo", http.MethodPost, "409", 1},
+		// paths no route matched share one label
+		{unmatchedRoute, http.MethodGet, "404", 2},
+		{unmatchedRoute, http.MethodDelete, "405", 1},
+	}
+	for _, tt := range tests {
+		labels := []string{tt.route, tt.method, tt.status}
+		if got := testutil.ToFloat64(httpRequests.WithLabelValues(labels...)); got != tt.want {
+			t.Errorf("requests %v = %v, want %v", labels, got, tt.want)
+		}
+	}
+
+	if got, want := testutil.CollectAndCount(httpRequests), len(tests); got != want {
+		t.Errorf("%d request series, want %d", got, want)
+	}
+	if got, want := testutil.CollectAndCount(httpRequestDuration), len(tests); got != want {
+		t.Errorf("%d latency series, want %d", got, want)
+	}
+}
@@ -0,0 +1,405 @@
+// Package quickadd parses a single line typed into the quick add box into
+// the parts of a task. Words the parser understands are taken out of the
+// line and the rest becomes the title:
+//
+//	tomorrow, monday, next week,     the day the task is due, a weekday
+//	in 3 days, on 2024-05-01         is the next such day after today
+//	10am, 10:30pm, 22:00, at noon    the time of day the task is due
+//	!low                             put the task at the bottom
+//	#finance                         tag the task
+//	every monday, every 2 weeks,     make the task recurring, the first
+//	daily, every weekday             occurrence is its due date
+//
+// e.g. "Call bank tomorrow 10am #finance every monday". New tasks go on top,
+// so only !low changes where a task is put. !high is still taken out of the
+// title and reported, it leaves the task on top like any other. Only the
+// first date, time, priority and recurrence of a line are taken, later ones
+// stay in the title, and so do Daily, Weekly, Monthly and Yearly when written
+// with a capital letter as in "Daily standup". Dates are placed in the user's
+// time zone, a date without a time is due at the end of that day and a time
+// without a date is due at its next occurrence.
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo_list_service/internal/recurrence"
+)
+
+type Priority string
+
+const (
+	PriorityHigh Priority = "high"
+	PriorityLow  Priority = "low"
+
+	// endOfDayHour and endOfDayMinute are the time of day of tasks given a
+	// date but no time.
+	endOfDayHour   = 23
+	endOfDayMinute = 59
+
+	maxLength = 1024
+)
+
+var (
+	clockRegexp = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	countRegexp = regexp.MustCompile(`^\d{1,3}$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"mon":       time.Monday,
+	"tuesday":   time.Tuesday,
+	"tue":       time.Tuesday,
+	"wednesday": time.Wednesday,
+	"wed":       time.Wednesday,
+	"thursday":  time.Thursday,
+	"thu":       time.Thursday,
+	"friday":    time.Friday,
+	"fri":       time.Friday,
+	"saturday":  time.Saturday,
+	"sat":       time.Saturday,
+	"sunday":    time.Sunday,
+	"sun":       time.Sunday,
+}
+
+// units maps the unit words of "in N days" and "every N weeks", singular
+// and plural, to recurrence frequencies.
+var units = map[string]recurrence.Frequency{
+	"day":    recurrence.Daily,
+	"days":   recurrence.Daily,
+	"week":   recurrence.Weekly,
+	"weeks":  recurrence.Weekly,
+	"month":  recurrence.Monthly,
+	"months": recurrence.Monthly,
+	"year":   recurrence.Yearly,
+	"years":  recurrence.Yearly,
+}
+
+var frequencyWords = map[string]recurrence.Frequency{
+	"daily":   recurrence.Daily,
+	"weekly":  recurrence.Weekly,
+	"monthly": recurrence.Monthly,
+	"yearly":  recurrence.Yearly,
+}
+
+var workdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Result is a parsed line. Due is nil when the line names neither a date,
+// a time nor a recurrence, Recurrence is a rule in the form the recurrence
+// package reads. Tags are names in the order of the line without repeats.
+type Result struct {
+	Title      string
+	Due        *time.Time
+	Priority   Priority
+	Tags       []string
+	Recurrence string
+}
+
+// date is a calendar day in the parser's location.
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+// clock is a time of day.
+type clock struct {
+	hour, minute int
+}
+
+type parser struct {
+	words []string
+	now   time.Time
+
+	date  *date
+	clock *clock
+	rule  *recurrence.Rule
+}
+
+// Parse reads a quick add line as typed by a user in loc at the moment now.
+// It fails when nothing is left for the title.
+func Parse(line string, now time.Time, loc *time.Location) (*Result, error) {
+	if len(line) > maxLength {
+		return nil, fmt.Errorf("quick add line is longer than %d bytes", maxLength)
+	}
+
+	p := &parser{words: strings.Fields(line), now: now.In(loc)}
+	result := &Result{}
+
+	var title []string
+	for i := 0; i < len(p.words); {
+		word := strings.ToLower(p.words[i])
+
+		switch {
+		case len(word) > 1 && word[0] == '#':
+			if tag := p.words[i][1:]; !slices.Contains(result.Tags, tag) {
+				result.Tags = append(result.Tags, tag)
+			}
+			i++
+			continue
+		case result.Priority == "" && (word == "!"+string(PriorityHigh) || word == "!"+string(PriorityLow)):
+			result.Priority = Priority(word[1:])
+			i++
+			continue
+		}
+
+		if n := p.match(i); n > 0 {
+			i += n
+			continue
+		}
+
+		title = append(title, p.words[i])
+		i++
+	}
+
+	result.Title = strings.Join(title, " ")
+	if result.Title == "" {
+		return nil, fmt.Errorf("quick add line [%s] has no title", line)
+	}
+
+	if p.rule != nil {
+		result.Recurrence = p.rule.String()
+	}
+	result.Due = p.due()
+
+	return result, nil
+}
+
+// match tries the date, time and recurrence phrases at word i and returns
+// how many words the first matching one took, 0 when none matched.
+func (p *parser) match(i int) int {
+	if p.rule == nil {
+		if n, rule := p.parseRecurrence(i); n > 0 {
+			p.rule = rule
+			return n
+		}
+	}
+	if p.date == nil {
+		if n, d := p.parseDate(i); n > 0 {
+			p.date = d
+			return n
+		}
+	}
+	if p.clock == nil {
+		if n, c := p.parseClock(i); n > 0 {
+			p.clock = c
+			return n
+		}
+	}
+	return 0
+}
+
+// word returns the lower cased word i, an empty string past the end.
+func (p *parser) word(i int) string {
+	if i >= len(p.words) {
+		return ""
+	}
+	return strings.ToLower(p.words[i])
+}
+
+func (p *parser) today() date {
+	year, month, day := p.now.Date()
+	return date{year, month, day}
+}
+
+// nextWeekday returns the first day on weekday after today.
+func (p *parser) nextWeekday(weekday time.Weekday) *date {
+	days := (int(weekday) - int(p.now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return p.addDays(days)
+}
+
+func (p *parser) addDays(days int) *date {
+	year, month, day := p.now.AddDate(0, 0, days).Date()
+	return &date{year, month, day}
+}
+
+func (p *parser) parseDate(i int) (int, *date) {
+	word := p.word(i)
+
+	// "on monday" and "on 2024-05-01" take the preposition along
+	if word == "on" {
+		if n, d := p.parseDate(i + 1); n > 0 {
+			return n + 1, d
+		}
+		return 0, nil
+	}
+
+	switch word {
+	case "today":
+		today := p.today()
+		return 1, &today
+	case "tomorrow":
+		return 1, p.addDays(1)
+	}
+
+	if weekday, ok := weekdays[word]; ok {
+		return 1, p.nextWeekday(weekday)
+	}
+	if word == "next" {
+		if weekday, ok := weekdays[p.word(i+1)]; ok {
+			return 2, p.nextWeekday(weekday)
+		}
+		if p.word(i+1) == "week" {
+			return 2, p.nextWeekday(time.Monday)
+		}
+		return 0, nil
+	}
+
+	if word == "in" && countRegexp.MatchString(p.word(i+1)) {
+		count, _ := strconv.Atoi(p.word(i + 1))
+		switch units[p.word(i+2)] {
+		case recurrence.Daily:
+			return 3, p.addDays(count)
+		case recurrence.Weekly:
+			return 3, p.addDays(7 * count)
+		case recurrence.Monthly:
+			year, month, day := p.now.AddDate(0, count, 0).Date()
+			return 3, &date{year, month, day}
+		case recurrence.Yearly:
+			year, month, day := p.now.AddDate(count, 0, 0).Date()
+			return 3, &date{year, month, day}
+		}
+		return 0, nil
+	}
+
+	if day, err := time.Parse(time.DateOnly, word); err == nil {
+		return 1, &date{day.Year(), day.Month(), day.Day()}
+	}
+	return 0, nil
+}
+
+func (p *parser) parseClock(i int) (int, *clock) {
+	word := p.word(i)
+
+	// "at 10am" takes the preposition along
+	if word == "at" {
+		if n, c := p.parseClock(i + 1); n > 0 {
+			return n + 1, c
+		}
+		return 0, nil
+	}
+
+	if word == "noon" {
+		return 1, &clock{12, 0}
+	}
+
+	// "10 am" is written as two words
+	n := 1
+	if next := p.word(i + 1); (next == "am" || next == "pm") && countRegexp.MatchString(word) {
+		word += next
+		n = 2
+	}
+
+	m := clockRegexp.FindStringSubmatch(word)
+	// a bare number is part of the title, a time needs minutes or am/pm
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, nil
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if minute > 59 {
+		return 0, nil
+	}
+
+	switch m[3] {
+	case "":
+		if hour > 23 {
+			return 0, nil
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, nil
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	return n, &clock{hour, minute}
+}
+
+func (p *parser) parseRecurrence(i int) (int, *recurrence.Rule) {
+	word := p.word(i)
+
+	// "Daily standup" names a task, only the lower case word is a rule
+	if freq, ok := frequencyWords[p.words[i]]; ok {
+		return 1, &recurrence.Rule{Freq: freq, Interval: 1}
+	}
+	if word != "every" {
+		return 0, nil
+	}
+
+	next := p.word(i + 1)
+	if next == "weekday" || next == "workday" {
+		return 2, &recurrence.Rule{Freq: recurrence.Weekly, Interval: 1, ByDay: workdays}
+	}
+	if freq, ok := units[next]; ok {
+		return 2, &recurrence.Rule{Freq: freq, Interval: 1}
+	}
+	if countRegexp.MatchString(next) {
+		interval, _ := strconv.Atoi(next)
+		if freq, ok := units[p.word(i+2)]; ok && interval > 0 {
+			return 3, &recurrence.Rule{Freq: freq, Interval: interval}
+		}
+		return 0, nil
+	}
+
+	// "every mon,thu"
+	var byDay []time.Weekday
+	for _, name := range strings.Split(next, ",") {
+		weekday, ok := weekdays[name]
+		if !ok {
+			return 0, nil
+		}
+		if !slices.Contains(byDay, weekday) {
+			byDay = append(byDay, weekday)
+		}
+	}
+	slices.SortFunc(byDay, func(a, b time.Weekday) int {
+		return (int(a)+6)%7 - (int(b)+6)%7
+	})
+	return 2, &recurrence.Rule{Freq: recurrence.Weekly, Interval: 1, ByDay: byDay}
+}
+
+// due puts the parsed date and time together. Without a date a recurring
+// task is due on the first day of its rule from today on and any other task
+// on the next occurrence of its time.
+func (p *parser) due() *time.Time {
+	if p.date == nil && p.clock == nil && p.rule == nil {
+		return nil
+	}
+
+	c := clock{endOfDayHour, endOfDayMinute}
+	if p.clock != nil {
+		c = *p.clock
+	}
+	at := func(d date) time.Time {
+		return time.Date(d.year, d.month, d.day, c.hour, c.minute, 0, 0, p.now.Location())
+	}
+
+	if p.date != nil {
+		due := at(*p.date)
+		return &due
+	}
+
+	due := at(p.today())
+	for day := 0; day < 7; day++ {
+		if !due.Before(p.now) && (p.rule == nil || len(p.rule.ByDay) == 0 || slices.Contains(p.rule.ByDay, due.Weekday())) {
+			break
+		}
+		due = at(*p.addDays(day + 1))
+	}
+	return &due
+}
@@ -0,0 +1,88 @@
+package quickadd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	// a Wednesday
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, loc)
+	at := func(month time.Month, day, hour, minute int) *time.Time {
+		due := time.Date(2024, month, day, hour, minute, 0, 0, loc)
+		return &due
+	}
+
+	tests := []struct {
+		line string
+		want Result
+	}{
+		{"Call bank tomorrow 10am !high #finance every monday", Result{
+			Title: "Call bank", Due: at(time.May, 2, 10, 0), Priority: PriorityHigh, Tags: []string{"finance"}, Recurrence: "FREQ=WEEKLY;BYDAY=MO",
+		}},
+		{"Buy milk", Result{Title: "Buy milk"}},
+		{"Buy 2 apples", Result{Title: "Buy 2 apples"}},
+		{"Daily standup", Result{Title: "Daily standup"}},
+		{"Weekly report on friday", Result{Title: "Weekly report", Due: at(time.May, 3, 23, 59)}},
+		{"Standup daily at 9:30", Result{Title: "Standup", Due: at(time.May, 2, 9, 30), Recurrence: "FREQ=DAILY"}},
+		{"Stretch at 18:00", Result{Title: "Stretch", Due: at(time.May, 1, 18, 0)}},
+		{"Pay rent on 2024-06-01", Result{Title: "Pay rent", Due: at(time.June, 1, 23, 59)}},
+		{"Plan sprint next week", Result{Title: "Plan sprint", Due: at(time.May, 6, 23, 59)}},
+		{"Call mom next wed", Result{Title: "Call mom", Due: at(time.May, 8, 23, 59)}},
+		{"Review in 3 days", Result{Title: "Review", Due: at(time.May, 4, 23, 59)}},
+		{"Renew passport in 2 weeks", Result{Title: "Renew passport", Due: at(time.May, 15, 23, 59)}},
+		{"Renew lease in 1 month", Result{Title: "Renew lease", Due: at(time.June, 1, 23, 59)}},
+		{"Lunch today at noon", Result{Title: "Lunch", Due: at(time.May, 1, 12, 0)}},
+		{"Gym every mon,thu", Result{Title: "Gym", Due: at(time.May, 2, 23, 59), Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH"}},
+		{"Water plants every 2 weeks", Result{Title: "Water plants", Due: at(time.May, 1, 23, 59), Recurrence: "FREQ=WEEKLY;INTERVAL=2"}},
+		{"Standup every weekday 10 am", Result{Title: "Standup", Due: at(time.May, 2, 10, 0), Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"}},
+		{"Call mom monday tuesday", Result{Title: "Call mom tuesday", Due: at(time.May, 6, 23, 59)}},
+		{"Task !low !high", Result{Title: "Task !high", Priority: PriorityLow}},
+		{"Tags #a #B #a", Result{Title: "Tags", Tags: []string{"a", "B"}}},
+		{"Pay at 25:00", Result{Title: "Pay at 25:00"}},
+		{"Ship 13pm 10:75", Result{Title: "Ship 13pm 10:75"}},
+		{"Read every 0 days", Result{Title: "Read every 0 days"}},
+		{"Meet in 3 parsecs", Result{Title: "Meet in 3 parsecs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := Parse(tt.line, now, loc)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if got.Title != tt.want.Title || got.Priority != tt.want.Priority || got.Recurrence != tt.want.Recurrence || !slices.Equal(got.Tags, tt.want.Tags) {
+				t.Errorf("Parse returned %+v, want %+v", *got, tt.want)
+			}
+			switch {
+			case got.Due == nil && tt.want.Due == nil:
+			case got.Due == nil || tt.want.Due == nil || !got.Due.Equal(*tt.want.Due):
+				t.Errorf("Parse returned due %v, want %v", got.Due, tt.want.Due)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, line := range []string{
+		"",
+		"   ",
+		"tomorrow 10am #errands !high",
+		"every monday",
+		strings.Repeat("a", maxLength+1),
+	} {
+		if got, err := Parse(line, now, time.UTC); err == nil {
+			t.Errorf("Parse(%q) returned %+v, want an error", line, *got)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules
+// supported for recurring tasks: FREQ (DAILY, WEEKLY, MONTHLY or YEARLY),
+// INTERVAL, BYDAY (plain weekdays, DAILY and WEEKLY rules only), UNTIL and
+// COUNT, e.g. "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+const (
+	maxInterval = 1000
+
+	untilLayout     = "20060102T150405Z"
+	untilDateLayout = "20060102"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// Rule is a parsed recurrence rule. Count is the number of occurrences left
+// including the current one, zero means the rule is not limited by count.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Until    *time.Time
+	Count    int
+}
+
+// Parse reads a rule such as "FREQ=DAILY;INTERVAL=2", an optional "RRULE:"
+// prefix is accepted. A date-only UNTIL covers the whole day in UTC.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	if prefix := len("RRULE:"); len(value) >= prefix && strings.EqualFold(value[:prefix], "RRULE:") {
+		value = value[prefix:]
+	}
+	if value == "" {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid recurrence rule part [%s]", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate recurrence rule part [%s]", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch freq := Frequency(val); freq {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = freq
+			default:
+				return nil, fmt.Errorf("unsupported frequency [%s]", val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 || interval > maxInterval {
+				return nil, fmt.Errorf("invalid interval [%s]", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, name := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.TrimSpace(name)]
+				if !ok {
+					return nil, fmt.Errorf("invalid weekday [%s]", name)
+				}
+				if !slices.Contains(rule.ByDay, weekday) {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count [%s]", val)
+			}
+			rule.Count = count
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part [%s]", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("recurrence rule has no FREQ")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != Daily && rule.Freq != Weekly {
+		return nil, fmt.Errorf("BYDAY is only supported with DAILY and WEEKLY rules")
+	}
+	if rule.Until != nil && rule.Count != 0 {
+		return nil, fmt.Errorf("recurrence rule cannot have both UNTIL and COUNT")
+	}
+
+	// keep BYDAY in week order, Monday first, so String is canonical
+	slices.SortFunc(rule.ByDay, func(a, b time.Weekday) int {
+		return weekdayOffset(a) - weekdayOffset(b)
+	})
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse(untilLayout, value); err == nil {
+		return until, nil
+	}
+	if day, err := time.Parse(untilDateLayout, value); err == nil {
+		return day.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid until [%s]", value)
+}
+
+// String formats the rule in canonical form, INTERVAL is left out when it
+// is 1.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			names = append(names, weekdayNames[weekday])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after prev. Calendar arithmetic happens
+// in prev's location, so the wall clock time survives daylight saving
+// changes. It reports false once the rule is exhausted by COUNT or UNTIL or
+// yields no further dates, like a monthly rule on the 30th with a 12 month
+// interval started in February.
+func (r *Rule) Next(prev time.Time) (time.Time, bool) {
+	if r.Count == 1 {
+		return time.Time{}, false
+	}
+
+	interval := max(r.Interval, 1)
+
+	var next time.Time
+	var ok bool
+	switch r.Freq {
+	case Daily:
+		next, ok = r.nextDaily(prev, interval)
+	case Weekly:
+		next, ok = r.nextWeekly(prev, interval)
+	case Monthly:
+		next, ok = addMonths(prev, interval, 48)
+	case Yearly:
+		next, ok = addMonths(prev, 12*interval, 8)
+	}
+
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r *Rule) nextDaily(prev time.Time, interval int) (time.Time, bool) {
+	// weekdays repeat after at most 7 steps, a BYDAY missing from those never matches
+	for step := 1; step <= 7; step++ {
+		next := addDays(prev, step*interval)
+		if len(r.ByDay) == 0 || slices.Contains(r.ByDay, next.Weekday()) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Rule) nextWeekly(prev time.Time, interval int) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return addDays(prev, 7*interval), true
+	}
+
+	offset := weekdayOffset(prev.Weekday())
+	for _, weekday := range r.ByDay {
+		if day := weekdayOffset(weekday); day > offset {
+			return addDays(prev, day-offset), true
+		}
+	}
+
+	// no days left this week, continue with the first day of the next matching week
+	weekStart := addDays(prev, -offset)
+	return addDays(weekStart, 7*interval+weekdayOffset(r.ByDay[0])), true
+}
+
+// addMonths moves prev by months, skipping up to attempts periods in which
+// the day of month does not exist, as RFC 5545 does for e.g. the 31st.
+func addMonths(prev time.Time, months, attempts int) (time.Time, bool) {
+	year, month, day := prev.Date()
+	hour, minute, sec := prev.Clock()
+
+	for step := 1; step <= attempts; step++ {
+		next := time.Date(year, month+time.Month(step*months), day, hour, minute, sec, prev.Nanosecond(), prev.Location())
+		if next.Day() == day {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func addDays(t time.Time, days int) time.Time {
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	return time.Date(year, month, day+days, hour, minute, sec, t.Nanosecond(), t.Location())
+}
+
+// weekdayOffset numbers weekdays from Monday, weeks start on Monday.
+func weekdayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
@@ -0,0 +1,216 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustParse(t *testing.T, value string) *Rule {
+	t.Helper()
+
+	rule, err := Parse(value)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", value, err)
+	}
+	return rule
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	local := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		prev time.Time
+		// want lists the following occurrences, the rule is exhausted after
+		// them when exhausted is set
+		want      []time.Time
+		exhausted bool
+	}{
+		{
+			name: "daily",
+			rule: "FREQ=DAILY",
+			prev: utc(2024, time.February, 28, 9),
+			want: []time.Time{utc(2024, time.February, 29, 9), utc(2024, time.March, 1, 9)},
+		},
+		{
+			name: "daily on weekdays skips the weekend",
+			rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			prev: utc(2024, time.May, 3, 9),
+			want: []time.Time{utc(2024, time.May, 6, 9), utc(2024, time.May, 7, 9)},
+		},
+		{
+			name: "every other day",
+			rule: "FREQ=DAILY;INTERVAL=2",
+			prev: utc(2024, time.December, 30, 9),
+			want: []time.Time{utc(2025, time.January, 1, 9), utc(2025, time.January, 3, 9)},
+		},
+		{
+			name: "weekly",
+			rule: "FREQ=WEEKLY",
+			prev: utc(2024, time.May, 1, 9),
+			want: []time.Time{utc(2024, time.May, 8, 9), utc(2024, time.May, 15, 9)},
+		},
+		{
+			name: "weekly by day walks the week then wraps",
+			rule: "FREQ=WEEKLY;BYDAY=TH,MO",
+			prev: utc(2024, time.May, 1, 9),
+			want: []time.Time{utc(2024, time.May, 2, 9), utc(2024, time.May, 6, 9), utc(2024, time.May, 9, 9)},
+		},
+		{
+			name: "biweekly by day skips a week",
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			prev: utc(2024, time.May, 3, 9),
+			want: []time.Time{utc(2024, time.May, 13, 9), utc(2024, time.May, 17, 9), utc(2024, time.May, 27, 9)},
+		},
+		{
+			name: "weekly by day on Sunday, the last day of the week",
+			rule: "FREQ=WEEKLY;BYDAY=SU",
+			prev: utc(2024, time.May, 5, 9),
+			want: []time.Time{utc(2024, time.May, 12, 9)},
+		},
+		{
+			name: "monthly on the 31st skips shorter months",
+			rule: "FREQ=MONTHLY",
+			prev: utc(2024, time.January, 31, 9),
+			want: []time.Time{utc(2024, time.March, 31, 9), utc(2024, time.May, 31, 9), utc(2024, time.July, 31, 9), utc(2024, time.August, 31, 9)},
+		},
+		{
+			name: "every other month on the 31st skips two short months",
+			rule: "FREQ=MONTHLY;INTERVAL=2",
+			prev: utc(2024, time.July, 31, 9),
+			want: []time.Time{utc(2025, time.January, 31, 9), utc(2025, time.March, 31, 9)},
+		},
+		{
+			name: "yearly on a leap day waits for the next leap year",
+			rule: "FREQ=YEARLY",
+			prev: utc(2024, time.February, 29, 9),
+			want: []time.Time{utc(2028, time.February, 29, 9)},
+		},
+		{
+			name: "daily across the spring DST change keeps the wall clock",
+			rule: "FREQ=DAILY",
+			prev: local(2024, time.March, 30, 9),
+			want: []time.Time{local(2024, time.March, 31, 9), local(2024, time.April, 1, 9)},
+		},
+		{
+			name: "weekly across the autumn DST change keeps the wall clock",
+			rule: "FREQ=WEEKLY;BYDAY=SA",
+			prev: local(2024, time.October, 26, 9),
+			want: []time.Time{local(2024, time.November, 2, 9)},
+		},
+		{
+			name: "count stops after the last occurrence",
+			rule: "FREQ=DAILY;COUNT=1",
+			prev: utc(2024, time.May, 1, 9),
+			want: nil, exhausted: true,
+		},
+		{
+			name: "until stops at the limit",
+			rule: "FREQ=DAILY;UNTIL=20240503T090000Z",
+			prev: utc(2024, time.May, 1, 9),
+			want: []time.Time{utc(2024, time.May, 2, 9), utc(2024, time.May, 3, 9)}, exhausted: true,
+		},
+		{
+			name: "date only until covers the whole day",
+			rule: "FREQ=WEEKLY;UNTIL=20240508",
+			prev: utc(2024, time.May, 1, 23),
+			want: []time.Time{utc(2024, time.May, 8, 23)}, exhausted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustParse(t, tt.rule)
+
+			prev := tt.prev
+			for i, want := range tt.want {
+				next, ok := rule.Next(prev)
+				if !ok {
+					t.Fatalf("occurrence %d: Next(%v) reported the rule exhausted, want %v", i, prev, want)
+				}
+				if !next.Equal(want) || next.Location() != want.Location() {
+					t.Fatalf("occurrence %d: Next(%v) = %v, want %v", i, prev, next, want)
+				}
+				prev = next
+			}
+
+			if tt.exhausted {
+				if next, ok := rule.Next(prev); ok {
+					t.Fatalf("Next(%v) = %v, want the rule exhausted", prev, next)
+				}
+			}
+		})
+	}
+}
+
+// TestNextCount follows a COUNT rule the way recurring tasks do, each
+// occurrence carries the count left after it.
+func TestNextCount(t *testing.T) {
+	rule := mustParse(t, "FREQ=WEEKLY;COUNT=3")
+	prev := time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC)
+
+	var got []time.Time
+	for {
+		next, ok := rule.Next(prev)
+		if !ok {
+			break
+		}
+		got = append(got, next)
+		rule.Count--
+		prev = next
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("COUNT=3 yielded %d occurrences after the first, want 2: %v", len(got), got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;interval=1", "FREQ=WEEKLY"},
+		{"FREQ=WEEKLY;BYDAY=FR,MO,MO", "FREQ=WEEKLY;BYDAY=MO,FR"},
+		{"FREQ=MONTHLY;INTERVAL=3;COUNT=4", "FREQ=MONTHLY;INTERVAL=3;COUNT=4"},
+		{"FREQ=YEARLY;UNTIL=20301231", "FREQ=YEARLY;UNTIL=20301231T235959Z"},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.value).String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=1001",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20300101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ",
+	} {
+		if rule, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) returned %q, want an error", value, rule)
+		}
+	}
+}
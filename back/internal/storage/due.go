@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DueOverdue  = "overdue"
+	DueToday    = "today"
+	DueThisWeek = "week"
+)
+
+// DueRange returns the [from, to) bounds of due dates matching a window as
+// seen by a user in loc at the moment now:
+//   - overdue: everything due before now, from is nil;
+//   - today: the user's current calendar day, overdue hours included;
+//   - week: from now until the end of the user's current Monday-based week.
+func DueRange(window string, now time.Time, loc *time.Location) (from *time.Time, to time.Time, err error) {
+	now = now.In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch window {
+	case DueOverdue:
+		return nil, now, nil
+	case DueToday:
+		return &startOfDay, startOfDay.AddDate(0, 0, 1), nil
+	case DueThisWeek:
+		// days left until next Monday, Sunday is the last day of the week
+		daysLeft := (8 - int(now.Weekday())) % 7
+		if daysLeft == 0 {
+			daysLeft = 7
+		}
+		return &now, startOfDay.AddDate(0, 0, daysLeft), nil
+	}
+
+	return nil, time.Time{}, fmt.Errorf("unknown due window [%s]", window)
+}
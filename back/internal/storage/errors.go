@@ -0,0 +1,20 @@
+package storage
+
+import "errors"
+
+// Kinds of failure every backend reports the same way, wrapped with the
+// details of the failed operation. Callers tell them apart with errors.Is.
+var (
+	// ErrNotFound is returned for missing records. Lookups are scoped by
+	// user, so records of other users are not found either.
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists is returned when a username, or a tag, project or
+	// view name of the user, is taken.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrConflict is returned for changes the current state of a record
+	// does not allow, such as updating an archived task.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is returned for changes no user may make, such as
+	// deleting an inbox.
+	ErrForbidden = errors.New("forbidden")
+)
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("cursor does not belong to the requested listing")
+
+// TaskSort is the order ListTasks returns tasks in, ties are broken by id so
+// every task has a fixed place in the listing.
+type TaskSort string
+
+const (
+	// SortByPriority lists the highest priority first, then lower ids.
+	SortByPriority TaskSort = "priority"
+	// SortByCreation lists the newest tasks first, then higher ids.
+	SortByCreation TaskSort = "creation"
+	// SortByDue lists the earliest due date first, tasks without one last,
+	// then lower ids.
+	SortByDue TaskSort = "due"
+)
+
+// ParseTaskSort checks a sort name, an empty one sorts by priority.
+func ParseTaskSort(name string) (TaskSort, error) {
+	switch sort := TaskSort(name); sort {
+	case "":
+		return SortByPriority, nil
+	case SortByPriority, SortByCreation, SortByDue:
+		return sort, nil
+	}
+	return "", fmt.Errorf("unknown task sort [%s]", name)
+}
+
+// TaskFilter narrows ListTasks. A non-zero ProjectID keeps the tasks of
+// that project only. With MatchAllTags unset a task needs any of TagIDs,
+// otherwise all of them. Non-empty Statuses keep tasks in one of them,
+// CreatedFrom and CreatedTo bound the creation time to [from, to), DueFrom
+// and DueTo the due date likewise, either of them drops undated tasks.
+// After keeps the tasks listed past a cursor in Sort order, which is
+// priority for an empty Sort. An empty filter matches every task.
+type TaskFilter struct {
+	ProjectID    int
+	TagIDs       []int
+	MatchAllTags bool
+	Statuses     []int8
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	DueFrom      *time.Time
+	DueTo        *time.Time
+	Sort         TaskSort
+	After        *TaskCursor
+}
+
+// UniqueTagIDs returns the filter's tag ids sorted and without duplicates.
+func (f TaskFilter) UniqueTagIDs() []int {
+	tagIDs := slices.Clone(f.TagIDs)
+	slices.Sort(tagIDs)
+	return slices.Compact(tagIDs)
+}
+
+// OrderBy returns the filter's sort, defaulting to priority.
+func (f TaskFilter) OrderBy() TaskSort {
+	if f.Sort == "" {
+		return SortByPriority
+	}
+	return f.Sort
+}
+
+// Matches reports whether task passes every condition of the filter except
+// the cursor, backends without a query language use it.
+func (f TaskFilter) Matches(task *Task) bool {
+	if f.ProjectID != 0 && task.ProjectID != f.ProjectID {
+		return false
+	}
+	if tagIDs := f.UniqueTagIDs(); len(tagIDs) > 0 && !hasTags(task.TagIDs, tagIDs, f.MatchAllTags) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, task.Status) {
+		return false
+	}
+	if f.CreatedFrom != nil && task.CreationTs.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !task.CreationTs.Before(*f.CreatedTo) {
+		return false
+	}
+	if (f.DueFrom != nil || f.DueTo != nil) && task.DueTs == nil {
+		return false
+	}
+	if f.DueFrom != nil && task.DueTs.Before(*f.DueFrom) {
+		return false
+	}
+	if f.DueTo != nil && !task.DueTs.Before(*f.DueTo) {
+		return false
+	}
+	return true
+}
+
+// hasTags reports whether tagIDs holds any or, with all set, every one of wanted.
+func hasTags(tagIDs, wanted []int, all bool) bool {
+	for _, tagID := range wanted {
+		found := slices.Contains(tagIDs, tagID)
+		if found && !all {
+			return true
+		}
+		if !found && all {
+			return false
+		}
+	}
+	return all
+}
+
+// CompareTasks orders two tasks the way sort lists them, it returns a
+// negative number when a comes first.
+func CompareTasks(sort TaskSort, a, b *Task) int {
+	var byKey int
+	switch sort {
+	case SortByCreation:
+		// newest first all the way, ids included
+		return cmp.Or(b.CreationTs.Compare(a.CreationTs), cmp.Compare(b.ID, a.ID))
+	case SortByDue:
+		switch {
+		case a.DueTs == nil && b.DueTs == nil:
+		case a.DueTs == nil:
+			return 1
+		case b.DueTs == nil:
+			return -1
+		default:
+			byKey = a.DueTs.Compare(*b.DueTs)
+		}
+	default:
+		byKey = cmp.Compare(b.Priority, a.Priority)
+	}
+	return cmp.Or(byKey, cmp.Compare(a.ID, b.ID))
+}
+
+// TaskCursor marks the last task of a listing page by the values the page
+// is sorted on. Inserting or deleting other tasks does not move it, the next
+// page starts right after where that task stood.
+type TaskCursor struct {
+	Sort     TaskSort   `json:"s"`
+	ID       int        `json:"i"`
+	Priority int        `json:"p,omitempty"`
+	Ts       *time.Time `json:"t,omitempty"`
+}
+
+// CursorAfter returns the cursor continuing a listing sorted by sort past task.
+func CursorAfter(sort TaskSort, task *Task) TaskCursor {
+	cursor := TaskCursor{Sort: sort, ID: task.ID}
+	switch sort {
+	case SortByCreation:
+		ts := task.CreationTs
+		cursor.Ts = &ts
+	case SortByDue:
+		cursor.Ts = copyTs(task.DueTs)
+	default:
+		cursor.Priority = task.Priority
+	}
+	return cursor
+}
+
+// Task returns a task standing where the cursor points, to be compared with
+// CompareTasks.
+func (c TaskCursor) Task() *Task {
+	task := &Task{ID: c.ID, Priority: c.Priority}
+	switch c.Sort {
+	case SortByCreation:
+		if c.Ts != nil {
+			task.CreationTs = *c.Ts
+		}
+	case SortByDue:
+		task.DueTs = copyTs(c.Ts)
+	}
+	return task
+}
+
+// String encodes the cursor into the opaque token handed out to clients.
+func (c TaskCursor) String() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// ParseTaskCursor decodes a token made by TaskCursor.String and checks it
+// was made for a listing sorted by sort.
+func ParseTaskCursor(token string, sort TaskSort) (*TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+
+	cursor := &TaskCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+	if cursor.Sort != sort || cursor.ID <= 0 || (sort == SortByCreation && cursor.Ts == nil) {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func copyTs(ts *time.Time) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	tsCopy := *ts
+	return &tsCopy
+}
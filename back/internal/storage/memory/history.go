@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+)
+
+func (s *Storage) GetTaskHistory(ctx context.Context, taskID, userID, limit, offset int) ([]storage.TaskAction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	actions := []storage.TaskAction{}
+	for i := len(s.taskActions) - 1; i >= 0 && (limit < 0 || len(actions) < limit); i-- {
+		action := s.taskActions[i]
+		if action.UserID != userID || action.TaskID != taskID {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+
+		action.Before = snapshot(action.Before)
+		action.After = snapshot(action.After)
+		action.ChangedFields = storage.ChangedFields(action.Before, action.After)
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+func (s *Storage) UndoTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.revertTask("storage.memory.UndoTask", taskID, userID, false)
+}
+
+func (s *Storage) RedoTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.revertTask("storage.memory.RedoTask", taskID, userID, true)
+}
+
+// revertTask undoes or, when redo is set, redoes the user's latest change of
+// a task by writing back the matching snapshot and logs it as a new action.
+// The restored status has to be part of the current workflow of the project
+// and the task is placed again rather than given its old priority back.
+func (s *Storage) revertTask(op string, taskID, userID int, redo bool) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return nil, fmt.Errorf(`'%s: task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	actions := []storage.TaskAction{}
+	for _, action := range s.taskActions {
+		if action.UserID == userID && action.TaskID == taskID {
+			actions = append(actions, action)
+		}
+	}
+
+	target, state, err := storage.PlanRevert(actions, redo)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, err)
+	}
+
+	// the task stays where it is when the project it was in is gone
+	projectID := task.ProjectID
+	if project, ok := s.projects[state.ProjectID]; ok && project.UserID == userID {
+		projectID = state.ProjectID
+	}
+	if state.Status != task.Status && !s.projectWorkflow(projectID).Has(state.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d] from status [%d] to [%d]: %w'`, op, taskID, task.Status, state.Status, storage.ErrInvalidTransition)
+	}
+
+	priority, err := s.restorePriority(task, state, projectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task [%d] in project [%d]: %w'`, op, taskID, projectID, err)
+	}
+
+	before := snapshot(task)
+	state = snapshot(state)
+	task.Title = state.Title
+	task.Description = state.Description
+	task.Status = state.Status
+	task.StatusTs = state.StatusTs
+	task.Priority = priority
+	task.ArchivedTs = state.ArchivedTs
+	task.StartTs = state.StartTs
+	task.DueTs = state.DueTs
+	task.Recurrence = state.Recurrence
+	task.ParentID = s.restorableParent(userID, taskID, state.ParentID)
+	task.ProjectID = projectID
+	task.TagIDs = slices.DeleteFunc(state.TagIDs, func(tagID int) bool {
+		tag, ok := s.tags[tagID]
+		return !ok || tag.UserID != userID
+	})
+
+	actionType := storage.UndoTaskType
+	if redo {
+		actionType = storage.RedoTaskType
+	}
+	s.addAction(actionType, userID, taskID, before, task)
+	targetActionID := target.ID
+	s.taskActions[len(s.taskActions)-1].TargetActionID = &targetActionID
+	if storage.IsDoneStatus(task.Status) && !storage.IsDoneStatus(before.Status) {
+		metrics.TasksClosed.Inc()
+	}
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+// restorePriority returns the priority of a task restored to state in
+// projectID. A done task sinks to the bottom. An open task keeps its place
+// when it stays open in the same project at the same priority, otherwise it
+// goes back where its snapshot priority ranks among the project's current
+// open tasks, so it never collides with tasks moved since.
+func (s *Storage) restorePriority(task, state *storage.Task, projectID int) (int, error) {
+	switch {
+	case storage.IsDoneStatus(state.Status):
+		return storage.TaskPriorityClosed, nil
+	case !storage.IsDoneStatus(task.Status) && projectID == task.ProjectID && state.Priority == task.Priority:
+		return task.Priority, nil
+	}
+
+	position := storage.TaskPosition{Place: storage.PlaceBottom}
+	var anchor *storage.Task
+	for _, other := range s.tasks {
+		if other.ProjectID != projectID || other.ID == task.ID || other.ArchivedTs != nil || storage.IsDoneStatus(other.Status) || other.Priority >= state.Priority {
+			continue
+		}
+		if anchor == nil || other.Priority > anchor.Priority || other.Priority == anchor.Priority && other.ID < anchor.ID {
+			anchor = other
+		}
+	}
+	if anchor != nil {
+		position = storage.TaskPosition{Place: storage.PlaceBefore, AnchorID: anchor.ID}
+	}
+
+	return s.placeInProject(projectID, task.ID, position)
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+var _ storage.Storage = (*Storage)(nil)
+
+// Storage keeps everything in process memory. It is safe for concurrent use
+// and is meant for tests and local demos, nothing survives a restart.
+type Storage struct {
+	mu sync.RWMutex
+
+	users         map[int]*storage.User
+	tasks         map[int]*storage.Task
+	tags          map[int]*storage.Tag
+	projects      map[int]*storage.Project
+	views         map[int]*storage.SavedView
+	taskActions   []storage.TaskAction
+	lastUserID    int
+	lastTaskID    int
+	lastTagID     int
+	lastProjectID int
+	lastActionID  int
+	lastViewID    int
+
+	// occurrences maps a closed recurring task to the occurrence it spawned.
+	occurrences map[int]int
+}
+
+func New() *Storage {
+	return &Storage{
+		users:       make(map[int]*storage.User),
+		tasks:       make(map[int]*storage.Task),
+		tags:        make(map[int]*storage.Tag),
+		projects:    make(map[int]*storage.Project),
+		views:       make(map[int]*storage.SavedView),
+		occurrences: make(map[int]int),
+	}
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// snapshot copies a task so later changes to it do not leak into the log.
+func snapshot(task *storage.Task) *storage.Task {
+	if task == nil {
+		return nil
+	}
+
+	taskCopy := *task
+	taskCopy.StatusTs = maps.Clone(task.StatusTs)
+	taskCopy.ArchivedTs = copyTs(task.ArchivedTs)
+	taskCopy.StartTs = copyTs(task.StartTs)
+	taskCopy.DueTs = copyTs(task.DueTs)
+	taskCopy.ParentID = copyID(task.ParentID)
+	taskCopy.TagIDs = slices.Clone(task.TagIDs)
+
+	return &taskCopy
+}
+
+func copyTs(ts *time.Time) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	tsCopy := *ts
+	return &tsCopy
+}
+
+func copyID(id *int) *int {
+	if id == nil {
+		return nil
+	}
+
+	idCopy := *id
+	return &idCopy
+}
+
+func (s *Storage) addAction(actionType, userID, taskID int, before, after *storage.Task) {
+	s.lastActionID++
+	s.taskActions = append(s.taskActions, storage.TaskAction{
+		ID:         s.lastActionID,
+		ActionType: actionType,
+		UserID:     userID,
+		TaskID:     taskID,
+		Ts:         time.Now(),
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	})
+}
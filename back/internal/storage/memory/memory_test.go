@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"testing"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return New()
+	})
+}
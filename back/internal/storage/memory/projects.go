@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+func (s *Storage) addProject(userID int, name string, isInbox bool, workflow storage.Workflow) *storage.Project {
+	if len(workflow.Statuses) == 0 {
+		workflow = storage.DefaultWorkflow()
+	}
+
+	s.lastProjectID++
+	project := &storage.Project{
+		ID:         s.lastProjectID,
+		UserID:     userID,
+		Name:       name,
+		IsInbox:    isInbox,
+		Workflow:   workflow,
+		CreationTs: time.Now(),
+	}
+	s.projects[project.ID] = project
+
+	return project
+}
+
+// resolveProject checks that the project belongs to the user, a zero id
+// stands for the user's inbox.
+func (s *Storage) resolveProject(op string, userID, projectID int) (int, error) {
+	for _, project := range s.projects {
+		if project.UserID != userID {
+			continue
+		}
+		if project.ID == projectID || (projectID == 0 && project.IsInbox) {
+			return project.ID, nil
+		}
+	}
+	return 0, fmt.Errorf(`'%s: project [%d] for user [%d]: %w'`, op, projectID, userID, storage.ErrNotFound)
+}
+
+// placeInProject returns the priority that puts a task at position among the
+// other open tasks of a project. They are renumbered when their priorities
+// leave no room.
+func (s *Storage) placeInProject(projectID, taskID int, position storage.TaskPosition) (int, error) {
+	var tasks []*storage.Task
+	for _, task := range s.tasks {
+		if task.ProjectID == projectID && task.ID != taskID && task.ArchivedTs == nil && !storage.IsDoneStatus(task.Status) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	ids := make([]int, len(tasks))
+	order := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		order[i] = task.Priority
+	}
+
+	index, ok := position.Index(ids)
+	if !ok {
+		return 0, storage.ErrInvalidAnchor
+	}
+
+	priority, spread := storage.PlaceAt(order, index)
+	if spread != nil {
+		for i, task := range tasks {
+			if i >= index {
+				task.Priority = spread[i+1]
+			} else {
+				task.Priority = spread[i]
+			}
+		}
+	}
+
+	return priority, nil
+}
+
+// statusPriority returns the priority of a task once it is in status. Done
+// tasks sink to the bottom, a task leaving its done status is put back on
+// top of its project and any other task keeps its place.
+func (s *Storage) statusPriority(task *storage.Task, status int8) (int, error) {
+	switch {
+	case storage.IsDoneStatus(status):
+		return storage.TaskPriorityClosed, nil
+	case storage.IsDoneStatus(task.Status):
+		return s.placeInProject(task.ProjectID, task.ID, storage.TaskPosition{Place: storage.PlaceTop})
+	}
+	return task.Priority, nil
+}
+
+// projectWorkflow returns the workflow the tasks of a project follow.
+func (s *Storage) projectWorkflow(projectID int) storage.Workflow {
+	if project, ok := s.projects[projectID]; ok {
+		return project.Workflow
+	}
+	return storage.DefaultWorkflow()
+}
+
+// moveTask puts a task on top of another project and logs the move.
+// Done tasks keep their priority at the bottom.
+func (s *Storage) moveTask(task *storage.Task, projectID int) {
+	before := snapshot(task)
+
+	if !storage.IsDoneStatus(task.Status) {
+		// a top placement has no anchor to miss
+		task.Priority, _ = s.placeInProject(projectID, task.ID, storage.TaskPosition{Place: storage.PlaceTop})
+	}
+	task.ProjectID = projectID
+	s.addAction(storage.MoveTaskType, task.UserID, task.ID, before, task)
+}
+
+func (s *Storage) projectNameTaken(userID, projectID int, name string) bool {
+	for _, project := range s.projects {
+		if project.UserID == userID && project.ID != projectID && project.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) CreateProject(ctx context.Context, newProject *storage.Project) (*storage.Project, error) {
+	const op = "storage.memory.CreateProject"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.projectNameTaken(newProject.UserID, 0, newProject.Name) {
+		return nil, fmt.Errorf(`'%s: project with name [%s]: %w'`, op, newProject.Name, storage.ErrAlreadyExists)
+	}
+
+	projectCopy := *s.addProject(newProject.UserID, newProject.Name, false, newProject.Workflow)
+	return &projectCopy, nil
+}
+
+func (s *Storage) UpdateProject(ctx context.Context, updatedProject *storage.Project) (*storage.Project, error) {
+	const op = "storage.memory.UpdateProject"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[updatedProject.ID]
+	if !ok || project.UserID != updatedProject.UserID {
+		return nil, fmt.Errorf(`'%s: project [%d] for user [%d]: %w'`, op, updatedProject.ID, updatedProject.UserID, storage.ErrNotFound)
+	}
+	if s.projectNameTaken(project.UserID, project.ID, updatedProject.Name) {
+		return nil, fmt.Errorf(`'%s: project with name [%s]: %w'`, op, updatedProject.Name, storage.ErrAlreadyExists)
+	}
+
+	project.Name = updatedProject.Name
+	if len(updatedProject.Workflow.Statuses) > 0 {
+		project.Workflow = updatedProject.Workflow
+	}
+
+	projectCopy := *project
+	return &projectCopy, nil
+}
+
+func (s *Storage) DeleteProject(ctx context.Context, projectID, userID int) error {
+	const op = "storage.memory.DeleteProject"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok || project.UserID != userID {
+		return fmt.Errorf(`'%s: project [%d] for user [%d]: %w'`, op, projectID, userID, storage.ErrNotFound)
+	}
+	if project.IsInbox {
+		return fmt.Errorf(`'%s: project [%d] is the inbox and cannot be deleted: %w'`, op, projectID, storage.ErrForbidden)
+	}
+
+	inboxID, err := s.resolveProject(op, userID, 0)
+	if err != nil {
+		return err
+	}
+
+	var tasks []*storage.Task
+	for _, task := range s.tasks {
+		if task.ProjectID == projectID {
+			tasks = append(tasks, task)
+		}
+	}
+
+	// lowest first, so the moved tasks keep their order on top of the inbox
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority < tasks[j].Priority
+		}
+		return tasks[i].ID > tasks[j].ID
+	})
+
+	for _, task := range tasks {
+		s.moveTask(task, inboxID)
+	}
+
+	delete(s.projects, projectID)
+	return nil
+}
+
+func (s *Storage) GetProjects(ctx context.Context, userID int) ([]storage.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := []storage.Project{}
+	for _, project := range s.projects {
+		if project.UserID == userID {
+			projects = append(projects, *project)
+		}
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].IsInbox != projects[j].IsInbox {
+			return projects[i].IsInbox
+		}
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return projects[i].ID < projects[j].ID
+	})
+
+	return projects, nil
+}
+
+func (s *Storage) MoveTask(ctx context.Context, taskID, projectID, userID int) (*storage.Task, error) {
+	const op = "storage.memory.MoveTask"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask(op, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetID, err := s.resolveProject(op, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.ProjectID != targetID {
+		s.moveTask(task, targetID)
+	}
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
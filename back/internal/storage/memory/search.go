@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"todo_list_service/internal/storage"
+	"unicode"
+)
+
+// highlight wraps the words of text starting with any of terms, it returns
+// the highlighted text and how many words matched each term.
+func highlight(text string, terms []string) (string, map[string]int) {
+	hits := map[string]int{}
+
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		lower := strings.ToLower(string(word))
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				hits[term]++
+				matched = true
+			}
+		}
+		if matched {
+			b.WriteString(storage.MatchStart + string(word) + storage.MatchStop)
+		} else {
+			b.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+
+	return b.String(), hits
+}
+
+// SearchTasks matches every word of query as a prefix of a word, it does no
+// stemming. Title matches weigh twice as much as description ones.
+func (s *Storage) SearchTasks(ctx context.Context, userID int, query string, limit int) ([]storage.TaskMatch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := []storage.TaskMatch{}
+
+	terms := storage.SearchTerms(query)
+	if len(terms) == 0 {
+		return matches, nil
+	}
+
+	for _, task := range s.sortedTasks(userID) {
+		title, titleHits := highlight(task.Title, terms)
+		description, descriptionHits := highlight(task.Description, terms)
+
+		rank := 0
+		for _, term := range terms {
+			if titleHits[term] == 0 && descriptionHits[term] == 0 {
+				rank = 0
+				break
+			}
+			rank += 2*titleHits[term] + descriptionHits[term]
+		}
+		if rank == 0 {
+			continue
+		}
+
+		matches = append(matches, storage.TaskMatch{
+			Task:               task,
+			Rank:               float64(rank),
+			TitleSnippet:       storage.HighlightSnippet(title),
+			DescriptionSnippet: storage.HighlightSnippet(description),
+		})
+	}
+
+	// sortedTasks already orders by priority and id, a stable sort keeps that for equal ranks
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+
+	if limit >= 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
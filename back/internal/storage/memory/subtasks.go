@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// resolveParent checks that the parent task belongs to the user and is not
+// archived.
+func (s *Storage) resolveParent(op string, userID, parentID int) error {
+	if _, err := s.activeTask(op, parentID, userID); err != nil {
+		return fmt.Errorf(`'%s: parent task [%d] for user [%d]: %w'`, op, parentID, userID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// createsCycle reports whether taskID is parentID itself or one of its
+// ancestors, so nesting the task under parentID would close a loop.
+func (s *Storage) createsCycle(taskID, parentID int) bool {
+	seen := map[int]bool{}
+	for id := parentID; !seen[id]; {
+		if id == taskID {
+			return true
+		}
+		seen[id] = true
+
+		task, ok := s.tasks[id]
+		if !ok || task.ParentID == nil {
+			return false
+		}
+		id = *task.ParentID
+	}
+	return false
+}
+
+// restorableParent returns the parent a reverted task may get back: nil
+// once the parent is gone or nesting under it would close a loop.
+func (s *Storage) restorableParent(userID, taskID int, parentID *int) *int {
+	if parentID == nil {
+		return nil
+	}
+	if parent, ok := s.tasks[*parentID]; !ok || parent.UserID != userID || s.createsCycle(taskID, *parentID) {
+		return nil
+	}
+	return parentID
+}
+
+// descendants returns the subtasks of a task at any depth ordered by id.
+func (s *Storage) descendants(taskID int) []*storage.Task {
+	var found []*storage.Task
+	parents := map[int]bool{taskID: true}
+	for grown := true; grown; {
+		grown = false
+		for _, task := range s.tasks {
+			if task.ParentID != nil && parents[*task.ParentID] && !parents[task.ID] {
+				parents[task.ID] = true
+				found = append(found, task)
+				grown = true
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].ID < found[j].ID
+	})
+
+	return found
+}
+
+// closeSubtasks applies the close policy to the open active subtasks, at any
+// depth, of a task being closed. Cascaded closes are logged as updates of
+// the subtasks and spawn the next occurrences of recurring ones. It returns
+// how many subtasks it closed.
+func (s *Storage) closeSubtasks(op string, taskID int, policy storage.ClosePolicy) (int, error) {
+	if policy == storage.ClosePolicyIgnore {
+		return 0, nil
+	}
+
+	var open []*storage.Task
+	for _, task := range s.descendants(taskID) {
+		if task.ArchivedTs == nil && !storage.IsDoneStatus(task.Status) {
+			open = append(open, task)
+		}
+	}
+	if len(open) == 0 {
+		return 0, nil
+	}
+	if policy == storage.ClosePolicyBlock {
+		return 0, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, storage.ErrOpenSubtasks)
+	}
+
+	for _, task := range open {
+		before := snapshot(task)
+		task.Status = storage.TaskStatusClosed
+		task.StatusTs = task.StatusTs.With(task.Status, time.Now())
+		task.Priority = storage.TaskPriorityClosed
+		s.addAction(storage.UpdateTaskType, task.UserID, task.ID, before, task)
+		if err := s.spawnOccurrence(task); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(open), nil
+}
+
+// unlinkSubtasks turns the subtasks of a deleted task into top level tasks.
+func (s *Storage) unlinkSubtasks(taskID int) {
+	for _, task := range s.tasks {
+		if task.ParentID != nil && *task.ParentID == taskID {
+			task.ParentID = nil
+		}
+	}
+}
+
+func (s *Storage) SetTaskParent(ctx context.Context, taskID, parentID, userID int) (*storage.Task, error) {
+	const op = "storage.memory.SetTaskParent"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask(op, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.ParentTaskID() == parentID {
+		taskCopy := *task
+		return &taskCopy, nil
+	}
+
+	var parent *int
+	if parentID != 0 {
+		if err := s.resolveParent(op, userID, parentID); err != nil {
+			return nil, err
+		}
+		if s.createsCycle(taskID, parentID) {
+			return nil, fmt.Errorf(`'%s: task [%d] under [%d]: %w'`, op, taskID, parentID, storage.ErrTaskCycle)
+		}
+		parent = &parentID
+	}
+
+	before := snapshot(task)
+	task.ParentID = parent
+	s.addAction(storage.SetTaskParentType, userID, taskID, before, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) GetSubtasks(ctx context.Context, taskID, userID int) ([]storage.Task, error) {
+	const op = "storage.memory.GetSubtasks"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if task, ok := s.tasks[taskID]; !ok || task.UserID != userID {
+		return nil, fmt.Errorf(`'%s: task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	subtasks := []storage.Task{}
+	for _, task := range s.sortedTasks(userID) {
+		if task.ParentTaskID() == taskID {
+			subtasks = append(subtasks, task)
+		}
+	}
+
+	return subtasks, nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"todo_list_service/internal/storage"
+)
+
+// ensureTags returns the ids of the user's tags named names, in the same
+// order, creating the ones the user does not have yet.
+func (s *Storage) ensureTags(userID int, names []string) []int {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		id := 0
+		for _, tag := range s.tags {
+			if tag.UserID == userID && tag.Name == name {
+				id = tag.ID
+				break
+			}
+		}
+		if id == 0 {
+			s.lastTagID++
+			id = s.lastTagID
+			s.tags[id] = &storage.Tag{ID: id, UserID: userID, Name: name}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *Storage) CreateTag(ctx context.Context, newTag *storage.Tag) (*storage.Tag, error) {
+	const op = "storage.memory.CreateTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range s.tags {
+		if tag.UserID == newTag.UserID && tag.Name == newTag.Name {
+			return nil, fmt.Errorf(`'%s: tag with name [%s]: %w'`, op, newTag.Name, storage.ErrAlreadyExists)
+		}
+	}
+
+	s.lastTagID++
+	tag := &storage.Tag{
+		ID:     s.lastTagID,
+		UserID: newTag.UserID,
+		Name:   newTag.Name,
+		Color:  newTag.Color,
+	}
+	s.tags[tag.ID] = tag
+
+	tagCopy := *tag
+	return &tagCopy, nil
+}
+
+func (s *Storage) UpdateTag(ctx context.Context, updatedTag *storage.Tag) (*storage.Tag, error) {
+	const op = "storage.memory.UpdateTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[updatedTag.ID]
+	if !ok || tag.UserID != updatedTag.UserID {
+		return nil, fmt.Errorf(`'%s: tag [%d] for user [%d]: %w'`, op, updatedTag.ID, updatedTag.UserID, storage.ErrNotFound)
+	}
+	for _, other := range s.tags {
+		if other.ID != tag.ID && other.UserID == tag.UserID && other.Name == updatedTag.Name {
+			return nil, fmt.Errorf(`'%s: tag with name [%s]: %w'`, op, updatedTag.Name, storage.ErrAlreadyExists)
+		}
+	}
+
+	tag.Name = updatedTag.Name
+	tag.Color = updatedTag.Color
+
+	tagCopy := *tag
+	return &tagCopy, nil
+}
+
+func (s *Storage) DeleteTag(ctx context.Context, tagID, userID int) error {
+	const op = "storage.memory.DeleteTag"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tag, ok := s.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return fmt.Errorf(`'%s: tag [%d] for user [%d]: %w'`, op, tagID, userID, storage.ErrNotFound)
+	}
+
+	delete(s.tags, tagID)
+	for _, task := range s.tasks {
+		if slices.Contains(task.TagIDs, tagID) {
+			task.TagIDs = storage.TaskWithTag(task, tagID, false).TagIDs
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) GetTags(ctx context.Context, userID int) ([]storage.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := []storage.Tag{}
+	for _, tag := range s.tags {
+		if tag.UserID == userID {
+			tags = append(tags, *tag)
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Name != tags[j].Name {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].ID < tags[j].ID
+	})
+
+	return tags, nil
+}
+
+func (s *Storage) AttachTag(ctx context.Context, taskID, tagID, userID int) (*storage.Task, error) {
+	return s.setTaskTag("storage.memory.AttachTag", taskID, tagID, userID, true)
+}
+
+func (s *Storage) DetachTag(ctx context.Context, taskID, tagID, userID int) (*storage.Task, error) {
+	return s.setTaskTag("storage.memory.DetachTag", taskID, tagID, userID, false)
+}
+
+// setTaskTag attaches or detaches a tag depending on attach and logs the
+// change. Repeating an applied change is a no-op.
+func (s *Storage) setTaskTag(op string, taskID, tagID, userID int, attach bool) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask(op, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, ok := s.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return nil, fmt.Errorf(`'%s: tag [%d] for user [%d]: %w'`, op, tagID, userID, storage.ErrNotFound)
+	}
+
+	updated := storage.TaskWithTag(task, tagID, attach)
+	if slices.Equal(task.TagIDs, updated.TagIDs) {
+		return updated, nil
+	}
+
+	before := snapshot(task)
+	task.TagIDs = updated.TagIDs
+
+	actionType := storage.AttachTagType
+	if !attach {
+		actionType = storage.DetachTagType
+	}
+	s.addAction(actionType, userID, taskID, before, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
@@ -0,0 +1,390 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+)
+
+// sortedTasks returns copies of the user's active tasks ordered the same way
+// the sql backends order them: by priority descending, ties broken by id.
+func (s *Storage) sortedTasks(userID int) []storage.Task {
+	tasks := []storage.Task{}
+	for _, task := range s.tasks {
+		if task.UserID == userID && task.ArchivedTs == nil {
+			tasks = append(tasks, *task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Priority != tasks[j].Priority {
+			return tasks[i].Priority > tasks[j].Priority
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks
+}
+
+// activeTask returns the user's task unless it is missing or archived.
+func (s *Storage) activeTask(op string, taskID, userID int) (*storage.Task, error) {
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return nil, fmt.Errorf(`'%s: task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+	if task.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, taskID, storage.ErrConflict)
+	}
+	return task, nil
+}
+
+func limitTasks(tasks []storage.Task, limit int) []storage.Task {
+	if limit >= 0 && len(tasks) > limit {
+		return tasks[:limit]
+	}
+	return tasks
+}
+
+// addTask puts a new task at priority in its project without logging it.
+func (s *Storage) addTask(newTask *storage.Task, projectID, priority int) *storage.Task {
+	now := time.Now()
+
+	s.lastTaskID++
+	task := &storage.Task{
+		ID:          s.lastTaskID,
+		Title:       newTask.Title,
+		Description: newTask.Description,
+		Status:      storage.TaskStatusOpened,
+		StatusTs:    storage.StatusTimes{}.With(storage.TaskStatusOpened, now),
+		UserID:      newTask.UserID,
+		ProjectID:   projectID,
+		ParentID:    copyID(newTask.ParentID),
+		Priority:    priority,
+		CreationTs:  now,
+		StartTs:     copyTs(newTask.StartTs),
+		DueTs:       copyTs(newTask.DueTs),
+		Recurrence:  newTask.Recurrence,
+	}
+	s.tasks[task.ID] = task
+
+	return task
+}
+
+// spawnOccurrence creates and logs the next occurrence of a recurring task
+// that has just been closed. The schedule follows the owner's time zone.
+// The series moves on to the new task, an instance that already spawned its
+// occurrence does not spawn another one when it is closed again.
+func (s *Storage) spawnOccurrence(closed *storage.Task) error {
+	if closed.Recurrence == "" {
+		return nil
+	}
+	if _, ok := s.occurrences[closed.ID]; ok {
+		return nil
+	}
+
+	var timeZone string
+	if user, ok := s.users[closed.UserID]; ok {
+		timeZone = user.TimeZone
+	}
+	loc, err := storage.LoadTimeZone(timeZone)
+	if err != nil {
+		return err
+	}
+
+	next, err := storage.NextOccurrence(closed, loc)
+	if err != nil || next == nil {
+		return err
+	}
+
+	// a top placement has no anchor to miss
+	priority, _ := s.placeInProject(next.ProjectID, 0, storage.TaskPosition{Place: storage.PlaceTop})
+	task := s.addTask(next, next.ProjectID, priority)
+	task.TagIDs = slices.DeleteFunc(next.TagIDs, func(tagID int) bool {
+		tag, ok := s.tags[tagID]
+		return !ok || tag.UserID != closed.UserID
+	})
+	s.occurrences[closed.ID] = task.ID
+	s.addAction(storage.CreateTaskType, task.UserID, task.ID, nil, task)
+
+	return nil
+}
+
+func (s *Storage) CreateTask(ctx context.Context, newTask *storage.Task) (*storage.Task, error) {
+	return s.createTask("storage.memory.CreateTask", newTask, nil, nil, storage.TaskPosition{Place: storage.PlaceTop})
+}
+
+func (s *Storage) CreateTaskWithTags(ctx context.Context, newTask *storage.Task, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	return s.createTask("storage.memory.CreateTaskWithTags", newTask, newTask.TagIDs, tagNames, position)
+}
+
+// createTask adds a task at position with the given tags, creating the
+// missing named ones, and logs it as one action.
+func (s *Storage) createTask(op string, newTask *storage.Task, tagIDs []int, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectID, err := s.resolveProject(op, newTask.UserID, newTask.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if newTask.ParentID != nil {
+		if err := s.resolveParent(op, newTask.UserID, *newTask.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	priority, err := s.placeInProject(projectID, 0, position)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task in project [%d]: %w'`, op, projectID, err)
+	}
+
+	task := s.addTask(newTask, projectID, priority)
+	for _, tagID := range append(slices.Clone(tagIDs), s.ensureTags(newTask.UserID, tagNames)...) {
+		if tag, ok := s.tags[tagID]; ok && tag.UserID == task.UserID {
+			task.TagIDs = storage.TaskWithTag(task, tagID, true).TagIDs
+		}
+	}
+	s.addAction(storage.CreateTaskType, task.UserID, task.ID, nil, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) UpdateTaskPriority(ctx context.Context, taskID, userID int, position storage.TaskPosition) (*storage.Task, error) {
+	const op = "storage.memory.UpdateTaskPriority"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask(op, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if storage.IsDoneStatus(task.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, storage.ErrTaskDone)
+	}
+
+	priority, err := s.placeInProject(task.ProjectID, task.ID, position)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, err)
+	}
+
+	before := snapshot(task)
+	task.Priority = priority
+	s.addAction(storage.UpdateTaskPriorityType, task.UserID, task.ID, before, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, updatedTask *storage.Task, closePolicy storage.ClosePolicy) (*storage.Task, error) {
+	const op = "storage.memory.UpdateTask"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask(op, updatedTask.ID, updatedTask.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.projectWorkflow(task.ProjectID).Allows(task.Status, updatedTask.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d] from status [%d] to [%d]: %w'`, op, task.ID, task.Status, updatedTask.Status, storage.ErrInvalidTransition)
+	}
+
+	// the task closes along with the subtasks the close policy closes
+	closing := storage.IsDoneStatus(updatedTask.Status) && !storage.IsDoneStatus(task.Status)
+	closed := 0
+	if closing {
+		cascaded, err := s.closeSubtasks(op, task.ID, closePolicy)
+		if err != nil {
+			return nil, err
+		}
+		closed = cascaded + 1
+	}
+
+	priority, err := s.statusPriority(task, updatedTask.Status)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task [%d]: %w'`, op, task.ID, err)
+	}
+
+	before := snapshot(task)
+	task.Title = updatedTask.Title
+	task.Description = updatedTask.Description
+	if task.Status != updatedTask.Status {
+		task.Status = updatedTask.Status
+		task.StatusTs = task.StatusTs.With(task.Status, time.Now())
+	}
+	task.Priority = priority
+	task.StartTs = copyTs(updatedTask.StartTs)
+	task.DueTs = copyTs(updatedTask.DueTs)
+	task.Recurrence = updatedTask.Recurrence
+	s.addAction(storage.UpdateTaskType, task.UserID, task.ID, before, task)
+
+	if closing {
+		if err := s.spawnOccurrence(task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to create next occurrence of task [%d]: %w'`, op, task.ID, err)
+		}
+	}
+	metrics.TasksClosed.Add(float64(closed))
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) GetTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	const op = "storage.memory.GetTask"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return nil, fmt.Errorf(`'%s: task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	return s.ListTasks(ctx, userID, storage.TaskFilter{}, limit)
+}
+
+func (s *Storage) ListTasks(ctx context.Context, userID int, filter storage.TaskFilter, limit int) ([]storage.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order := filter.OrderBy()
+	tasks := slices.DeleteFunc(s.sortedTasks(userID), func(task storage.Task) bool {
+		if filter.After != nil && storage.CompareTasks(order, &task, filter.After.Task()) <= 0 {
+			return true
+		}
+		return !filter.Matches(&task)
+	})
+	slices.SortStableFunc(tasks, func(a, b storage.Task) int {
+		return storage.CompareTasks(order, &a, &b)
+	})
+
+	return limitTasks(tasks, limit), nil
+}
+
+func (s *Storage) GetDueTasks(ctx context.Context, userID int, from *time.Time, to time.Time, limit int) ([]storage.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := []storage.Task{}
+	for _, task := range s.sortedTasks(userID) {
+		if storage.IsDoneStatus(task.Status) || task.DueTs == nil || !task.DueTs.Before(to) {
+			continue
+		}
+		if from != nil && task.DueTs.Before(*from) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	// sortedTasks already orders by priority and id, a stable sort keeps that for equal due dates
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DueTs.Before(*tasks[j].DueTs)
+	})
+
+	return limitTasks(tasks, limit), nil
+}
+
+func (s *Storage) ArchiveTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.activeTask("storage.memory.ArchiveTask", taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := snapshot(task)
+	archivedTs := time.Now()
+	task.ArchivedTs = &archivedTs
+	s.addAction(storage.ArchiveTaskType, task.UserID, task.ID, before, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	const op = "storage.memory.RestoreTask"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID || task.ArchivedTs == nil {
+		return nil, fmt.Errorf(`'%s: archived task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	before := snapshot(task)
+	task.ArchivedTs = nil
+	s.addAction(storage.RestoreTaskType, task.UserID, task.ID, before, task)
+
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (s *Storage) GetArchivedTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := []storage.Task{}
+	for _, task := range s.tasks {
+		if task.UserID == userID && task.ArchivedTs != nil {
+			tasks = append(tasks, *task)
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].ArchivedTs.Equal(*tasks[j].ArchivedTs) {
+			return tasks[i].ArchivedTs.After(*tasks[j].ArchivedTs)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return limitTasks(tasks, limit), nil
+}
+
+func (s *Storage) DeleteTask(ctx context.Context, taskID, userID int) error {
+	const op = "storage.memory.DeleteTask"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok || task.UserID != userID {
+		return fmt.Errorf(`'%s: task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	delete(s.tasks, taskID)
+	s.unlinkSubtasks(taskID)
+	s.addAction(storage.DeleteTaskType, userID, taskID, task, nil)
+
+	return nil
+}
+
+func (s *Storage) PurgeArchivedTasks(ctx context.Context, archivedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for id, task := range s.tasks {
+		if task.ArchivedTs != nil && task.ArchivedTs.Before(archivedBefore) {
+			delete(s.tasks, id)
+			s.unlinkSubtasks(id)
+			s.addAction(storage.DeleteTaskType, task.UserID, id, task, nil)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+func (s *Storage) CreateUser(ctx context.Context, username, hashedPassword, email string) (int, error) {
+	const op = "storage.memory.CreateUser"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			return 0, fmt.Errorf(`'%s: user with name [%s]: %w'`, op, username, storage.ErrAlreadyExists)
+		}
+	}
+
+	s.lastUserID++
+	s.users[s.lastUserID] = &storage.User{
+		ID:         s.lastUserID,
+		Username:   username,
+		Password:   hashedPassword,
+		Email:      email,
+		CreationTs: time.Now(),
+		TimeZone:   storage.DefaultTimeZone,
+	}
+	s.addProject(s.lastUserID, storage.InboxProjectName, true, storage.Workflow{})
+
+	return s.lastUserID, nil
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (*storage.User, error) {
+	const op = "storage.memory.GetUser"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			userCopy := *user
+			return &userCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf(`'%s: user [%s]: %w'`, op, username, storage.ErrNotFound)
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, userID int) (*storage.User, error) {
+	const op = "storage.memory.GetUserByID"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, fmt.Errorf(`'%s: user [%d]: %w'`, op, userID, storage.ErrNotFound)
+	}
+
+	userCopy := *user
+	return &userCopy, nil
+}
+
+func (s *Storage) ListUsers(ctx context.Context) ([]storage.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]storage.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, *user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users, nil
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error {
+	const op = "storage.memory.UpdateUserPassword"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return fmt.Errorf(`'%s: user [%d]: %w'`, op, userID, storage.ErrNotFound)
+	}
+
+	user.Password = hashedPassword
+	return nil
+}
+
+func (s *Storage) UpdateUserTimeZone(ctx context.Context, userID int, timeZone string) error {
+	const op = "storage.memory.UpdateUserTimeZone"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return fmt.Errorf(`'%s: user [%d]: %w'`, op, userID, storage.ErrNotFound)
+	}
+
+	user.TimeZone = timeZone
+	return nil
+}
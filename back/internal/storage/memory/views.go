@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// viewNameTaken reports whether another view of the user already has name.
+func (s *Storage) viewNameTaken(userID, viewID int, name string) bool {
+	for _, view := range s.views {
+		if view.ID != viewID && view.UserID == userID && view.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) CreateSavedView(ctx context.Context, newView *storage.SavedView) (*storage.SavedView, error) {
+	const op = "storage.memory.CreateSavedView"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.viewNameTaken(newView.UserID, 0, newView.Name) {
+		return nil, fmt.Errorf(`'%s: view with name [%s]: %w'`, op, newView.Name, storage.ErrAlreadyExists)
+	}
+
+	s.lastViewID++
+	view := &storage.SavedView{
+		ID:         s.lastViewID,
+		UserID:     newView.UserID,
+		Name:       newView.Name,
+		Query:      newView.Query,
+		CreationTs: time.Now(),
+	}
+	s.views[view.ID] = view
+
+	viewCopy := *view
+	return &viewCopy, nil
+}
+
+func (s *Storage) UpdateSavedView(ctx context.Context, updatedView *storage.SavedView) (*storage.SavedView, error) {
+	const op = "storage.memory.UpdateSavedView"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view, ok := s.views[updatedView.ID]
+	if !ok || view.UserID != updatedView.UserID {
+		return nil, fmt.Errorf(`'%s: view [%d] for user [%d]: %w'`, op, updatedView.ID, updatedView.UserID, storage.ErrNotFound)
+	}
+	if s.viewNameTaken(view.UserID, view.ID, updatedView.Name) {
+		return nil, fmt.Errorf(`'%s: view with name [%s]: %w'`, op, updatedView.Name, storage.ErrAlreadyExists)
+	}
+
+	view.Name = updatedView.Name
+	view.Query = updatedView.Query
+
+	viewCopy := *view
+	return &viewCopy, nil
+}
+
+func (s *Storage) DeleteSavedView(ctx context.Context, viewID, userID int) error {
+	const op = "storage.memory.DeleteSavedView"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view, ok := s.views[viewID]
+	if !ok || view.UserID != userID {
+		return fmt.Errorf(`'%s: view [%d] for user [%d]: %w'`, op, viewID, userID, storage.ErrNotFound)
+	}
+
+	delete(s.views, viewID)
+	return nil
+}
+
+func (s *Storage) GetSavedView(ctx context.Context, viewID, userID int) (*storage.SavedView, error) {
+	const op = "storage.memory.GetSavedView"
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	view, ok := s.views[viewID]
+	if !ok || view.UserID != userID {
+		return nil, fmt.Errorf(`'%s: view [%d] for user [%d]: %w'`, op, viewID, userID, storage.ErrNotFound)
+	}
+
+	viewCopy := *view
+	return &viewCopy, nil
+}
+
+func (s *Storage) GetSavedViews(ctx context.Context, userID int) ([]storage.SavedView, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	views := []storage.SavedView{}
+	for _, view := range s.views {
+		if view.UserID == userID {
+			views = append(views, *view)
+		}
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Name != views[j].Name {
+			return views[i].Name < views[j].Name
+		}
+		return views[i].ID < views[j].ID
+	})
+
+	return views, nil
+}
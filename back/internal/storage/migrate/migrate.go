@@ -0,0 +1,413 @@
+// Package migrate applies versioned sql migrations and records them in the
+// schema_migrations table.
+//
+// Migration files are named <version>_<name>.up.sql with an optional paired
+// <version>_<name>.down.sql used for rollbacks. Files without a direction
+// suffix are treated as up migrations.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration checksum does not match migration file")
+	ErrNoDownMigration  = errors.New("migration has no down file")
+)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Applied   bool      `json:"applied"`
+	AppliedAt time.Time `json:"applied_at"`
+	Drift     bool      `json:"drift"`
+}
+
+// Dialect holds the database specific bits of the runner.
+type Dialect struct {
+	// LockSQL and UnlockSQL guard the whole run against concurrent
+	// migrators, both are optional.
+	LockSQL   string
+	UnlockSQL string
+
+	Placeholder func(n int) string
+}
+
+const advisoryLockID = 7283640512
+
+var (
+	Postgres = Dialect{
+		LockSQL:     fmt.Sprintf("SELECT pg_advisory_lock(%d)", advisoryLockID),
+		UnlockSQL:   fmt.Sprintf("SELECT pg_advisory_unlock(%d)", advisoryLockID),
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	}
+
+	// Sqlite relies on the database wide write lock taken by every
+	// migration transaction.
+	Sqlite = Dialect{
+		Placeholder: func(int) string { return "?" },
+	}
+)
+
+const createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(256),
+    checksum VARCHAR(64),
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads all migrations from the root of fsys ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	const op = "storage.migrate.Load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read migrations directory: %w'`, op, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, down, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: %w'`, op, err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read migration file %s: %w'`, op, entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf(`'%s: migrations %s and %s share version %d'`, op, migration.Name, name, version)
+		}
+
+		if down {
+			migration.Down = string(content)
+			continue
+		}
+		if migration.Up != "" {
+			return nil, fmt.Errorf(`'%s: duplicate up migration for version %d'`, op, version)
+		}
+		migration.Up = string(content)
+		sum := sha256.Sum256(content)
+		migration.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf(`'%s: migration %d_%s has no up file'`, op, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseFileName splits "03_task_actions.up.sql" into its version, name and direction.
+func parseFileName(fileName string) (version int, name string, down bool, err error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+	switch {
+	case strings.HasSuffix(base, ".down"):
+		base, down = strings.TrimSuffix(base, ".down"), true
+	case strings.HasSuffix(base, ".up"):
+		base = strings.TrimSuffix(base, ".up")
+	}
+
+	digits := 0
+	for digits < len(base) && base[digits] >= '0' && base[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return 0, "", false, fmt.Errorf("migration file %s does not start with a version", fileName)
+	}
+
+	version, err = strconv.Atoi(base[:digits])
+	if err != nil {
+		return 0, "", false, fmt.Errorf("migration file %s has invalid version: %w", fileName, err)
+	}
+
+	name = strings.TrimLeft(base[digits:], "_.-")
+	return version, name, down, nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Up applies every pending migration, each one in its own transaction, and
+// returns the migrations that were applied.
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	const op = "storage.migrate.Up"
+
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(done); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return applied, fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the migrations that were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (reverted []Migration, err error) {
+	const op = "storage.migrate.Down"
+
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(done); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return reverted, fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	return reverted, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	const op = "storage.migrate.Status"
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get connection: %w'`, op, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, createTableSQL); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to create schema_migrations: %w'`, op, err)
+	}
+
+	done, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if applied, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = applied.appliedAt
+			status.Drift = applied.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending reports whether some known migration has not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) (bool, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, status := range statuses {
+		if !status.Applied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect.LockSQL != "" {
+		if _, err := conn.ExecContext(ctx, m.dialect.LockSQL); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			_, _ = conn.ExecContext(context.Background(), m.dialect.UnlockSQL)
+		}()
+	}
+
+	if _, err := conn.ExecContext(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]appliedMigration)
+	for rows.Next() {
+		var (
+			version int
+			applied appliedMigration
+		)
+		if err := rows.Scan(&version, &applied.checksum, &applied.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		done[version] = applied
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	return done, nil
+}
+
+// verify refuses to go on when an applied migration was edited afterwards.
+func (m *Migrator) verify(done map[int]appliedMigration) error {
+	for _, migration := range m.migrations {
+		applied, ok := done[migration.Version]
+		if ok && applied.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	p := m.dialect.Placeholder
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return fmt.Errorf("failed to execute migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO schema_migrations (version, name, checksum) VALUES (%s, %s, %s)", p(1), p(2), p(3))
+	if _, err := tx.ExecContext(ctx, query, migration.Version, migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	query := fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %s", m.dialect.Placeholder(1))
+	if _, err := tx.ExecContext(ctx, query, migration.Version); err != nil {
+		return fmt.Errorf("failed to unrecord migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit revert of %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	return nil
+}
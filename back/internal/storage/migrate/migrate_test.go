@@ -0,0 +1,257 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"01_users.up.sql":   file("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)"),
+		"01_users.down.sql": file("DROP TABLE users"),
+		"02_tasks.up.sql":   file("CREATE TABLE tasks (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id))"),
+		"02_tasks.down.sql": file("DROP TABLE tasks"),
+		"03_task_title.sql": file("ALTER TABLE tasks ADD COLUMN title TEXT"),
+		"04_tags.up.sql":    file("CREATE TABLE tags (id INTEGER PRIMARY KEY)"),
+		"04_tags.down.sql":  file("DROP TABLE tags"),
+		// only sql files at the root are migrations
+		"README.md":         file("not a migration"),
+		"old/05_old.up.sql": file("CREATE TABLE old (id INTEGER)"),
+	}
+}
+
+func newMigrator(t *testing.T, db *sql.DB, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+
+	m, err := New(db, Sqlite, fsys)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return m
+}
+
+func versions(migrations []Migration) []int {
+	result := []int{}
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n); err != nil {
+		t.Fatalf("failed to look up table %s: %v", name, err)
+	}
+	return n > 0
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	fsys := testMigrations()
+	delete(fsys, "04_tags.up.sql")
+	delete(fsys, "04_tags.down.sql")
+	m := newMigrator(t, db, fsys)
+
+	if pending, err := m.Pending(ctx); err != nil || !pending {
+		t.Fatalf("Pending on an empty database returned %v, %v, want true", pending, err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Up applied %v, want [1 2 3]", got)
+	}
+	if _, err := db.Exec(`INSERT INTO tasks (user_id, title) VALUES (NULL, 'migrated')`); err != nil {
+		t.Fatalf("migrated schema is unusable: %v", err)
+	}
+
+	// a second run is a no-op
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("second Up: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("second Up applied %v", versions(applied))
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Drift || status.AppliedAt.IsZero() {
+			t.Fatalf("Status reported %+v, want applied without drift", status)
+		}
+	}
+	if pending, err := m.Pending(ctx); err != nil || pending {
+		t.Fatalf("Pending after Up returned %v, %v, want false", pending, err)
+	}
+
+	// a new migration is the only one applied by the next run
+	m = newMigrator(t, db, testMigrations())
+	if pending, err := m.Pending(ctx); err != nil || !pending {
+		t.Fatalf("Pending with a new migration returned %v, %v, want true", pending, err)
+	}
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int{4}) {
+		t.Fatalf("Up applied %v, want [4]", got)
+	}
+}
+
+func TestUpFailure(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	fsys := testMigrations()
+	fsys["04_tags.up.sql"] = file("CREATE TABLE tags (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1)")
+
+	applied, err := newMigrator(t, db, fsys).Up(ctx)
+	if err == nil {
+		t.Fatal("Up of a broken migration returned no error")
+	}
+	if got := versions(applied); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("Up applied %v before failing, want [1 2 3]", got)
+	}
+	// the broken migration is rolled back as a whole
+	if tableExists(t, db, "tags") {
+		t.Fatal("failed migration left its table behind")
+	}
+
+	statuses, err := newMigrator(t, db, fsys).Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 4 || last.Applied {
+		t.Fatalf("Status reported %+v for the failed migration", last)
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+
+	if _, err := newMigrator(t, db, testMigrations()).Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	edited := testMigrations()
+	edited["02_tasks.up.sql"] = file("CREATE TABLE tasks (id INTEGER PRIMARY KEY)")
+	edited["05_labels.up.sql"] = file("CREATE TABLE labels (id INTEGER PRIMARY KEY)")
+	m := newMigrator(t, db, edited)
+
+	if _, err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Up after editing an applied migration returned %v, want ErrChecksumMismatch", err)
+	}
+	if tableExists(t, db, "labels") {
+		t.Fatal("Up applied a new migration despite the checksum mismatch")
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Down after editing an applied migration returned %v, want ErrChecksumMismatch", err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Drift != (status.Version == 2) {
+			t.Fatalf("Status reported %+v", status)
+		}
+	}
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db, testMigrations())
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if got := versions(reverted); !slices.Equal(got, []int{4}) {
+		t.Fatalf("Down reverted %v, want [4]", got)
+	}
+	if tableExists(t, db, "tags") {
+		t.Fatal("Down left the table of the reverted migration")
+	}
+	if pending, err := m.Pending(ctx); err != nil || !pending {
+		t.Fatalf("Pending after Down returned %v, %v, want true", pending, err)
+	}
+
+	// 03 has no down file, the run stops there
+	reverted, err = m.Down(ctx, 2)
+	if !errors.Is(err, ErrNoDownMigration) {
+		t.Fatalf("Down past a migration without down file returned %v, want ErrNoDownMigration", err)
+	}
+	if len(reverted) != 0 {
+		t.Fatalf("Down reverted %v", versions(reverted))
+	}
+
+	// the reverted migration is applied again
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int{4}) || !tableExists(t, db, "tags") {
+		t.Fatalf("Up after Down applied %v, want [4]", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := versions(migrations); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("Load returned versions %v, want [1 2 3 4]", got)
+	}
+	if migrations[1].Name != "tasks" || migrations[1].Down == "" || migrations[2].Name != "task_title" || migrations[2].Down != "" {
+		t.Fatalf("Load returned %+v", migrations)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no version":      {"users.up.sql": file("SELECT 1")},
+		"shared version":  {"01_users.up.sql": file("SELECT 1"), "01_tasks.up.sql": file("SELECT 1")},
+		"duplicate up":    {"01_users.up.sql": file("SELECT 1"), "01_users.sql": file("SELECT 1")},
+		"down without up": {"01_users.down.sql": file("SELECT 1")},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("Load of %s returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"todo_list_service/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the postgres error code of a unique constraint failing.
+const uniqueViolation = "23505"
+
+// storageError tells the storage error kind of a failed query: no rows is
+// storage.ErrNotFound, as every lookup is scoped by user, and a unique
+// violation storage.ErrAlreadyExists. Other errors are returned as they are.
+func storageError(err error) error {
+	var pqErr *pq.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return fmt.Errorf("%w: %w", storage.ErrAlreadyExists, err)
+	}
+	return err
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+)
+
+// marshalSnapshot encodes a task for the task_actions snapshot columns, a nil
+// task is stored as NULL.
+func marshalSnapshot(task *storage.Task) (any, error) {
+	if task == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func unmarshalSnapshot(data []byte) (*storage.Task, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	task := &storage.Task{}
+	if err := json.Unmarshal(data, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func insertTaskAction(ctx context.Context, tx *sql.Tx, actionType, userID, taskID int, before, after *storage.Task) error {
+	return insertRevertAction(ctx, tx, actionType, userID, taskID, nil, before, after)
+}
+
+// insertRevertAction logs an action, undo and redo actions also keep the id
+// of the action they revert.
+func insertRevertAction(ctx context.Context, tx *sql.Tx, actionType, userID, taskID int, targetActionID *int, before, after *storage.Task) error {
+	beforeSnapshot, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterSnapshot, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO task_actions (action_type, user_id, task_id, target_action_id, before_snapshot, after_snapshot)
+		VALUES ($1, $2, $3, $4, $5, $6)`, actionType, userID, taskID, targetActionID, beforeSnapshot, afterSnapshot)
+	return err
+}
+
+// lockTask reads the user's task inside tx and locks its row until the
+// transaction ends, so the before snapshot matches what gets updated.
+func lockTask(ctx context.Context, tx *sql.Tx, taskID, userID int) (*storage.Task, error) {
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE user_id = $1 AND id = $2 FOR UPDATE`, userID, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, err
+	}
+
+	if err := loadTaskTags(ctx, tx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+const taskActionColumns = `id, action_type, user_id, task_id, ts, target_action_id, before_snapshot, after_snapshot`
+
+func scanTaskActions(rows *sql.Rows) ([]storage.TaskAction, error) {
+	defer rows.Close()
+
+	actions := []storage.TaskAction{}
+	for rows.Next() {
+		var action storage.TaskAction
+		var before, after []byte
+		if err := rows.Scan(&action.ID, &action.ActionType, &action.UserID, &action.TaskID, &action.Ts, &action.TargetActionID, &before, &after); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if action.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot of action [%d]: %w", action.ID, err)
+		}
+		if action.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot of action [%d]: %w", action.ID, err)
+		}
+		action.ChangedFields = storage.ChangedFields(action.Before, action.After)
+
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+func (s *Storage) GetTaskHistory(ctx context.Context, taskID, userID, limit, offset int) ([]storage.TaskAction, error) {
+	const op = "storage.postgres.GetTaskHistory"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskActionColumns+` FROM task_actions
+		WHERE user_id = $1 AND task_id = $2
+		ORDER BY id DESC LIMIT $3 OFFSET $4`, userID, taskID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get history of task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	actions, err := scanTaskActions(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read history of task [%d]: %w'`, op, taskID, err)
+	}
+
+	return actions, nil
+}
+
+func (s *Storage) UndoTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.revertTask(ctx, "storage.postgres.UndoTask", taskID, userID, false)
+}
+
+func (s *Storage) RedoTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.revertTask(ctx, "storage.postgres.RedoTask", taskID, userID, true)
+}
+
+// revertTask undoes or, when redo is set, redoes the user's latest change of
+// a task by writing back the matching snapshot and logs it as a new action.
+// The restored status has to be part of the current workflow of the project
+// and the task is placed again rather than given its old priority back.
+func (s *Storage) revertTask(ctx context.Context, op string, taskID, userID int, redo bool) (*storage.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	// the project the task goes back to is locked along with its current
+	// one, so it is looked up before the locks and checked again after them
+	var plannedProjectID int
+	if _, planned, err := planRevert(ctx, tx, taskID, userID, redo); err == nil {
+		plannedProjectID = planned.ProjectID
+	}
+
+	before, err := lockPlacedTask(ctx, tx, taskID, userID, plannedProjectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	target, state, err := planRevert(ctx, tx, taskID, userID, redo)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, err)
+	}
+	if state.ProjectID != plannedProjectID && state.ProjectID != before.ProjectID {
+		return nil, fmt.Errorf(`'%s: task [%d] changed meanwhile: %w'`, op, taskID, storage.ErrConflict)
+	}
+
+	parentID, err := restorableParent(ctx, tx, userID, taskID, state.ParentID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to check parent of task [%d]: %w'`, op, taskID, err)
+	}
+
+	// the task stays where it is when the project it was in is gone
+	projectID := before.ProjectID
+	if state.ProjectID != 0 && state.ProjectID != before.ProjectID {
+		restored, err := resolveProject(ctx, tx, userID, state.ProjectID)
+		switch {
+		case err == nil:
+			projectID = restored
+		case !errors.Is(err, storage.ErrNotFound):
+			return nil, fmt.Errorf(`'%s: failed to get project [%d] for user [%d]: %w'`, op, state.ProjectID, userID, err)
+		}
+	}
+
+	if state.Status != before.Status {
+		workflow, err := projectWorkflow(ctx, tx, projectID)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get workflow of project [%d]: %w'`, op, projectID, err)
+		}
+		if !workflow.Has(state.Status) {
+			return nil, fmt.Errorf(`'%s: task [%d] from status [%d] to [%d]: %w'`, op, taskID, before.Status, state.Status, storage.ErrInvalidTransition)
+		}
+	}
+
+	priority, err := restorePriority(ctx, tx, before, state, projectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task [%d] in project [%d]: %w'`, op, taskID, projectID, err)
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, archived_ts = $5,
+			start_ts = $6, due_ts = $7, parent_id = $8, recurrence = $9, status_ts = $10, project_id = $11
+		WHERE user_id = $12 AND id = $13
+		RETURNING `+taskColumns,
+		state.Title, state.Description, state.Status, priority, state.ArchivedTs,
+		state.StartTs, state.DueTs, parentID, state.Recurrence, state.StatusTs, projectID, userID, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := setTaskTags(ctx, tx, taskID, userID, state.TagIDs); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to restore tags of task [%d]: %w'`, op, taskID, err)
+	}
+	if err := loadTaskTags(ctx, tx, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags of task [%d]: %w'`, op, taskID, err)
+	}
+
+	actionType := storage.UndoTaskType
+	if redo {
+		actionType = storage.RedoTaskType
+	}
+	if err := insertRevertAction(ctx, tx, actionType, userID, taskID, &target.ID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+	if storage.IsDoneStatus(task.Status) && !storage.IsDoneStatus(before.Status) {
+		metrics.TasksClosed.Inc()
+	}
+
+	return task, nil
+}
+
+// planRevert reads the user's log of a task and picks the action to revert,
+// see storage.PlanRevert.
+func planRevert(ctx context.Context, tx *sql.Tx, taskID, userID int, redo bool) (*storage.TaskAction, *storage.Task, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT `+taskActionColumns+` FROM task_actions
+		WHERE user_id = $1 AND task_id = $2 ORDER BY id`, userID, taskID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	actions, err := scanTaskActions(rows)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return storage.PlanRevert(actions, redo)
+}
+
+// restorePriority returns the priority of a locked task restored to state in
+// projectID. A done task sinks to the bottom. An open task keeps its place
+// when it stays open in the same project at the same priority, otherwise it
+// goes back where its snapshot priority ranks among the project's current
+// open tasks, so it never collides with tasks moved since.
+func restorePriority(ctx context.Context, tx *sql.Tx, before, state *storage.Task, projectID int) (int, error) {
+	switch {
+	case storage.IsDoneStatus(state.Status):
+		return storage.TaskPriorityClosed, nil
+	case !storage.IsDoneStatus(before.Status) && projectID == before.ProjectID && state.Priority == before.Priority:
+		return before.Priority, nil
+	}
+
+	position := storage.TaskPosition{Place: storage.PlaceBottom}
+	row := tx.QueryRowContext(ctx, `SELECT id FROM tasks
+		WHERE project_id = $1 AND id <> $2 AND archived_ts IS NULL AND status NOT IN ($3, $4) AND priority < $5
+		ORDER BY priority DESC, id LIMIT 1 FOR UPDATE`, projectID, before.ID, storage.TaskStatusClosed, storage.TaskStatusCancelled, state.Priority)
+	switch err := row.Scan(&position.AnchorID); {
+	case err == nil:
+		position.Place = storage.PlaceBefore
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, err
+	}
+
+	return placeInProject(ctx, tx, projectID, before.ID, position)
+}
@@ -1,39 +1,53 @@
 package postgres
 
 import (
+	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"os"
-	"sort"
-	"strings"
+	"todo_list_service/internal/storage/migrate"
 )
 
-func (s *Storage) applyMigrations(dir string) error {
-	const op = "storage.postgres.ApplyMigrations"
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationsSource returns the embedded migrations unless the config points
+// to an out-of-tree directory.
+func migrationsSource(dir string) (fs.FS, error) {
+	if dir != "" {
+		return os.DirFS(dir), nil
+	}
+	return fs.Sub(migrationsFS, "migrations")
+}
+
+// Migrator returns the migration runner bound to this storage.
+func (s *Storage) Migrator() (*migrate.Migrator, error) {
+	const op = "storage.postgres.Migrator"
 
-	entries, err := os.ReadDir(dir)
+	source, err := migrationsSource(s.cfg.MigrationsDir)
 	if err != nil {
-		return fmt.Errorf(`'%s: failed to read migrations directory %s: %w'`, op, dir, err)
+		return nil, fmt.Errorf(`'%s: %w'`, op, err)
 	}
 
-	var files []string
-	for _, entry := range entries {
-		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".sql") {
-			files = append(files, fmt.Sprintf("%s/%s", dir, entry.Name()))
-		}
+	migrator, err := migrate.New(s.db, migrate.Postgres, source)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to load migrations: %w'`, op, err)
 	}
 
-	sort.Strings(files)
+	return migrator, nil
+}
+
+func (s *Storage) applyMigrations() error {
+	const op = "storage.postgres.ApplyMigrations"
 
-	for _, file := range files {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf(`'%s: failed to read migration file %s: %w'`, op, file, err)
-		}
+	migrator, err := s.Migrator()
+	if err != nil {
+		return err
+	}
 
-		_, err = s.db.Exec(string(content))
-		if err != nil {
-			return fmt.Errorf(`'%s: failed to execute migration %s: %w'`, op, file, err)
-		}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return fmt.Errorf(`'%s: %w'`, op, err)
 	}
 
 	return nil
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS task_actions_user_task_idx;
+
+ALTER TABLE task_actions DROP COLUMN IF EXISTS after_snapshot;
+ALTER TABLE task_actions DROP COLUMN IF EXISTS before_snapshot;
@@ -0,0 +1,7 @@
+ALTER TABLE task_actions ADD COLUMN IF NOT EXISTS before_snapshot JSONB;
+ALTER TABLE task_actions ADD COLUMN IF NOT EXISTS after_snapshot JSONB;
+
+-- 'now' was folded into a constant when the table was created, history needs real timestamps.
+ALTER TABLE task_actions ALTER COLUMN ts SET DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS task_actions_user_task_idx ON task_actions (user_id, task_id, id);
@@ -0,0 +1 @@
+ALTER TABLE task_actions DROP COLUMN IF EXISTS target_action_id;
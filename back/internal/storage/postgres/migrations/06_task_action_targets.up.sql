@@ -0,0 +1 @@
+ALTER TABLE task_actions ADD COLUMN IF NOT EXISTS target_action_id INTEGER;
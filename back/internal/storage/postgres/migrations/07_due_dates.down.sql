@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN IF EXISTS time_zone;
+
+DROP INDEX IF EXISTS tasks_due_ts_idx;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_ts;
+ALTER TABLE tasks DROP COLUMN IF EXISTS start_ts;
@@ -0,0 +1,6 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS start_ts TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_ts TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS tasks_due_ts_idx ON tasks (user_id, due_ts) WHERE due_ts IS NOT NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    creation_ts TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS projects_inbox_idx ON projects (user_id) WHERE is_inbox;
+
+-- every existing user, and every owner of existing tasks, gets an inbox
+INSERT INTO projects (user_id, name, is_inbox)
+SELECT id, 'Inbox', TRUE FROM users
+UNION
+SELECT DISTINCT user_id, 'Inbox', TRUE FROM tasks WHERE user_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects (id);
+
+UPDATE tasks SET project_id = projects.id
+FROM projects
+WHERE projects.user_id = tasks.user_id AND projects.is_inbox AND tasks.project_id IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN project_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS tasks_project_priority_idx ON tasks (project_id, priority DESC, id);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS status_ts;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS workflow;
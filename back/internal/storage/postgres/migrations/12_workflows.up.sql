@@ -0,0 +1,10 @@
+-- an empty workflow stands for the default one
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workflow TEXT NOT NULL DEFAULT '';
+
+-- when each task last entered each status, keyed by status
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status_ts JSONB NOT NULL DEFAULT '{}';
+
+-- creation_ts has no time zone until 13_task_listing, it is written out as
+-- RFC 3339 in UTC like storage.StatusTimes expects
+UPDATE tasks SET status_ts = jsonb_build_object(status::TEXT, to_char(creation_ts, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'))
+WHERE status IS NOT NULL AND creation_ts IS NOT NULL;
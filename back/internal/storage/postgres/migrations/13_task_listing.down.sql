@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tasks_creation_ts_idx;
+DROP INDEX IF EXISTS tasks_priority_idx;
+
+ALTER TABLE tasks ALTER COLUMN creation_ts TYPE TIMESTAMP USING creation_ts AT TIME ZONE 'UTC';
@@ -0,0 +1,8 @@
+-- the 'now' literal was evaluated once when the table was created, every
+-- task got the same creation time
+ALTER TABLE tasks ALTER COLUMN creation_ts TYPE TIMESTAMPTZ USING creation_ts AT TIME ZONE 'UTC';
+ALTER TABLE tasks ALTER COLUMN creation_ts SET DEFAULT now();
+
+-- keyset pagination of task listings
+CREATE INDEX IF NOT EXISTS tasks_priority_idx ON tasks (user_id, priority DESC, id);
+CREATE INDEX IF NOT EXISTS tasks_creation_ts_idx ON tasks (user_id, creation_ts DESC, id DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_search_vector_idx;
+
+DROP TRIGGER IF EXISTS tasks_search_vector_trigger ON tasks;
+DROP FUNCTION IF EXISTS tasks_search_vector();
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
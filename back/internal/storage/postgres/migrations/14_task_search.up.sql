@@ -0,0 +1,23 @@
+-- titles weigh more than descriptions, both are stemmed as russian and as
+-- english since users write in both languages
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector TSVECTOR NOT NULL DEFAULT ''::TSVECTOR;
+
+CREATE OR REPLACE FUNCTION tasks_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.description, '')), 'B') ||
+        setweight(to_tsvector('russian', coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_search_vector_trigger ON tasks;
+CREATE TRIGGER tasks_search_vector_trigger BEFORE INSERT OR UPDATE OF title, description ON tasks
+    FOR EACH ROW EXECUTE FUNCTION tasks_search_vector();
+
+-- fires the trigger for the existing tasks
+UPDATE tasks SET title = title;
+
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
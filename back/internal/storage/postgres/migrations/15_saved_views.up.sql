@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS saved_views (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    creation_ts TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, name)
+);
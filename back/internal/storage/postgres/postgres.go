@@ -1,11 +1,23 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+
+	"github.com/XSAM/otelsql"
+	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+var _ storage.Storage = (*Storage)(nil)
+
+// spanOptions keeps the traced queries to the statements themselves, spans
+// for every row read and connection reset would only be noise.
+var spanOptions = otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}
+
 type Storage struct {
 	cfg *config.PgConfig
 	db  *sql.DB
@@ -15,25 +27,50 @@ func generateUrlFromConfig(cfg *config.PgConfig) string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DBName)
 }
 
+// DB returns the connection pool, for metrics to read its stats.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
+// Ping checks that the database answers.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.postgres.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf(`'%s: %w'`, op, err)
+	}
+	return nil
+}
+
 func (s *Storage) Close() error {
 	err := s.db.Close()
 	return err
 }
 
-func New(cfg *config.PgConfig) (*Storage, error) {
-	const op = "storage.postgres.New"
+// Open connects to the database without touching the schema.
+func Open(cfg *config.PgConfig) (*Storage, error) {
+	const op = "storage.postgres.Open"
 
-	db, err := sql.Open("postgres", generateUrlFromConfig(cfg))
+	db, err := otelsql.Open("postgres", generateUrlFromConfig(cfg), otelsql.WithAttributes(semconv.DBSystemPostgreSQL), otelsql.WithSpanOptions(spanOptions))
 	if err != nil {
 		return nil, fmt.Errorf(`'%s: %w'`, op, err)
 	}
 
-	storage := &Storage{
+	return &Storage{
 		db:  db,
 		cfg: cfg,
+	}, nil
+}
+
+// New connects to the database and applies pending migrations.
+func New(cfg *config.PgConfig) (*Storage, error) {
+	storage, err := Open(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := storage.applyMigrations(cfg.MigrationsDir); err != nil {
+	if err := storage.applyMigrations(); err != nil {
+		_ = storage.Close()
 		return nil, err
 	}
 
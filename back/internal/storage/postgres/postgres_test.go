@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/migrate"
+	"todo_list_service/internal/storage/storagetest"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+func readConfig(t *testing.T) *config.PgConfig {
+	t.Helper()
+
+	if os.Getenv("PG_HOST") == "" {
+		t.Skip("PG_HOST is not set")
+	}
+
+	var cfg config.PgConfig
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		t.Fatalf("cannot read pg config: %v", err)
+	}
+	return &cfg
+}
+
+// TestStorage runs the conformance suite against a live database. It is
+// skipped unless PG_HOST points to a server the tests may write to.
+func TestStorage(t *testing.T) {
+	cfg := readConfig(t)
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := New(cfg)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// migrationsUpTo returns the embedded migrations up to version, with the
+// same contents and so the same checksums.
+func migrationsUpTo(t *testing.T, version int) fs.FS {
+	t.Helper()
+
+	source, err := migrationsSource("")
+	if err != nil {
+		t.Fatalf("migrationsSource: %v", err)
+	}
+	migrations, err := migrate.Load(source)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	older := fstest.MapFS{}
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			older[fmt.Sprintf("%02d_%s.up.sql", migration.Version, migration.Name)] = &fstest.MapFile{Data: []byte(migration.Up)}
+		}
+	}
+	return older
+}
+
+// TestUpgradeStatusTimes reads back a task written before status times
+// were tracked. The schema is built from scratch in a throwaway search
+// path, so the pool is kept to the one connection that has it set.
+func TestUpgradeStatusTimes(t *testing.T) {
+	ctx := context.Background()
+	cfg := readConfig(t)
+
+	s, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	s.db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("upgrade_%d", time.Now().UnixNano())
+	if _, err := s.db.ExecContext(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { _, _ = s.db.ExecContext(context.Background(), "DROP SCHEMA "+schema+" CASCADE") })
+	if _, err := s.db.ExecContext(ctx, "SET search_path TO "+schema); err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+
+	migrator, err := migrate.New(s.db, migrate.Postgres, migrationsUpTo(t, 11))
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up to 11: %v", err)
+	}
+
+	var userID, taskID int
+	if err := s.db.QueryRowContext(ctx, `INSERT INTO users (username, password) VALUES ('old', 'hashed') RETURNING id`).Scan(&userID); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO projects (user_id, name, is_inbox) VALUES ($1, 'Inbox', TRUE)`, userID); err != nil {
+		t.Fatalf("insert inbox: %v", err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO tasks (title, description, status, priority, user_id, project_id, creation_ts)
+		SELECT 'old', '', $1, 1, $2, id, '2024-05-01 10:00:00.123456' FROM projects WHERE user_id = $2
+		RETURNING id`, storage.TaskStatusOpened, userID).Scan(&taskID)
+	if err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+
+	if err := s.applyMigrations(); err != nil {
+		t.Fatalf("applyMigrations: %v", err)
+	}
+
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC)
+	if entered := task.StatusTs[storage.TaskStatusOpened]; !entered.Equal(want) {
+		t.Errorf("entered opened at %v, want %v", entered, want)
+	}
+
+	if _, err := s.ListTasks(ctx, userID, storage.TaskFilter{}, 10); err != nil {
+		t.Errorf("ListTasks: %v", err)
+	}
+}
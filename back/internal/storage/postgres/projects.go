@@ -0,0 +1,317 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"todo_list_service/internal/storage"
+)
+
+const projectColumns = `id, user_id, name, is_inbox, workflow, creation_ts`
+
+func scanProject(row interface{ Scan(dest ...any) error }, project *storage.Project) error {
+	return storageError(row.Scan(&project.ID, &project.UserID, &project.Name, &project.IsInbox, &project.Workflow, &project.CreationTs))
+}
+
+func insertInbox(ctx context.Context, tx *sql.Tx, userID int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO projects (user_id, name, is_inbox) VALUES ($1, $2, TRUE)`, userID, storage.InboxProjectName)
+	return err
+}
+
+// resolveProject checks that the project belongs to the user, a zero id
+// stands for the user's inbox.
+func resolveProject(ctx context.Context, tx *sql.Tx, userID, projectID int) (int, error) {
+	row := tx.QueryRowContext(ctx, `SELECT id FROM projects WHERE user_id = $1 AND (id = $2 OR ($2 = 0 AND is_inbox))`, userID, projectID)
+	if err := row.Scan(&projectID); err != nil {
+		return 0, storageError(err)
+	}
+	return projectID, nil
+}
+
+// lockProjects locks project rows in id order. Every write placing a task
+// takes the lock of its projects before any task row, so concurrent
+// reorders queue up instead of deadlocking on each other's task rows and
+// inserts into a project without open tasks cannot pick the same priority.
+func lockProjects(ctx context.Context, tx *sql.Tx, projectIDs ...int) error {
+	ids := slices.Compact(slices.Sorted(slices.Values(projectIDs)))
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM projects WHERE id = $1 FOR UPDATE`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockPlacedTask locks the project of the user's task together with
+// projectIDs and then the task itself, see lockProjects. A task moved away
+// before its row got locked has its new project locked in turn.
+func lockPlacedTask(ctx context.Context, tx *sql.Tx, taskID, userID int, projectIDs ...int) (*storage.Task, error) {
+	for {
+		var projectID int
+		row := tx.QueryRowContext(ctx, `SELECT project_id FROM tasks WHERE user_id = $1 AND id = $2`, userID, taskID)
+		if err := row.Scan(&projectID); err != nil {
+			return nil, storageError(err)
+		}
+
+		if err := lockProjects(ctx, tx, append([]int{projectID}, projectIDs...)...); err != nil {
+			return nil, err
+		}
+
+		task, err := lockTask(ctx, tx, taskID, userID)
+		if err != nil || task.ProjectID == projectID {
+			return task, err
+		}
+	}
+}
+
+// placeInProject returns the priority that puts a task at position among the
+// other open tasks of a project, the caller holds the project lock. The
+// other open tasks are locked and renumbered when their priorities leave no
+// room.
+func placeInProject(ctx context.Context, tx *sql.Tx, projectID, taskID int, position storage.TaskPosition) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, priority FROM tasks
+		WHERE project_id = $1 AND id <> $2 AND archived_ts IS NULL AND status NOT IN ($3, $4)
+		ORDER BY priority DESC, id FOR UPDATE`, projectID, taskID, storage.TaskStatusClosed, storage.TaskStatusCancelled)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids, order []int
+	for rows.Next() {
+		var id, priority int
+		if err := rows.Scan(&id, &priority); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+		order = append(order, priority)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	index, ok := position.Index(ids)
+	if !ok {
+		return 0, storage.ErrInvalidAnchor
+	}
+
+	priority, spread := storage.PlaceAt(order, index)
+	if spread == nil {
+		return priority, nil
+	}
+
+	for i, id := range ids {
+		position := i
+		if i >= index {
+			position++
+		}
+		if spread[position] == order[i] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE tasks SET priority = $1 WHERE id = $2`, spread[position], id); err != nil {
+			return 0, fmt.Errorf("failed to renumber task [%d]: %w", id, err)
+		}
+	}
+
+	return priority, nil
+}
+
+// projectWorkflow returns the workflow the tasks of a project follow.
+func projectWorkflow(ctx context.Context, tx *sql.Tx, projectID int) (storage.Workflow, error) {
+	var workflow storage.Workflow
+	row := tx.QueryRowContext(ctx, `SELECT workflow FROM projects WHERE id = $1`, projectID)
+	if err := row.Scan(&workflow); err != nil {
+		return storage.Workflow{}, err
+	}
+	return workflow, nil
+}
+
+// statusPriority returns the priority of a locked task once it is in
+// status. Done tasks sink to the bottom, a task leaving its done status is
+// put back on top of its project and any other task keeps its place.
+func statusPriority(ctx context.Context, tx *sql.Tx, before *storage.Task, status int8) (int, error) {
+	switch {
+	case storage.IsDoneStatus(status):
+		return storage.TaskPriorityClosed, nil
+	case storage.IsDoneStatus(before.Status):
+		return placeInProject(ctx, tx, before.ProjectID, before.ID, storage.TaskPosition{Place: storage.PlaceTop})
+	}
+	return before.Priority, nil
+}
+
+// moveTask puts a locked task on top of another project and logs the move.
+// Done tasks keep their priority at the bottom.
+func moveTask(ctx context.Context, tx *sql.Tx, before *storage.Task, projectID int) (*storage.Task, error) {
+	priority := before.Priority
+	if !storage.IsDoneStatus(before.Status) {
+		var err error
+		if priority, err = placeInProject(ctx, tx, projectID, before.ID, storage.TaskPosition{Place: storage.PlaceTop}); err != nil {
+			return nil, err
+		}
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET project_id = $1, priority = $2 WHERE id = $3 RETURNING `+taskColumns,
+		projectID, priority, before.ID)
+	if err := scanTask(row, task); err != nil {
+		return nil, err
+	}
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, storage.MoveTaskType, task.UserID, task.ID, before, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+func (s *Storage) CreateProject(ctx context.Context, newProject *storage.Project) (*storage.Project, error) {
+	const op = "storage.postgres.CreateProject"
+
+	project := &storage.Project{}
+
+	row := s.db.QueryRowContext(ctx, `INSERT INTO projects (user_id, name, workflow) VALUES ($1, $2, $3) RETURNING `+projectColumns,
+		newProject.UserID, newProject.Name, newProject.Workflow)
+	if err := scanProject(row, project); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to create project [%s] for user [%d]: %w'`, op, newProject.Name, newProject.UserID, err)
+	}
+
+	return project, nil
+}
+
+func (s *Storage) UpdateProject(ctx context.Context, updatedProject *storage.Project) (*storage.Project, error) {
+	const op = "storage.postgres.UpdateProject"
+
+	project := &storage.Project{}
+
+	row := s.db.QueryRowContext(ctx, `UPDATE projects SET name = $1, workflow = COALESCE(NULLIF($2, ''), workflow)
+		WHERE user_id = $3 AND id = $4 RETURNING `+projectColumns,
+		updatedProject.Name, updatedProject.Workflow, updatedProject.UserID, updatedProject.ID)
+	if err := scanProject(row, project); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to update project [%d] for user [%d]: %w'`, op, updatedProject.ID, updatedProject.UserID, err)
+	}
+
+	return project, nil
+}
+
+func (s *Storage) DeleteProject(ctx context.Context, projectID, userID int) error {
+	const op = "storage.postgres.DeleteProject"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	var isInbox bool
+	row := tx.QueryRowContext(ctx, `SELECT is_inbox FROM projects WHERE user_id = $1 AND id = $2`, userID, projectID)
+	if err := row.Scan(&isInbox); err != nil {
+		return fmt.Errorf(`'%s: failed to get project [%d] for user [%d]: %w'`, op, projectID, userID, storageError(err))
+	}
+	if isInbox {
+		return fmt.Errorf(`'%s: project [%d] is the inbox and cannot be deleted: %w'`, op, projectID, storage.ErrForbidden)
+	}
+
+	inboxID, err := resolveProject(ctx, tx, userID, 0)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to get inbox of user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := lockProjects(ctx, tx, projectID, inboxID); err != nil {
+		return fmt.Errorf(`'%s: failed to lock projects [%d] and [%d]: %w'`, op, projectID, inboxID, err)
+	}
+
+	// lowest first, so the moved tasks keep their order on top of the inbox
+	rows, err := tx.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE project_id = $1 ORDER BY priority, id DESC FOR UPDATE`, projectID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to get tasks of project [%d]: %w'`, op, projectID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to read tasks of project [%d]: %w'`, op, projectID, err)
+	}
+	if err := loadTasksTags(ctx, tx, tasks); err != nil {
+		return fmt.Errorf(`'%s: failed to read tags of project [%d]: %w'`, op, projectID, err)
+	}
+
+	for i := range tasks {
+		if _, err := moveTask(ctx, tx, &tasks[i], inboxID); err != nil {
+			return fmt.Errorf(`'%s: failed to move task [%d] to the inbox: %w'`, op, tasks[i].ID, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, projectID); err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetProjects(ctx context.Context, userID int) ([]storage.Project, error) {
+	const op = "storage.postgres.GetProjects"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE user_id = $1 ORDER BY is_inbox DESC, name, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get projects for user [%d]: %w'`, op, userID, err)
+	}
+	defer rows.Close()
+
+	projects := []storage.Project{}
+	for rows.Next() {
+		var project storage.Project
+		if err := scanProject(rows, &project); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read project: %w'`, op, err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get projects for user [%d]: %w'`, op, userID, err)
+	}
+
+	return projects, nil
+}
+
+func (s *Storage) MoveTask(ctx context.Context, taskID, projectID, userID int) (*storage.Task, error) {
+	const op = "storage.postgres.MoveTask"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	targetID, err := resolveProject(ctx, tx, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get project [%d] for user [%d]: %w'`, op, projectID, userID, err)
+	}
+
+	before, err := lockPlacedTask(ctx, tx, taskID, userID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, taskID, storage.ErrConflict)
+	}
+	if before.ProjectID == targetID {
+		return before, nil
+	}
+
+	task, err := moveTask(ctx, tx, before, targetID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
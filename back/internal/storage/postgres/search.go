@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"todo_list_service/internal/storage"
+)
+
+// ts_headline options, titles are short enough to be shown whole. Matches
+// are marked for storage.HighlightSnippet, which escapes the rest.
+const (
+	titleHeadlineOptions       = "HighlightAll=true, " + matchSelOptions
+	descriptionHeadlineOptions = "MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=\" … \", " + matchSelOptions
+
+	matchSelOptions = `StartSel="` + storage.MatchStart + `", StopSel="` + storage.MatchStop + `"`
+)
+
+// scanWith scans the task columns of a row followed by extra columns.
+type scanWith struct {
+	row   interface{ Scan(dest ...any) error }
+	extra []any
+}
+
+func (s scanWith) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+func (s *Storage) SearchTasks(ctx context.Context, userID int, query string, limit int) ([]storage.TaskMatch, error) {
+	const op = "storage.postgres.SearchTasks"
+
+	// the query is stemmed both ways, the russian configuration stems latin
+	// words as english too, so it highlights matches of either language
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+`, ts_rank_cd(search_vector, search.query) AS search_rank,
+			ts_headline('russian', title, search.query, $3), ts_headline('russian', description, search.query, $4)
+		FROM tasks, (SELECT plainto_tsquery('english', $2) || plainto_tsquery('russian', $2) AS query) AS search
+		WHERE user_id = $1 AND archived_ts IS NULL AND search_vector @@ search.query
+		ORDER BY search_rank DESC, priority DESC, id LIMIT $5`, userID, query, titleHeadlineOptions, descriptionHeadlineOptions, limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to search tasks for user [%d]: %w'`, op, userID, err)
+	}
+	defer rows.Close()
+
+	matches := []storage.TaskMatch{}
+	for rows.Next() {
+		var match storage.TaskMatch
+		if err := scanTask(scanWith{rows, []any{&match.Rank, &match.TitleSnippet, &match.DescriptionSnippet}}, &match.Task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+		}
+		match.TitleSnippet = storage.HighlightSnippet(match.TitleSnippet)
+		match.DescriptionSnippet = storage.HighlightSnippet(match.DescriptionSnippet)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks := make([]*storage.Task, len(matches))
+	for i := range matches {
+		tasks[i] = &matches[i].Task
+	}
+	if err := loadTaskTags(ctx, s.db, tasks...); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// resolveParent checks that the parent task belongs to the user and is not
+// archived.
+func resolveParent(ctx context.Context, tx *sql.Tx, userID, parentID int) error {
+	row := tx.QueryRowContext(ctx, `SELECT id FROM tasks WHERE user_id = $1 AND id = $2 AND archived_ts IS NULL`, userID, parentID)
+	return storageError(row.Scan(&parentID))
+}
+
+// createsCycle reports whether taskID is parentID itself or one of its
+// ancestors, so nesting the task under parentID would close a loop.
+func createsCycle(ctx context.Context, tx *sql.Tx, taskID, parentID int) (bool, error) {
+	var cycle bool
+	row := tx.QueryRowContext(ctx, `WITH RECURSIVE ancestors (id, parent_id) AS (
+			SELECT id, parent_id FROM tasks WHERE id = $1
+			UNION
+			SELECT tasks.id, tasks.parent_id FROM tasks JOIN ancestors ON tasks.id = ancestors.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`, parentID, taskID)
+	if err := row.Scan(&cycle); err != nil {
+		return false, err
+	}
+	return cycle, nil
+}
+
+// restorableParent returns the parent a reverted task may get back: nil
+// once the parent is gone or nesting under it would close a loop.
+func restorableParent(ctx context.Context, tx *sql.Tx, userID, taskID int, parentID *int) (*int, error) {
+	if parentID == nil {
+		return nil, nil
+	}
+
+	var exists bool
+	row := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE user_id = $1 AND id = $2)`, userID, *parentID)
+	if err := row.Scan(&exists); err != nil || !exists {
+		return nil, err
+	}
+
+	cycle, err := createsCycle(ctx, tx, taskID, *parentID)
+	if err != nil || cycle {
+		return nil, err
+	}
+	return parentID, nil
+}
+
+// closeSubtasks applies the close policy to the open active subtasks, at any
+// depth, of a task being closed. Cascaded closes are logged as updates of
+// the subtasks and spawn the next occurrences of recurring ones. It returns
+// how many subtasks it closed.
+func closeSubtasks(ctx context.Context, tx *sql.Tx, taskID int, policy storage.ClosePolicy) (int, error) {
+	if policy == storage.ClosePolicyIgnore {
+		return 0, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `WITH RECURSIVE descendants (id) AS (
+			SELECT id FROM tasks WHERE parent_id = $1
+			UNION
+			SELECT tasks.id FROM tasks JOIN descendants ON tasks.parent_id = descendants.id
+		)
+		SELECT `+taskColumns+` FROM tasks
+		WHERE id IN (SELECT id FROM descendants) AND archived_ts IS NULL AND status NOT IN ($2, $3)
+		ORDER BY id FOR UPDATE`, taskID, storage.TaskStatusClosed, storage.TaskStatusCancelled)
+	if err != nil {
+		return 0, err
+	}
+
+	subtasks, err := scanTasks(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(subtasks) == 0 {
+		return 0, nil
+	}
+	if policy == storage.ClosePolicyBlock {
+		return 0, storage.ErrOpenSubtasks
+	}
+
+	if err := loadTasksTags(ctx, tx, subtasks); err != nil {
+		return 0, err
+	}
+
+	for i := range subtasks {
+		before := &subtasks[i]
+		task := &storage.Task{}
+
+		row := tx.QueryRowContext(ctx, `UPDATE tasks SET status = $1, status_ts = $2, priority = $3 WHERE id = $4 RETURNING `+taskColumns,
+			storage.TaskStatusClosed, before.StatusTs.With(storage.TaskStatusClosed, time.Now()), storage.TaskPriorityClosed, before.ID)
+		if err := scanTask(row, task); err != nil {
+			return 0, err
+		}
+		task.TagIDs = before.TagIDs
+
+		if err := insertTaskAction(ctx, tx, storage.UpdateTaskType, task.UserID, task.ID, before, task); err != nil {
+			return 0, err
+		}
+		if err := spawnOccurrence(ctx, tx, task); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(subtasks), nil
+}
+
+func (s *Storage) SetTaskParent(ctx context.Context, taskID, parentID, userID int) (*storage.Task, error) {
+	const op = "storage.postgres.SetTaskParent"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if before.ParentTaskID() == parentID {
+		return before, nil
+	}
+
+	var parent *int
+	if parentID != 0 {
+		if err := resolveParent(ctx, tx, userID, parentID); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get parent task [%d] for user [%d]: %w'`, op, parentID, userID, err)
+		}
+
+		cycle, err := createsCycle(ctx, tx, taskID, parentID)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to check ancestors of task [%d]: %w'`, op, parentID, err)
+		}
+		if cycle {
+			return nil, fmt.Errorf(`'%s: task [%d] under [%d]: %w'`, op, taskID, parentID, storage.ErrTaskCycle)
+		}
+		parent = &parentID
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET parent_id = $1 WHERE id = $2 AND archived_ts IS NULL RETURNING `+taskColumns, parent, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, storage.SetTaskParentType, userID, taskID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) GetSubtasks(ctx context.Context, taskID, userID int) ([]storage.Task, error) {
+	const op = "storage.postgres.GetSubtasks"
+
+	var exists bool
+	row := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE user_id = $1 AND id = $2)`, userID, taskID)
+	if err := row.Scan(&exists); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, storage.ErrNotFound)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE user_id = $1 AND parent_id = $2 AND archived_ts IS NULL
+		ORDER BY priority DESC, id`, userID, taskID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get subtasks of task [%d]: %w'`, op, taskID, err)
+	}
+
+	subtasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read subtasks of task [%d]: %w'`, op, taskID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, subtasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags of subtasks of task [%d]: %w'`, op, taskID, err)
+	}
+
+	return subtasks, nil
+}
@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"todo_list_service/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+const tagColumns = `id, user_id, name, color`
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func scanTag(row interface{ Scan(dest ...any) error }, tag *storage.Tag) error {
+	return storageError(row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color))
+}
+
+// intArray binds a list of ids as a postgres integer array.
+func intArray(ids []int) any {
+	values := make([]int64, len(ids))
+	for i, id := range ids {
+		values[i] = int64(id)
+	}
+	return pq.Array(values)
+}
+
+// loadTaskTags fills TagIDs of the given tasks with a single query.
+func loadTaskTags(ctx context.Context, q queryer, tasks ...*storage.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(tasks))
+	byID := make(map[int]*storage.Task, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		byID[task.ID] = task
+		task.TagIDs = nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT task_id, tag_id FROM task_tags WHERE task_id = ANY($1) ORDER BY tag_id`, intArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID, tagID int
+		if err := rows.Scan(&taskID, &tagID); err != nil {
+			return err
+		}
+		byID[taskID].TagIDs = append(byID[taskID].TagIDs, tagID)
+	}
+
+	return rows.Err()
+}
+
+func loadTasksTags(ctx context.Context, q queryer, tasks []storage.Task) error {
+	ptrs := make([]*storage.Task, len(tasks))
+	for i := range tasks {
+		ptrs[i] = &tasks[i]
+	}
+	return loadTaskTags(ctx, q, ptrs...)
+}
+
+// setTaskTags replaces the tags of a task, ids of tags the user no longer
+// has are skipped.
+func setTaskTags(ctx context.Context, tx *sql.Tx, taskID, userID int, tagIDs []int) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = $1`, taskID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO task_tags (task_id, tag_id)
+		SELECT $1, id FROM tags WHERE user_id = $2 AND id = ANY($3)`, taskID, userID, intArray(tagIDs))
+	return err
+}
+
+// ensureTags returns the ids of the user's tags named names, in the same
+// order, creating the ones the user does not have yet.
+func ensureTags(ctx context.Context, tx *sql.Tx, userID int, names []string) ([]int, error) {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tags (user_id, name) VALUES ($1, $2) ON CONFLICT (user_id, name) DO NOTHING`, userID, name); err != nil {
+			return nil, fmt.Errorf("failed to create tag [%s]: %w", name, err)
+		}
+
+		var id int
+		row := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE user_id = $1 AND name = $2`, userID, name)
+		if err := row.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to get tag [%s]: %w", name, storageError(err))
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *Storage) CreateTag(ctx context.Context, newTag *storage.Tag) (*storage.Tag, error) {
+	const op = "storage.postgres.CreateTag"
+
+	tag := &storage.Tag{}
+
+	row := s.db.QueryRowContext(ctx, `INSERT INTO tags (user_id, name, color) VALUES ($1, $2, $3) RETURNING `+tagColumns,
+		newTag.UserID, newTag.Name, newTag.Color)
+	if err := scanTag(row, tag); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to create tag [%s] for user [%d]: %w'`, op, newTag.Name, newTag.UserID, err)
+	}
+
+	return tag, nil
+}
+
+func (s *Storage) UpdateTag(ctx context.Context, updatedTag *storage.Tag) (*storage.Tag, error) {
+	const op = "storage.postgres.UpdateTag"
+
+	tag := &storage.Tag{}
+
+	row := s.db.QueryRowContext(ctx, `UPDATE tags SET name = $1, color = $2 WHERE user_id = $3 AND id = $4 RETURNING `+tagColumns,
+		updatedTag.Name, updatedTag.Color, updatedTag.UserID, updatedTag.ID)
+	if err := scanTag(row, tag); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to update tag [%d] for user [%d]: %w'`, op, updatedTag.ID, updatedTag.UserID, err)
+	}
+
+	return tag, nil
+}
+
+func (s *Storage) DeleteTag(ctx context.Context, tagID, userID int) error {
+	const op = "storage.postgres.DeleteTag"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM tags WHERE user_id = $1 AND id = $2`, userID, tagID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf(`'%s: tag [%d] for user [%d]: %w'`, op, tagID, userID, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetTags(ctx context.Context, userID int) ([]storage.Tag, error) {
+	const op = "storage.postgres.GetTags"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE user_id = $1 ORDER BY name, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tags for user [%d]: %w'`, op, userID, err)
+	}
+	defer rows.Close()
+
+	tags := []storage.Tag{}
+	for rows.Next() {
+		var tag storage.Tag
+		if err := scanTag(rows, &tag); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read tag: %w'`, op, err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tags, nil
+}
+
+func (s *Storage) AttachTag(ctx context.Context, taskID, tagID, userID int) (*storage.Task, error) {
+	return s.setTaskTag(ctx, "storage.postgres.AttachTag", taskID, tagID, userID, true)
+}
+
+func (s *Storage) DetachTag(ctx context.Context, taskID, tagID, userID int) (*storage.Task, error) {
+	return s.setTaskTag(ctx, "storage.postgres.DetachTag", taskID, tagID, userID, false)
+}
+
+// setTaskTag attaches or detaches a tag depending on attach and logs the
+// change in the same transaction. Repeating an applied change is a no-op.
+func (s *Storage) setTaskTag(ctx context.Context, op string, taskID, tagID, userID int, attach bool) (*storage.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, taskID, storage.ErrConflict)
+	}
+
+	var foundID int
+	row := tx.QueryRowContext(ctx, `SELECT id FROM tags WHERE user_id = $1 AND id = $2`, userID, tagID)
+	if err := row.Scan(&foundID); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tag [%d] for user [%d]: %w'`, op, tagID, userID, storageError(err))
+	}
+
+	task := storage.TaskWithTag(before, tagID, attach)
+	if slices.Equal(before.TagIDs, task.TagIDs) {
+		return task, nil
+	}
+
+	query := `INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2)`
+	actionType := storage.AttachTagType
+	if !attach {
+		query = `DELETE FROM task_tags WHERE task_id = $1 AND tag_id = $2`
+		actionType = storage.DetachTagType
+	}
+
+	if _, err := tx.ExecContext(ctx, query, taskID, tagID); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := insertTaskAction(ctx, tx, actionType, userID, taskID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
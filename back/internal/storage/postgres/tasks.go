@@ -1,61 +1,160 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"slices"
+	"time"
+	"todo_list_service/internal/metrics"
 	"todo_list_service/internal/storage"
 )
 
-func (s *Storage) GetMaxPriority(userID int) (int, error) {
-	const op = "storage.postgres.GetMaxPriority"
+const taskColumns = `id, title, description, status, status_ts, priority, user_id, project_id, parent_id, creation_ts, archived_ts, start_ts, due_ts, recurrence`
 
-	tasks, err := s.GetTasks(userID, 1)
+func scanTask(row interface{ Scan(dest ...any) error }, task *storage.Task) error {
+	return storageError(row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.StatusTs, &task.Priority, &task.UserID, &task.ProjectID, &task.ParentID, &task.CreationTs, &task.ArchivedTs, &task.StartTs, &task.DueTs, &task.Recurrence))
+}
+
+func scanTasks(rows *sql.Rows) ([]storage.Task, error) {
+	defer rows.Close()
+
+	tasks := []storage.Task{}
+	for rows.Next() {
+		var task storage.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// insertTask adds a task at position in its project without logging it.
+func insertTask(ctx context.Context, tx *sql.Tx, newTask *storage.Task, projectID int, position storage.TaskPosition) (*storage.Task, error) {
+	priority, err := placeInProject(ctx, tx, projectID, 0, position)
 	if err != nil {
-		return 0, fmt.Errorf(`'%s: failed to get max_priority task for user [%d]: %w'`, op, userID, err)
+		return nil, fmt.Errorf("failed to place task in project [%d]: %w", projectID, err)
 	}
 
-	if len(tasks) == 0 {
-		return 0, nil
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO tasks (title, description, status, status_ts, priority, user_id, project_id, parent_id, start_ts, due_ts, recurrence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+taskColumns,
+		newTask.Title, newTask.Description, storage.TaskStatusOpened, storage.StatusTimes{}.With(storage.TaskStatusOpened, time.Now()), priority, newTask.UserID, projectID, newTask.ParentID,
+		newTask.StartTs, newTask.DueTs, newTask.Recurrence)
+	if err := scanTask(row, task); err != nil {
+		return nil, err
 	}
 
-	return tasks[len(tasks)-1].Priority, nil
+	return task, nil
 }
 
-func (s *Storage) CreateTask(newTask *storage.Task) (task *storage.Task, err error) {
-	const op = "storage.postgres.CreateTask"
+// spawnOccurrence creates and logs the next occurrence of a recurring task
+// that has just been closed. The schedule follows the owner's time zone.
+// The series moves on to the new task, an instance that already spawned its
+// occurrence does not spawn another one when it is closed again.
+func spawnOccurrence(ctx context.Context, tx *sql.Tx, closed *storage.Task) error {
+	if closed.Recurrence == "" {
+		return nil
+	}
+
+	var spawnedID *int
+	if err := tx.QueryRowContext(ctx, `SELECT next_occurrence_id FROM tasks WHERE id = $1`, closed.ID).Scan(&spawnedID); err != nil {
+		return fmt.Errorf("failed to get next occurrence of task [%d]: %w", closed.ID, err)
+	}
+	if spawnedID != nil {
+		return nil
+	}
 
-	maxPriority, err := s.GetMaxPriority(newTask.UserID)
+	var timeZone string
+	if err := tx.QueryRowContext(ctx, `SELECT time_zone FROM users WHERE id = $1`, closed.UserID).Scan(&timeZone); err != nil {
+		return fmt.Errorf("failed to get time zone of user [%d]: %w", closed.UserID, err)
+	}
+	loc, err := storage.LoadTimeZone(timeZone)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	tx, _ := s.db.Begin()
+	next, err := storage.NextOccurrence(closed, loc)
+	if err != nil || next == nil {
+		return err
+	}
 
-	createTaskStmt, err := tx.Prepare(`INSERT INTO tasks (title, description, status, priority, user_id) VALUES ($1, $2, $3, $4, $5)
-							           RETURNING id, title, description, status, priority, user_id, creation_ts`)
+	// a closed subtask may sit in another project than the task being closed
+	if err := lockProjects(ctx, tx, next.ProjectID); err != nil {
+		return err
+	}
+	task, err := insertTask(ctx, tx, next, next.ProjectID, storage.TaskPosition{Place: storage.PlaceTop})
 	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
+		return err
+	}
+	if err := setTaskTags(ctx, tx, task.ID, task.UserID, next.TagIDs); err != nil {
+		return err
+	}
+	if err := loadTaskTags(ctx, tx, task); err != nil {
+		return err
 	}
-	defer createTaskStmt.Close()
 
-	task = &storage.Task{}
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET next_occurrence_id = $1 WHERE id = $2`, task.ID, closed.ID); err != nil {
+		return err
+	}
 
-	queryRes := createTaskStmt.QueryRow(newTask.Title, newTask.Description, storage.TaskStatusOpened, maxPriority+storage.TaskPriorityDelta, newTask.UserID)
-	err = queryRes.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.UserID, &task.CreationTs)
+	return insertTaskAction(ctx, tx, storage.CreateTaskType, task.UserID, task.ID, nil, task)
+}
+
+func (s *Storage) CreateTask(ctx context.Context, newTask *storage.Task) (*storage.Task, error) {
+	return s.createTask(ctx, "storage.postgres.CreateTask", newTask, nil, nil, storage.TaskPosition{Place: storage.PlaceTop})
+}
+
+func (s *Storage) CreateTaskWithTags(ctx context.Context, newTask *storage.Task, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	return s.createTask(ctx, "storage.postgres.CreateTaskWithTags", newTask, newTask.TagIDs, tagNames, position)
+}
+
+// createTask inserts a task at position with the given tags, creating the
+// missing named ones, and logs it as one action.
+func (s *Storage) createTask(ctx context.Context, op string, newTask *storage.Task, tagIDs []int, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
 	}
+	defer tx.Rollback()
 
-	insertActionStmt, err := tx.Prepare(`INSERT INTO task_actions (action_type, user_id, task_id) VALUES ($1, $2, $3)`)
+	projectID, err := resolveProject(ctx, tx, newTask.UserID, newTask.ProjectID)
 	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
+		return nil, fmt.Errorf(`'%s: failed to get project [%d] for user [%d]: %w'`, op, newTask.ProjectID, newTask.UserID, err)
+	}
+	if err := lockProjects(ctx, tx, projectID); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to lock project [%d]: %w'`, op, projectID, err)
+	}
+
+	if newTask.ParentID != nil {
+		if err := resolveParent(ctx, tx, newTask.UserID, *newTask.ParentID); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get parent task [%d] for user [%d]: %w'`, op, *newTask.ParentID, newTask.UserID, err)
+		}
 	}
-	defer insertActionStmt.Close()
 
-	if _, err = insertActionStmt.Exec(storage.CreateTaskType, task.UserID, task.ID); err != nil {
-		_ = tx.Rollback()
+	task, err := insertTask(ctx, tx, newTask, projectID, position)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if len(tagIDs) > 0 || len(tagNames) > 0 {
+		createdIDs, err := ensureTags(ctx, tx, newTask.UserID, tagNames)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to create tags: %w'`, op, err)
+		}
+		if err := setTaskTags(ctx, tx, task.ID, task.UserID, append(slices.Clone(tagIDs), createdIDs...)); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to attach tags: %w'`, op, err)
+		}
+		if err := loadTaskTags(ctx, tx, task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get tags of task [%d]: %w'`, op, task.ID, err)
+		}
+	}
+
+	if err := insertTaskAction(ctx, tx, storage.CreateTaskType, task.UserID, task.ID, nil, task); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
@@ -63,42 +162,44 @@ func (s *Storage) CreateTask(newTask *storage.Task) (task *storage.Task, err err
 		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
 	}
 
-	return
+	return task, nil
 }
 
-func (s *Storage) UpdateTaskPriority(taskID, userID, priority int) (*storage.Task, error) {
+func (s *Storage) UpdateTaskPriority(ctx context.Context, taskID, userID int, position storage.TaskPosition) (*storage.Task, error) {
 	const op = "storage.postgres.UpdateTaskPriority"
 
-	query := `UPDATE tasks SET priority = $1 WHERE user_id = $2 AND id = $3
-			  RETURNING id, title, description, status, priority, user_id, creation_ts`
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
 
-	tx, _ := s.db.Begin()
+	before, err := lockPlacedTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, taskID, storage.ErrConflict)
+	}
+	if storage.IsDoneStatus(before.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, storage.ErrTaskDone)
+	}
 
-	updateStmt, err := tx.Prepare(query)
+	priority, err := placeInProject(ctx, tx, before.ProjectID, taskID, position)
 	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
+		return nil, fmt.Errorf(`'%s: failed to place task [%d] in project [%d]: %w'`, op, taskID, before.ProjectID, err)
 	}
-	defer updateStmt.Close()
 
 	task := &storage.Task{}
 
-	queryRes := updateStmt.QueryRow(priority, userID, taskID)
-	err = queryRes.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.UserID, &task.CreationTs)
-	if err != nil {
-		_ = tx.Rollback()
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET priority = $1 WHERE id = $2 RETURNING `+taskColumns, priority, taskID)
+	if err := scanTask(row, task); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
-	insertStmt, err := tx.Prepare(`INSERT INTO task_actions (action_type, user_id, task_id) VALUES ($1, $2, $3)`)
-	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
-	}
-	defer insertStmt.Close()
+	task.TagIDs = before.TagIDs
 
-	if _, err = insertStmt.Exec(storage.UpdateTaskPriorityType, task.UserID, task.ID); err != nil {
-		_ = tx.Rollback()
+	if err := insertTaskAction(ctx, tx, storage.UpdateTaskPriorityType, task.UserID, task.ID, before, task); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
@@ -109,102 +210,343 @@ func (s *Storage) UpdateTaskPriority(taskID, userID, priority int) (*storage.Tas
 	return task, nil
 }
 
-func (s *Storage) UpdateTask(updatedTask *storage.Task) (*storage.Task, error) {
+func (s *Storage) UpdateTask(ctx context.Context, updatedTask *storage.Task, closePolicy storage.ClosePolicy) (*storage.Task, error) {
 	const op = "storage.postgres.UpdateTask"
 
-	if updatedTask.Status == storage.TaskStatusClosed {
-		updatedTask.Priority = storage.TaskPriorityClosed
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockPlacedTask(ctx, tx, updatedTask.ID, updatedTask.UserID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, updatedTask.ID, updatedTask.UserID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, updatedTask.ID, storage.ErrConflict)
+	}
+
+	workflow, err := projectWorkflow(ctx, tx, before.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get workflow of project [%d]: %w'`, op, before.ProjectID, err)
+	}
+	if !workflow.Allows(before.Status, updatedTask.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d] from status [%d] to [%d]: %w'`, op, before.ID, before.Status, updatedTask.Status, storage.ErrInvalidTransition)
 	}
 
-	query := `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4 WHERE user_id = $5 AND id = $6
-			  RETURNING id, title, description, status, priority, user_id, creation_ts`
+	statusTs := before.StatusTs
+	if updatedTask.Status != before.Status {
+		statusTs = statusTs.With(updatedTask.Status, time.Now())
+	}
 
-	tx, _ := s.db.Begin()
+	closing := storage.IsDoneStatus(updatedTask.Status) && !storage.IsDoneStatus(before.Status) && before.ArchivedTs == nil
+	// the task closes along with the subtasks the close policy closes
+	closed := 0
+	if closing {
+		cascaded, err := closeSubtasks(ctx, tx, before.ID, closePolicy)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to close subtasks of task [%d]: %w'`, op, before.ID, err)
+		}
+		closed = cascaded + 1
+	}
 
-	updateStmt, err := tx.Prepare(query)
+	priority, err := statusPriority(ctx, tx, before, updatedTask.Status)
 	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
+		return nil, fmt.Errorf(`'%s: failed to place task [%d]: %w'`, op, before.ID, err)
 	}
-	defer updateStmt.Close()
 
 	task := &storage.Task{}
 
-	queryRes := updateStmt.QueryRow(updatedTask.Title, updatedTask.Description, updatedTask.Status, updatedTask.Priority, updatedTask.UserID, updatedTask.ID)
-	err = queryRes.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.UserID, &task.CreationTs)
-	if err != nil {
-		_ = tx.Rollback()
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET title = $1, description = $2, status = $3, status_ts = $4, priority = $5, start_ts = $6, due_ts = $7, recurrence = $8
+		WHERE user_id = $9 AND id = $10 AND archived_ts IS NULL
+		RETURNING `+taskColumns,
+		updatedTask.Title, updatedTask.Description, updatedTask.Status, statusTs, priority, updatedTask.StartTs, updatedTask.DueTs, updatedTask.Recurrence,
+		updatedTask.UserID, updatedTask.ID)
+	if err := scanTask(row, task); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
-	insertStmt, err := tx.Prepare(`INSERT INTO task_actions (action_type, user_id, task_id) VALUES ($1, $2, $3)`)
-	if err != nil {
-		_ = tx.Rollback()
-		return nil, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
-	}
-	defer insertStmt.Close()
+	task.TagIDs = before.TagIDs
 
-	if _, err = insertStmt.Exec(storage.UpdateTaskType, task.UserID, task.ID); err != nil {
-		_ = tx.Rollback()
+	if err := insertTaskAction(ctx, tx, storage.UpdateTaskType, task.UserID, task.ID, before, task); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
+	if closing {
+		if err := spawnOccurrence(ctx, tx, task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to create next occurrence of task [%d]: %w'`, op, task.ID, err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
 	}
+	metrics.TasksClosed.Add(float64(closed))
 
 	return task, nil
 }
 
-func (s *Storage) GetTask(taskID, userID int) (task *storage.Task, err error) {
-	const op = "storage.postgres.GetTasks"
+func (s *Storage) GetTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	const op = "storage.postgres.GetTask"
 
-	rows, err := s.db.Query(`SELECT title, status, creation_ts FROM tasks
-		WHERE user_id = $1 AND id = $2`, userID, taskID)
+	task := &storage.Task{}
 
-	if err != nil {
-		return nil, fmt.Errorf(`'%s: failed to get task for user: %w'`, op, err)
-	} else {
-		defer rows.Close()
+	row := s.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE user_id = $1 AND id = $2`, userID, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if err := loadTaskTags(ctx, s.db, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tags of task [%d]: %w'`, op, taskID, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	return s.ListTasks(ctx, userID, storage.TaskFilter{}, limit)
+}
+
+// orderBy is the ORDER BY clause of each task sort, see storage.CompareTasks.
+var orderBy = map[storage.TaskSort]string{
+	storage.SortByPriority: "priority DESC, id",
+	storage.SortByCreation: "creation_ts DESC, id DESC",
+	storage.SortByDue:      "due_ts NULLS LAST, id",
+}
 
-		rows.Next()
-		task = &storage.Task{
-			ID: taskID,
+func (s *Storage) ListTasks(ctx context.Context, userID int, filter storage.TaskFilter, limit int) ([]storage.Task, error) {
+	const op = "storage.postgres.ListTasks"
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE user_id = " + arg(userID) + " AND archived_ts IS NULL"
+
+	if filter.ProjectID != 0 {
+		query += " AND project_id = " + arg(filter.ProjectID)
+	}
+
+	if tagIDs := filter.UniqueTagIDs(); len(tagIDs) > 0 {
+		tagCond := "task_tags.task_id = tasks.id AND task_tags.tag_id = ANY(" + arg(intArray(tagIDs)) + ")"
+		if filter.MatchAllTags {
+			query += " AND (SELECT COUNT(*) FROM task_tags WHERE " + tagCond + ") = " + arg(len(tagIDs))
+		} else {
+			query += " AND EXISTS (SELECT 1 FROM task_tags WHERE " + tagCond + ")"
 		}
-		if err := rows.Scan(&task.Title, &task.Status, &task.CreationTs); err != nil {
-			return nil, fmt.Errorf(`'%s: failed to read task: %w'`, op, err)
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]int, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = int(status)
 		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf(`'%s: got error while selecting task from db: %w'`, op, err)
+		query += " AND status = ANY(" + arg(intArray(statuses)) + ")"
+	}
+	if filter.CreatedFrom != nil {
+		query += " AND creation_ts >= " + arg(*filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query += " AND creation_ts < " + arg(*filter.CreatedTo)
+	}
+	if filter.DueFrom != nil {
+		query += " AND due_ts >= " + arg(*filter.DueFrom)
+	}
+	if filter.DueTo != nil {
+		query += " AND due_ts < " + arg(*filter.DueTo)
+	}
+
+	order := filter.OrderBy()
+	if cursor := filter.After; cursor != nil {
+		id := arg(cursor.ID)
+		switch order {
+		case storage.SortByCreation:
+			ts := arg(*cursor.Ts)
+			query += " AND (creation_ts < " + ts + " OR (creation_ts = " + ts + " AND id < " + id + "))"
+		case storage.SortByDue:
+			if cursor.Ts == nil {
+				query += " AND due_ts IS NULL AND id > " + id
+			} else {
+				ts := arg(*cursor.Ts)
+				query += " AND (due_ts > " + ts + " OR due_ts IS NULL OR (due_ts = " + ts + " AND id > " + id + "))"
+			}
+		default:
+			priority := arg(cursor.Priority)
+			query += " AND (priority < " + priority + " OR (priority = " + priority + " AND id > " + id + "))"
 		}
-		return
 	}
+
+	query += " ORDER BY " + orderBy[order] + " LIMIT " + arg(limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tasks, nil
 }
 
-func (s *Storage) GetTasks(userID, limit int) (tasks []storage.Task, err error) {
-	const op = "storage.postgres.GetTasks"
+func (s *Storage) GetDueTasks(ctx context.Context, userID int, from *time.Time, to time.Time, limit int) ([]storage.Task, error) {
+	const op = "storage.postgres.GetDueTasks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE user_id = $1 AND archived_ts IS NULL AND status NOT IN ($2, $3)
+			AND due_ts IS NOT NULL AND ($4::TIMESTAMPTZ IS NULL OR due_ts >= $4) AND due_ts < $5
+		ORDER BY due_ts, priority DESC, id LIMIT $6`, userID, storage.TaskStatusClosed, storage.TaskStatusCancelled, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get due tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read due tasks for user [%d]: %w'`, op, userID, err)
+	}
 
-	tasks = []storage.Task{}
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
 
-	rows, err := s.db.Query("SELECT id, title, description, status, priority, creation_ts FROM tasks WHERE user_id = $1 ORDER BY priority DESC LIMIT $2", userID, limit)
+	return tasks, nil
+}
 
+// setArchived archives or restores a task depending on archive and logs the action.
+func (s *Storage) setArchived(ctx context.Context, op string, taskID, userID int, archive bool) (*storage.Task, error) {
+	query := `UPDATE tasks SET archived_ts = now() WHERE user_id = $1 AND id = $2 AND archived_ts IS NULL RETURNING ` + taskColumns
+	actionType := storage.ArchiveTaskType
+	if !archive {
+		query = `UPDATE tasks SET archived_ts = NULL WHERE user_id = $1 AND id = $2 AND archived_ts IS NOT NULL RETURNING ` + taskColumns
+		actionType = storage.RestoreTaskType
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf(`'%s: failed to get tasks for user [%d]: %w'`, op, userID, err)
-	} else {
-		defer rows.Close()
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
 
-		for rows.Next() {
-			task := storage.Task{
-				UserID: userID,
-			}
-			if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.Priority, &task.CreationTs); err != nil {
-				return nil, fmt.Errorf(`'%s: failed to read task: %w'`, op, err)
-			}
-			tasks = append(tasks, task)
-		}
-		if err = rows.Err(); err != nil {
-			return nil, fmt.Errorf(`'%s: failed to get tasks for user [%d]: %w'`, op, userID, err)
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	task := &storage.Task{}
+
+	if err := scanTask(tx.QueryRowContext(ctx, query, userID, taskID), task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, actionType, task.UserID, task.ID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) ArchiveTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.setArchived(ctx, "storage.postgres.ArchiveTask", taskID, userID, true)
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.setArchived(ctx, "storage.postgres.RestoreTask", taskID, userID, false)
+}
+
+func (s *Storage) GetArchivedTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	const op = "storage.postgres.GetArchivedTasks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE user_id = $1 AND archived_ts IS NOT NULL
+		ORDER BY archived_ts DESC, id LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get archived tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read archived tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) DeleteTask(ctx context.Context, taskID, userID int) error {
+	const op = "storage.postgres.DeleteTask"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE user_id = $1 AND id = $2`, userID, taskID); err != nil {
+		return fmt.Errorf(`'%s: failed to delete task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if err := insertTaskAction(ctx, tx, storage.DeleteTaskType, userID, taskID, before, nil); err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PurgeArchivedTasks(ctx context.Context, archivedBefore time.Time) (int, error) {
+	const op = "storage.postgres.PurgeArchivedTasks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `DELETE FROM tasks WHERE archived_ts IS NOT NULL AND archived_ts < $1 RETURNING `+taskColumns, archivedBefore)
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	purged, err := scanTasks(rows)
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to read purged tasks: %w'`, op, err)
+	}
+
+	for i := range purged {
+		if err := insertTaskAction(ctx, tx, storage.DeleteTaskType, purged[i].UserID, purged[i].ID, &purged[i], nil); err != nil {
+			return 0, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 		}
-		return
 	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return len(purged), nil
 }
@@ -1,38 +1,47 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"todo_list_service/internal/storage"
 )
 
-func (s *Storage) CreateUser(username, hashedPassword, email string) (userID int, err error) {
+func (s *Storage) CreateUser(ctx context.Context, username, hashedPassword, email string) (userID int, err error) {
 	const op = "storage.postgres.CreateUser"
 
 	var cnt int
-	row := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = $1", username)
+	row := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username = $1", username)
 	if err := row.Scan(&cnt); err != nil && err != sql.ErrNoRows {
 		return -1, fmt.Errorf(`'%s: failed to scan user data: %w'`, op, err)
 	}
 	if cnt != 0 {
-		return 0, fmt.Errorf(`'%s: user with name [%s] already exists'`, op, username)
+		return 0, fmt.Errorf(`'%s: user with name [%s]: %w'`, op, username, storage.ErrAlreadyExists)
 	}
 
-	tx, _ := s.db.Begin()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
 
-	stmt, err := tx.Prepare("INSERT INTO users (username, password, email) VALUES ($1, $2, $3) RETURNING id")
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO users (username, password, email) VALUES ($1, $2, $3) RETURNING id")
 	if err != nil {
 		_ = tx.Rollback()
 		return -1, fmt.Errorf(`'%s: failed to prepare query: %w'`, op, err)
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRow(username, hashedPassword, email).Scan(&userID)
+	err = stmt.QueryRowContext(ctx, username, hashedPassword, email).Scan(&userID)
 	if err != nil {
 		_ = tx.Rollback()
 		return -1, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
 	}
 
+	if err := insertInbox(ctx, tx, userID); err != nil {
+		_ = tx.Rollback()
+		return -1, fmt.Errorf(`'%s: failed to create inbox: %w'`, op, err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return -1, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
 	}
@@ -40,32 +49,95 @@ func (s *Storage) CreateUser(username, hashedPassword, email string) (userID int
 	return
 }
 
-func (s *Storage) GetUserByUsername(username string) (user *storage.User, err error) {
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (user *storage.User, err error) {
 	const op = "storage.postgres.GetUser"
 
 	user = &storage.User{
 		Username: username,
 	}
 
-	row := s.db.QueryRow("SELECT id, password, email, creation_ts FROM users WHERE username = $1", username)
-	if err := row.Scan(&user.ID, &user.Password, &user.Email, &user.CreationTs); err != nil {
-		return nil, fmt.Errorf(`'%s: failed to get user by username from db: %w'`, op, err)
+	row := s.db.QueryRowContext(ctx, "SELECT id, password, email, creation_ts, time_zone FROM users WHERE username = $1", username)
+	if err := row.Scan(&user.ID, &user.Password, &user.Email, &user.CreationTs, &user.TimeZone); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get user by username from db: %w'`, op, storageError(err))
 	}
 
 	return
 }
 
-func (s *Storage) GetUserByID(userID int) (user *storage.User, err error) {
+func (s *Storage) GetUserByID(ctx context.Context, userID int) (user *storage.User, err error) {
 	const op = "storage.postgres.GetUserByID"
 
 	user = &storage.User{
 		ID: userID,
 	}
 
-	row := s.db.QueryRow("SELECT username, password, email, creation_ts FROM users WHERE id = $1", userID)
-	if err := row.Scan(&user.Username, &user.Password, &user.Email, &user.CreationTs); err != nil {
-		return nil, fmt.Errorf(`'%s: failed to get user by id from db: %w'`, op, err)
+	row := s.db.QueryRowContext(ctx, "SELECT username, password, email, creation_ts, time_zone FROM users WHERE id = $1", userID)
+	if err := row.Scan(&user.Username, &user.Password, &user.Email, &user.CreationTs, &user.TimeZone); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get user by id from db: %w'`, op, storageError(err))
+	}
+
+	return
+}
+
+func (s *Storage) ListUsers(ctx context.Context) (users []storage.User, err error) {
+	const op = "storage.postgres.ListUsers"
+
+	users = []storage.User{}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, username, password, email, creation_ts, time_zone FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to list users: %w'`, op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user storage.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreationTs, &user.TimeZone); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read user: %w'`, op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to list users: %w'`, op, err)
 	}
 
 	return
 }
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error {
+	const op = "storage.postgres.UpdateUserPassword"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hashedPassword, userID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf(`'%s: user [%d]: %w'`, op, userID, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateUserTimeZone(ctx context.Context, userID int, timeZone string) error {
+	const op = "storage.postgres.UpdateUserTimeZone"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET time_zone = $1 WHERE id = $2", timeZone, userID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf(`'%s: user [%d]: %w'`, op, userID, storage.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"todo_list_service/internal/storage"
+)
+
+const viewColumns = `id, user_id, name, query, creation_ts`
+
+func scanView(row interface{ Scan(dest ...any) error }, view *storage.SavedView) error {
+	return storageError(row.Scan(&view.ID, &view.UserID, &view.Name, &view.Query, &view.CreationTs))
+}
+
+func (s *Storage) CreateSavedView(ctx context.Context, newView *storage.SavedView) (*storage.SavedView, error) {
+	const op = "storage.postgres.CreateSavedView"
+
+	view := &storage.SavedView{}
+
+	row := s.db.QueryRowContext(ctx, `INSERT INTO saved_views (user_id, name, query) VALUES ($1, $2, $3) RETURNING `+viewColumns,
+		newView.UserID, newView.Name, newView.Query)
+	if err := scanView(row, view); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to create view [%s] for user [%d]: %w'`, op, newView.Name, newView.UserID, err)
+	}
+
+	return view, nil
+}
+
+func (s *Storage) UpdateSavedView(ctx context.Context, updatedView *storage.SavedView) (*storage.SavedView, error) {
+	const op = "storage.postgres.UpdateSavedView"
+
+	view := &storage.SavedView{}
+
+	row := s.db.QueryRowContext(ctx, `UPDATE saved_views SET name = $1, query = $2 WHERE user_id = $3 AND id = $4 RETURNING `+viewColumns,
+		updatedView.Name, updatedView.Query, updatedView.UserID, updatedView.ID)
+	if err := scanView(row, view); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to update view [%d] for user [%d]: %w'`, op, updatedView.ID, updatedView.UserID, err)
+	}
+
+	return view, nil
+}
+
+func (s *Storage) DeleteSavedView(ctx context.Context, viewID, userID int) error {
+	const op = "storage.postgres.DeleteSavedView"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM saved_views WHERE user_id = $1 AND id = $2`, userID, viewID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf(`'%s: view [%d] for user [%d]: %w'`, op, viewID, userID, storage.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) GetSavedView(ctx context.Context, viewID, userID int) (*storage.SavedView, error) {
+	const op = "storage.postgres.GetSavedView"
+
+	view := &storage.SavedView{}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+viewColumns+` FROM saved_views WHERE user_id = $1 AND id = $2`, userID, viewID)
+	if err := scanView(row, view); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get view [%d] for user [%d]: %w'`, op, viewID, userID, err)
+	}
+
+	return view, nil
+}
+
+func (s *Storage) GetSavedViews(ctx context.Context, userID int) ([]storage.SavedView, error) {
+	const op = "storage.postgres.GetSavedViews"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+viewColumns+` FROM saved_views WHERE user_id = $1 ORDER BY name, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get views for user [%d]: %w'`, op, userID, err)
+	}
+	defer rows.Close()
+
+	views := []storage.SavedView{}
+	for rows.Next() {
+		var view storage.SavedView
+		if err := scanView(rows, &view); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read view: %w'`, op, err)
+		}
+		views = append(views, view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get views for user [%d]: %w'`, op, userID, err)
+	}
+
+	return views, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrTaskDone      = errors.New("done tasks stay at the bottom of their project")
+	ErrInvalidAnchor = errors.New("anchor task is not another open task of the same project")
+)
+
+// Placement names where a task goes relative to the other open tasks of
+// its project, ordered from the highest priority down.
+type Placement string
+
+const (
+	PlaceTop    Placement = "top"
+	PlaceBottom Placement = "bottom"
+	// PlaceBefore puts the task right above the anchor task.
+	PlaceBefore Placement = "before"
+	// PlaceAfter puts the task right below the anchor task.
+	PlaceAfter Placement = "after"
+)
+
+// TaskPosition is where UpdateTaskPriority moves a task, AnchorID is only
+// used by PlaceBefore and PlaceAfter.
+type TaskPosition struct {
+	Place    Placement
+	AnchorID int
+}
+
+// NewTaskPosition checks a placement name and its anchor, before and after
+// need one, top and bottom must not have one.
+func NewTaskPosition(place string, anchorID int) (TaskPosition, error) {
+	position := TaskPosition{Place: Placement(place), AnchorID: anchorID}
+	switch position.Place {
+	case PlaceTop, PlaceBottom:
+		if anchorID != 0 {
+			return TaskPosition{}, fmt.Errorf("placement [%s] takes no anchor task", place)
+		}
+	case PlaceBefore, PlaceAfter:
+		if anchorID <= 0 {
+			return TaskPosition{}, fmt.Errorf("placement [%s] needs an anchor task", place)
+		}
+	default:
+		return TaskPosition{}, fmt.Errorf("unknown placement [%s]", place)
+	}
+	return position, nil
+}
+
+// Index returns where the position puts a task among the ids of the other
+// open tasks of its project, top first. It reports false when the anchor is
+// not one of them.
+func (position TaskPosition) Index(ids []int) (int, bool) {
+	switch position.Place {
+	case PlaceTop:
+		return 0, true
+	case PlaceBottom:
+		return len(ids), true
+	}
+
+	index := slices.Index(ids, position.AnchorID)
+	if index < 0 {
+		return 0, false
+	}
+	if position.Place == PlaceAfter {
+		index++
+	}
+	return index, true
+}
+
+// PriorityBetween returns a priority strictly between the priorities of the
+// tasks right above and right below a place in a project. MaxInt as above
+// stands for the top of the list and MinInt as below for its bottom, ends of
+// the list are left TaskPriorityDelta apart when there is room for it. It
+// reports false when the neighbours leave no room and the list needs to be
+// spread out with SpreadPriorities.
+func PriorityBetween(above, below int) (int, bool) {
+	// int64 keeps the sums from overflowing on 32 bit builds
+	hi, lo := int64(above), int64(below)
+
+	var priority int64
+	switch {
+	case above == MaxInt && below == MinInt:
+		return TaskPriorityDelta, true
+	case above == MaxInt:
+		priority = lo + TaskPriorityDelta
+	case below == MinInt:
+		priority = hi - TaskPriorityDelta
+	default:
+		priority = lo + (hi-lo)/2
+	}
+
+	if priority >= hi || priority <= lo {
+		priority = lo + (hi-lo)/2
+	}
+	if priority >= hi || priority <= lo {
+		return 0, false
+	}
+	return int(priority), true
+}
+
+// SpreadPriorities returns n strictly decreasing priorities for a list of n
+// tasks, TaskPriorityDelta apart, or closer when the list is too long for
+// that to fit below MaxInt.
+func SpreadPriorities(n int) []int {
+	step := TaskPriorityDelta
+	if limit := MaxInt / (n + 1); limit < step {
+		step = max(limit, 1)
+	}
+
+	priorities := make([]int, n)
+	for i := range priorities {
+		priorities[i] = (n - i) * step
+	}
+	return priorities
+}
+
+// PlaceAt works out the priority of a task put at index among the
+// priorities of the other open tasks of its project, highest first. When
+// the neighbours leave no room, spread holds new priorities for the whole
+// new list, the task's own included, and the other tasks have to be
+// renumbered with them.
+func PlaceAt(order []int, index int) (priority int, spread []int) {
+	above, below := MaxInt, MinInt
+	if index > 0 {
+		above = order[index-1]
+	}
+	if index < len(order) {
+		below = order[index]
+	}
+
+	if priority, ok := PriorityBetween(above, below); ok {
+		return priority, nil
+	}
+
+	spread = SpreadPriorities(len(order) + 1)
+	return spread[index], spread
+}
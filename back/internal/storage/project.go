@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// InboxProjectName is the name of the project every user gets at sign up.
+// Tasks created without a project land there.
+const InboxProjectName = "Inbox"
+
+// Project groups a user's tasks, priorities are ordered within a project.
+type Project struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	IsInbox    bool      `json:"is_inbox"`
+	Workflow   Workflow  `json:"workflow"`
+	CreationTs time.Time `json:"creation_ts"`
+}
+
+// ProjectRepository is implemented by every backend able to persist
+// projects. The inbox cannot be deleted, deleting any other project moves
+// its tasks to the inbox. A project without workflow statuses uses
+// DefaultWorkflow, UpdateProject keeps the current workflow then.
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, newProject *Project) (*Project, error)
+	UpdateProject(ctx context.Context, updatedProject *Project) (*Project, error)
+	DeleteProject(ctx context.Context, projectID, userID int) error
+	GetProjects(ctx context.Context, userID int) ([]Project, error)
+
+	// MoveTask puts a task on top of another project of the same user.
+	MoveTask(ctx context.Context, taskID, projectID, userID int) (*Task, error)
+}
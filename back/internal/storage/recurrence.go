@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"slices"
+	"time"
+	"todo_list_service/internal/recurrence"
+)
+
+// NextOccurrence builds the task that follows a closed occurrence of a
+// recurring task. The due date comes from the recurrence rule evaluated in
+// loc, the start date keeps its distance to the due date. It returns nil
+// when the task does not recur, has no due date or its rule is exhausted.
+func NextOccurrence(task *Task, loc *time.Location) (*Task, error) {
+	if task.Recurrence == "" || task.DueTs == nil {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	due, ok := rule.Next(task.DueTs.In(loc))
+	if !ok {
+		return nil, nil
+	}
+	if rule.Count > 0 {
+		rule.Count--
+	}
+
+	next := &Task{
+		Title:       task.Title,
+		Description: task.Description,
+		UserID:      task.UserID,
+		ProjectID:   task.ProjectID,
+		DueTs:       &due,
+		Recurrence:  rule.String(),
+		TagIDs:      slices.Clone(task.TagIDs),
+	}
+	if task.ParentID != nil {
+		parentID := *task.ParentID
+		next.ParentID = &parentID
+	}
+	if task.StartTs != nil {
+		start := task.StartTs.Add(due.Sub(*task.DueTs))
+		next.StartTs = &start
+	}
+
+	return next, nil
+}
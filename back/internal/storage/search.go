@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// HighlightStart and HighlightStop wrap the matched words in snippets.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+
+	// MatchStart and MatchStop wrap the matched words in the raw snippets
+	// backends build, they are private use characters so that no markup
+	// comes from the task text itself.
+	MatchStart = "\uE000"
+	MatchStop  = "\uE001"
+)
+
+var highlightReplacer = strings.NewReplacer(MatchStart, HighlightStart, MatchStop, HighlightStop)
+
+// HighlightSnippet escapes a raw snippet for html and turns its match marks
+// into HighlightStart and HighlightStop.
+func HighlightSnippet(raw string) string {
+	return highlightReplacer.Replace(html.EscapeString(raw))
+}
+
+// TaskMatch is a task found by SearchTasks. TitleSnippet is the whole title
+// and DescriptionSnippet the best matching part of the description, matched
+// words are wrapped in HighlightStart and HighlightStop. Snippets are html,
+// the task text in them is escaped. A higher Rank matches better, ranks are
+// only comparable within one search.
+type TaskMatch struct {
+	Task               Task    `json:"task"`
+	Rank               float64 `json:"rank"`
+	TitleSnippet       string  `json:"title_snippet"`
+	DescriptionSnippet string  `json:"description_snippet"`
+}
+
+// SearchRepository is implemented by every backend able to search tasks.
+type SearchRepository interface {
+	// SearchTasks returns the user's active tasks whose title or description
+	// hold every word of query, best matches first. Words are stemmed where
+	// the backend knows the language, Russian and English on postgres.
+	SearchTasks(ctx context.Context, userID int, query string, limit int) ([]TaskMatch, error)
+}
+
+// SearchTerms splits a search query into lowercase words, everything but
+// letters and digits separates them.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
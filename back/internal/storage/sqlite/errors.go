@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"todo_list_service/internal/storage"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// storageError tells the storage error kind of a failed query: no rows is
+// storage.ErrNotFound, as every lookup is scoped by user, and a unique
+// constraint failing storage.ErrAlreadyExists. Other errors are returned as
+// they are.
+func storageError(err error) error {
+	var sqliteErr *sqlite.Error
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+	case errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		return fmt.Errorf("%w: %w", storage.ErrAlreadyExists, err)
+	}
+	return err
+}
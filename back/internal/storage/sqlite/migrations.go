@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"todo_list_service/internal/storage/migrate"
+)
+
+// Migrations are compiled into the binary so a single file is enough to run
+// the service on machines without the source tree.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migrator returns the migration runner bound to this storage.
+func (s *Storage) Migrator() (*migrate.Migrator, error) {
+	const op = "storage.sqlite.Migrator"
+
+	source, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	migrator, err := migrate.New(s.db, migrate.Sqlite, source)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to load migrations: %w'`, op, err)
+	}
+
+	return migrator, nil
+}
+
+func (s *Storage) applyMigrations() error {
+	const op = "storage.sqlite.ApplyMigrations"
+
+	migrator, err := s.Migrator()
+	if err != nil {
+		return err
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	return nil
+}
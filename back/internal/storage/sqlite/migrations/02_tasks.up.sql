@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(128),
+    description VARCHAR(4096),
+    status SMALLINT, -- 1 (opened), 2 (closed)
+    priority INTEGER,
+    user_id INTEGER,
+    creation_ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS tasks_user_id_idx ON tasks (user_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS task_actions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action_type SMALLINT, -- 0 (create task), 1 (update task), 2 (update task priority)
+    user_id INTEGER,
+    task_id INTEGER,
+    ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS task_id_idx ON task_actions (task_id);
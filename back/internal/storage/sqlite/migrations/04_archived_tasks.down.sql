@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_archived_ts_idx;
+
+ALTER TABLE tasks DROP COLUMN archived_ts;
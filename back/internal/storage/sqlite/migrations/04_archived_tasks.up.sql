@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN archived_ts TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_archived_ts_idx ON tasks (archived_ts) WHERE archived_ts IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE task_actions ADD COLUMN before_snapshot TEXT;
+ALTER TABLE task_actions ADD COLUMN after_snapshot TEXT;
+
+CREATE INDEX IF NOT EXISTS task_actions_user_task_idx ON task_actions (user_id, task_id, id);
@@ -0,0 +1 @@
+ALTER TABLE task_actions DROP COLUMN target_action_id;
@@ -0,0 +1 @@
+ALTER TABLE task_actions ADD COLUMN target_action_id INTEGER;
@@ -0,0 +1,6 @@
+ALTER TABLE users DROP COLUMN time_zone;
+
+DROP INDEX IF EXISTS tasks_due_ts_idx;
+
+ALTER TABLE tasks DROP COLUMN due_ts;
+ALTER TABLE tasks DROP COLUMN start_ts;
@@ -0,0 +1,6 @@
+ALTER TABLE tasks ADD COLUMN start_ts TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN due_ts TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS tasks_due_ts_idx ON tasks (user_id, due_ts) WHERE due_ts IS NOT NULL;
+
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
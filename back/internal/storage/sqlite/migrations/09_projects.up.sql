@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    creation_ts TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS projects_inbox_idx ON projects (user_id) WHERE is_inbox;
+
+-- every existing user, and every owner of existing tasks, gets an inbox
+INSERT OR IGNORE INTO projects (user_id, name, is_inbox)
+SELECT id, 'Inbox', TRUE FROM users
+UNION
+SELECT DISTINCT user_id, 'Inbox', TRUE FROM tasks WHERE user_id IS NOT NULL;
+
+-- no REFERENCES here: sqlite cannot drop a column used by a foreign key,
+-- the storage checks project ownership itself
+ALTER TABLE tasks ADD COLUMN project_id INTEGER;
+
+UPDATE tasks SET project_id = (
+    SELECT projects.id FROM projects WHERE projects.user_id = tasks.user_id AND projects.is_inbox
+);
+
+CREATE INDEX IF NOT EXISTS tasks_project_priority_idx ON tasks (project_id, priority DESC, id);
@@ -0,0 +1,5 @@
+-- no REFERENCES here: sqlite cannot drop a column used by a foreign key,
+-- the storage unlinks subtasks of deleted tasks itself
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parent_id);
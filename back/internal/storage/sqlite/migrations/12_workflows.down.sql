@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN status_ts;
+
+ALTER TABLE projects DROP COLUMN workflow;
@@ -0,0 +1,8 @@
+-- an empty workflow stands for the default one
+ALTER TABLE projects ADD COLUMN workflow TEXT NOT NULL DEFAULT '';
+
+-- when each task last entered each status, keyed by status
+ALTER TABLE tasks ADD COLUMN status_ts TEXT NOT NULL DEFAULT '{}';
+
+UPDATE tasks SET status_ts = json_object(CAST(status AS TEXT), strftime('%Y-%m-%dT%H:%M:%SZ', creation_ts))
+WHERE status IS NOT NULL AND creation_ts IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tasks_creation_ts_idx;
+DROP INDEX IF EXISTS tasks_priority_idx;
@@ -0,0 +1,3 @@
+-- keyset pagination of task listings
+CREATE INDEX IF NOT EXISTS tasks_priority_idx ON tasks (user_id, priority DESC, id);
+CREATE INDEX IF NOT EXISTS tasks_creation_ts_idx ON tasks (user_id, creation_ts DESC, id DESC);
@@ -0,0 +1,24 @@
+-- full-text index over tasks kept in sync by triggers, porter stems english
+-- words only
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_search USING fts5(
+    title,
+    description,
+    content = 'tasks',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+INSERT INTO tasks_search (tasks_search) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS tasks_search_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_search (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_search_delete AFTER DELETE ON tasks BEGIN
+    INSERT INTO tasks_search (tasks_search, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_search_update AFTER UPDATE OF title, description ON tasks BEGIN
+    INSERT INTO tasks_search (tasks_search, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO tasks_search (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
@@ -0,0 +1 @@
+ALTER TABLE tasks DROP COLUMN next_occurrence_id;
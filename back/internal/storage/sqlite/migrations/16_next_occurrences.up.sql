@@ -0,0 +1,3 @@
+-- the occurrence a recurring task spawned when it was closed, closing it
+-- again after a reopen or an undo does not spawn a second one
+ALTER TABLE tasks ADD COLUMN next_occurrence_id INTEGER;
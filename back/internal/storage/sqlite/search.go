@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"todo_list_service/internal/storage"
+)
+
+// scanWith scans the task columns of a row followed by extra columns.
+type scanWith struct {
+	row   interface{ Scan(dest ...any) error }
+	extra []any
+}
+
+func (s scanWith) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// matchQuery turns a search query into an fts5 query matching every word as
+// a prefix, which stands in for stemming in languages porter does not know.
+func matchQuery(query string) string {
+	terms := storage.SearchTerms(query)
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+func (s *Storage) SearchTasks(ctx context.Context, userID int, query string, limit int) ([]storage.TaskMatch, error) {
+	const op = "storage.sqlite.SearchTasks"
+
+	matches := []storage.TaskMatch{}
+
+	ftsQuery := matchQuery(query)
+	if ftsQuery == "" {
+		return matches, nil
+	}
+
+	// bm25 is lower for better matches, titles weigh twice as much
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+`, match_rank, title_snippet, description_snippet
+		FROM tasks JOIN (
+			SELECT rowid AS match_id, -bm25(tasks_search, 2.0, 1.0) AS match_rank,
+				highlight(tasks_search, 0, ?, ?) AS title_snippet,
+				snippet(tasks_search, 1, ?, ?, '…', 16) AS description_snippet
+			FROM tasks_search WHERE tasks_search MATCH ?
+		) ON id = match_id
+		WHERE user_id = ? AND archived_ts IS NULL
+		ORDER BY match_rank DESC, priority DESC, id LIMIT ?`,
+		storage.MatchStart, storage.MatchStop, storage.MatchStart, storage.MatchStop, ftsQuery, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to search tasks for user [%d]: %w'`, op, userID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var match storage.TaskMatch
+		if err := scanTask(scanWith{rows, []any{&match.Rank, &match.TitleSnippet, &match.DescriptionSnippet}}, &match.Task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+		}
+		match.TitleSnippet = storage.HighlightSnippet(match.TitleSnippet)
+		match.DescriptionSnippet = storage.HighlightSnippet(match.DescriptionSnippet)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+	}
+	// the tags need the only connection
+	rows.Close()
+
+	tasks := make([]*storage.Task, len(matches))
+	for i := range matches {
+		tasks[i] = &matches[i].Task
+	}
+	if err := loadTaskTags(ctx, s.db, tasks...); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return matches, nil
+}
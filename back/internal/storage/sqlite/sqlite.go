@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	_ "modernc.org/sqlite"
+)
+
+var _ storage.Storage = (*Storage)(nil)
+
+// spanOptions keeps the traced queries to the statements themselves, spans
+// for every row read and connection reset would only be noise.
+var spanOptions = otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}
+
+type Storage struct {
+	cfg *config.SqliteConfig
+	db  *sql.DB
+}
+
+func generateDSNFromConfig(cfg *config.SqliteConfig) string {
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", cfg.Path)
+}
+
+// DB returns the connection pool, for metrics to read its stats.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
+// Ping checks that the database answers.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.sqlite.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf(`'%s: %w'`, op, err)
+	}
+	return nil
+}
+
+func (s *Storage) Close() error {
+	err := s.db.Close()
+	return err
+}
+
+// Open opens the database file without touching the schema.
+func Open(cfg *config.SqliteConfig) (*Storage, error) {
+	const op = "storage.sqlite.Open"
+
+	db, err := otelsql.Open("sqlite", generateDSNFromConfig(cfg), otelsql.WithAttributes(semconv.DBSystemSqlite), otelsql.WithSpanOptions(spanOptions))
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: %w'`, op, err)
+	}
+
+	// sqlite allows a single writer at a time, serializing connections
+	// avoids "database is locked" errors under concurrent requests.
+	db.SetMaxOpenConns(1)
+
+	return &Storage{
+		db:  db,
+		cfg: cfg,
+	}, nil
+}
+
+// New opens the database file and applies pending migrations.
+func New(cfg *config.SqliteConfig) (*Storage, error) {
+	storage, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.applyMigrations(); err != nil {
+		_ = storage.Close()
+		return nil, err
+	}
+
+	return storage, nil
+}
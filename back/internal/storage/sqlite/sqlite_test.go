@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+	"todo_list_service/internal/config"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/migrate"
+	"todo_list_service/internal/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		s, err := New(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), "todo_list.db")})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	})
+}
+
+// migrationsUpTo returns the embedded migrations up to version, with the
+// same contents and so the same checksums.
+func migrationsUpTo(t *testing.T, version int) fs.FS {
+	t.Helper()
+
+	source, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		t.Fatalf("fs.Sub: %v", err)
+	}
+	migrations, err := migrate.Load(source)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	older := fstest.MapFS{}
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			older[fmt.Sprintf("%02d_%s.up.sql", migration.Version, migration.Name)] = &fstest.MapFile{Data: []byte(migration.Up)}
+		}
+	}
+	return older
+}
+
+// TestUpgradeStatusTimes reads back a task written before status times
+// were tracked.
+func TestUpgradeStatusTimes(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.SqliteConfig{Path: filepath.Join(t.TempDir(), "todo_list.db")}
+
+	s, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	migrator, err := migrate.New(s.db, migrate.Sqlite, migrationsUpTo(t, 11))
+	if err != nil {
+		t.Fatalf("migrate.New: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up to 11: %v", err)
+	}
+
+	var userID, taskID int
+	if err := s.db.QueryRowContext(ctx, `INSERT INTO users (username, password) VALUES ('old', 'hashed') RETURNING id`).Scan(&userID); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO projects (user_id, name, is_inbox) VALUES (?, 'Inbox', TRUE)`, userID); err != nil {
+		t.Fatalf("insert inbox: %v", err)
+	}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO tasks (title, description, status, priority, user_id, project_id, creation_ts)
+		SELECT 'old', '', ?, 1, ?, id, '2024-05-01 10:00:00' FROM projects WHERE user_id = ?
+		RETURNING id`, storage.TaskStatusOpened, userID, userID).Scan(&taskID)
+	if err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+
+	if err := s.applyMigrations(); err != nil {
+		t.Fatalf("applyMigrations: %v", err)
+	}
+
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	if entered := task.StatusTs[storage.TaskStatusOpened]; !entered.Equal(want) {
+		t.Errorf("entered opened at %v, want %v", entered, want)
+	}
+
+	if _, err := s.ListTasks(ctx, userID, storage.TaskFilter{}, 10); err != nil {
+		t.Errorf("ListTasks: %v", err)
+	}
+}
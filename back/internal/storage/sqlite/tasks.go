@@ -0,0 +1,566 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+)
+
+const taskColumns = `id, title, description, status, status_ts, priority, user_id, project_id, parent_id, creation_ts, archived_ts, start_ts, due_ts, recurrence`
+
+func scanTask(row interface{ Scan(dest ...any) error }, task *storage.Task) error {
+	return storageError(row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.StatusTs, &task.Priority, &task.UserID, &task.ProjectID, &task.ParentID, &task.CreationTs, &task.ArchivedTs, &task.StartTs, &task.DueTs, &task.Recurrence))
+}
+
+func scanTasks(rows *sql.Rows) ([]storage.Task, error) {
+	defer rows.Close()
+
+	tasks := []storage.Task{}
+	for rows.Next() {
+		var task storage.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// now returns the current time in UTC. Timestamps are stored as text in
+// sqlite, keeping them in one zone makes them comparable.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// insertTask adds a task at position in its project without logging it.
+func insertTask(ctx context.Context, tx *sql.Tx, newTask *storage.Task, projectID int, position storage.TaskPosition) (*storage.Task, error) {
+	priority, err := placeInProject(ctx, tx, projectID, 0, position)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place task in project [%d]: %w", projectID, err)
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `INSERT INTO tasks (title, description, status, status_ts, priority, user_id, project_id, parent_id, start_ts, due_ts, recurrence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+taskColumns,
+		newTask.Title, newTask.Description, storage.TaskStatusOpened, storage.StatusTimes{}.With(storage.TaskStatusOpened, now()), priority, newTask.UserID, projectID, newTask.ParentID,
+		utcTs(newTask.StartTs), utcTs(newTask.DueTs), newTask.Recurrence)
+	if err := scanTask(row, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// spawnOccurrence creates and logs the next occurrence of a recurring task
+// that has just been closed. The schedule follows the owner's time zone.
+// The series moves on to the new task, an instance that already spawned its
+// occurrence does not spawn another one when it is closed again.
+func spawnOccurrence(ctx context.Context, tx *sql.Tx, closed *storage.Task) error {
+	if closed.Recurrence == "" {
+		return nil
+	}
+
+	var spawnedID *int
+	if err := tx.QueryRowContext(ctx, `SELECT next_occurrence_id FROM tasks WHERE id = ?`, closed.ID).Scan(&spawnedID); err != nil {
+		return fmt.Errorf("failed to get next occurrence of task [%d]: %w", closed.ID, err)
+	}
+	if spawnedID != nil {
+		return nil
+	}
+
+	var timeZone string
+	if err := tx.QueryRowContext(ctx, `SELECT time_zone FROM users WHERE id = ?`, closed.UserID).Scan(&timeZone); err != nil {
+		return fmt.Errorf("failed to get time zone of user [%d]: %w", closed.UserID, err)
+	}
+	loc, err := storage.LoadTimeZone(timeZone)
+	if err != nil {
+		return err
+	}
+
+	next, err := storage.NextOccurrence(closed, loc)
+	if err != nil || next == nil {
+		return err
+	}
+
+	task, err := insertTask(ctx, tx, next, next.ProjectID, storage.TaskPosition{Place: storage.PlaceTop})
+	if err != nil {
+		return err
+	}
+	if err := setTaskTags(ctx, tx, task.ID, task.UserID, next.TagIDs); err != nil {
+		return err
+	}
+	if err := loadTaskTags(ctx, tx, task); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET next_occurrence_id = ? WHERE id = ?`, task.ID, closed.ID); err != nil {
+		return err
+	}
+
+	return insertTaskAction(ctx, tx, storage.CreateTaskType, task.UserID, task.ID, nil, task)
+}
+
+func (s *Storage) CreateTask(ctx context.Context, newTask *storage.Task) (*storage.Task, error) {
+	return s.createTask(ctx, "storage.sqlite.CreateTask", newTask, nil, nil, storage.TaskPosition{Place: storage.PlaceTop})
+}
+
+func (s *Storage) CreateTaskWithTags(ctx context.Context, newTask *storage.Task, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	return s.createTask(ctx, "storage.sqlite.CreateTaskWithTags", newTask, newTask.TagIDs, tagNames, position)
+}
+
+// createTask inserts a task at position with the given tags, creating the
+// missing named ones, and logs it as one action.
+func (s *Storage) createTask(ctx context.Context, op string, newTask *storage.Task, tagIDs []int, tagNames []string, position storage.TaskPosition) (*storage.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	projectID, err := resolveProject(ctx, tx, newTask.UserID, newTask.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get project [%d] for user [%d]: %w'`, op, newTask.ProjectID, newTask.UserID, err)
+	}
+
+	if newTask.ParentID != nil {
+		if err := resolveParent(ctx, tx, newTask.UserID, *newTask.ParentID); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get parent task [%d] for user [%d]: %w'`, op, *newTask.ParentID, newTask.UserID, err)
+		}
+	}
+
+	task, err := insertTask(ctx, tx, newTask, projectID, position)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if len(tagIDs) > 0 || len(tagNames) > 0 {
+		createdIDs, err := ensureTags(ctx, tx, newTask.UserID, tagNames)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to create tags: %w'`, op, err)
+		}
+		if err := setTaskTags(ctx, tx, task.ID, task.UserID, append(slices.Clone(tagIDs), createdIDs...)); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to attach tags: %w'`, op, err)
+		}
+		if err := loadTaskTags(ctx, tx, task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to get tags of task [%d]: %w'`, op, task.ID, err)
+		}
+	}
+
+	if err := insertTaskAction(ctx, tx, storage.CreateTaskType, task.UserID, task.ID, nil, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) UpdateTaskPriority(ctx context.Context, taskID, userID int, position storage.TaskPosition) (*storage.Task, error) {
+	const op = "storage.sqlite.UpdateTaskPriority"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, taskID, storage.ErrConflict)
+	}
+	if storage.IsDoneStatus(before.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d]: %w'`, op, taskID, storage.ErrTaskDone)
+	}
+
+	priority, err := placeInProject(ctx, tx, before.ProjectID, taskID, position)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task [%d] in project [%d]: %w'`, op, taskID, before.ProjectID, err)
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET priority = ? WHERE id = ? RETURNING `+taskColumns, priority, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, storage.UpdateTaskPriorityType, task.UserID, task.ID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, updatedTask *storage.Task, closePolicy storage.ClosePolicy) (*storage.Task, error) {
+	const op = "storage.sqlite.UpdateTask"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, updatedTask.ID, updatedTask.UserID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, updatedTask.ID, updatedTask.UserID, err)
+	}
+	if before.ArchivedTs != nil {
+		return nil, fmt.Errorf(`'%s: task [%d] is archived: %w'`, op, updatedTask.ID, storage.ErrConflict)
+	}
+
+	workflow, err := projectWorkflow(ctx, tx, before.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get workflow of project [%d]: %w'`, op, before.ProjectID, err)
+	}
+	if !workflow.Allows(before.Status, updatedTask.Status) {
+		return nil, fmt.Errorf(`'%s: task [%d] from status [%d] to [%d]: %w'`, op, before.ID, before.Status, updatedTask.Status, storage.ErrInvalidTransition)
+	}
+
+	statusTs := before.StatusTs
+	if updatedTask.Status != before.Status {
+		statusTs = statusTs.With(updatedTask.Status, now())
+	}
+
+	closing := storage.IsDoneStatus(updatedTask.Status) && !storage.IsDoneStatus(before.Status) && before.ArchivedTs == nil
+	// the task closes along with the subtasks the close policy closes
+	closed := 0
+	if closing {
+		cascaded, err := closeSubtasks(ctx, tx, before.ID, closePolicy)
+		if err != nil {
+			return nil, fmt.Errorf(`'%s: failed to close subtasks of task [%d]: %w'`, op, before.ID, err)
+		}
+		closed = cascaded + 1
+	}
+
+	priority, err := statusPriority(ctx, tx, before, updatedTask.Status)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to place task [%d]: %w'`, op, before.ID, err)
+	}
+
+	task := &storage.Task{}
+
+	row := tx.QueryRowContext(ctx, `UPDATE tasks SET title = ?, description = ?, status = ?, status_ts = ?, priority = ?, start_ts = ?, due_ts = ?, recurrence = ?
+		WHERE user_id = ? AND id = ? AND archived_ts IS NULL
+		RETURNING `+taskColumns,
+		updatedTask.Title, updatedTask.Description, updatedTask.Status, statusTs, priority, utcTs(updatedTask.StartTs), utcTs(updatedTask.DueTs), updatedTask.Recurrence,
+		updatedTask.UserID, updatedTask.ID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, storage.UpdateTaskType, task.UserID, task.ID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if closing {
+		if err := spawnOccurrence(ctx, tx, task); err != nil {
+			return nil, fmt.Errorf(`'%s: failed to create next occurrence of task [%d]: %w'`, op, task.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+	metrics.TasksClosed.Add(float64(closed))
+
+	return task, nil
+}
+
+func (s *Storage) GetTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	const op = "storage.sqlite.GetTask"
+
+	task := &storage.Task{}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE user_id = ? AND id = ?`, userID, taskID)
+	if err := scanTask(row, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if err := loadTaskTags(ctx, s.db, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tags of task [%d]: %w'`, op, taskID, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	return s.ListTasks(ctx, userID, storage.TaskFilter{}, limit)
+}
+
+// orderBy is the ORDER BY clause of each task sort, see storage.CompareTasks.
+var orderBy = map[storage.TaskSort]string{
+	storage.SortByPriority: "priority DESC, id",
+	storage.SortByCreation: "creation_ts DESC, id DESC",
+	storage.SortByDue:      "due_ts NULLS LAST, id",
+}
+
+// creationTs formats ts the way CURRENT_TIMESTAMP fills in creation_ts, in
+// whole UTC seconds, so the two compare as strings.
+func creationTs(ts time.Time) string {
+	return ts.UTC().Format(time.DateTime)
+}
+
+func (s *Storage) ListTasks(ctx context.Context, userID int, filter storage.TaskFilter, limit int) ([]storage.Task, error) {
+	const op = "storage.sqlite.ListTasks"
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return "?"
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE user_id = " + arg(userID) + " AND archived_ts IS NULL"
+
+	if filter.ProjectID != 0 {
+		query += " AND project_id = " + arg(filter.ProjectID)
+	}
+
+	if tagIDs := filter.UniqueTagIDs(); len(tagIDs) > 0 {
+		tagCond := "task_tags.task_id = tasks.id AND task_tags.tag_id IN (SELECT value FROM json_each(" + arg(intArray(tagIDs)) + "))"
+		if filter.MatchAllTags {
+			query += " AND (SELECT COUNT(*) FROM task_tags WHERE " + tagCond + ") = " + arg(len(tagIDs))
+		} else {
+			query += " AND EXISTS (SELECT 1 FROM task_tags WHERE " + tagCond + ")"
+		}
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]int, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = int(status)
+		}
+		query += " AND status IN (SELECT value FROM json_each(" + arg(intArray(statuses)) + "))"
+	}
+	if filter.CreatedFrom != nil {
+		query += " AND creation_ts >= " + arg(creationTs(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		query += " AND creation_ts < " + arg(creationTs(*filter.CreatedTo))
+	}
+	if filter.DueFrom != nil {
+		query += " AND due_ts >= " + arg(filter.DueFrom.UTC())
+	}
+	if filter.DueTo != nil {
+		query += " AND due_ts < " + arg(filter.DueTo.UTC())
+	}
+
+	order := filter.OrderBy()
+	if cursor := filter.After; cursor != nil {
+		switch order {
+		case storage.SortByCreation:
+			ts := creationTs(*cursor.Ts)
+			query += " AND (creation_ts < " + arg(ts) + " OR (creation_ts = " + arg(ts) + " AND id < " + arg(cursor.ID) + "))"
+		case storage.SortByDue:
+			if cursor.Ts == nil {
+				query += " AND due_ts IS NULL AND id > " + arg(cursor.ID)
+			} else {
+				ts := cursor.Ts.UTC()
+				query += " AND (due_ts > " + arg(ts) + " OR due_ts IS NULL OR (due_ts = " + arg(ts) + " AND id > " + arg(cursor.ID) + "))"
+			}
+		default:
+			query += " AND (priority < " + arg(cursor.Priority) + " OR (priority = " + arg(cursor.Priority) + " AND id > " + arg(cursor.ID) + "))"
+		}
+	}
+
+	query += " ORDER BY " + orderBy[order] + " LIMIT " + arg(limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) GetDueTasks(ctx context.Context, userID int, from *time.Time, to time.Time, limit int) ([]storage.Task, error) {
+	const op = "storage.sqlite.GetDueTasks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE user_id = ? AND archived_ts IS NULL AND status NOT IN (?, ?)
+			AND due_ts IS NOT NULL AND (? IS NULL OR due_ts >= ?) AND due_ts < ?
+		ORDER BY due_ts, priority DESC, id LIMIT ?`, userID, storage.TaskStatusClosed, storage.TaskStatusCancelled, utcTs(from), utcTs(from), to.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get due tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read due tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tasks, nil
+}
+
+// setArchived archives or restores a task depending on archive and logs the action.
+func (s *Storage) setArchived(ctx context.Context, op string, taskID, userID int, archive bool) (*storage.Task, error) {
+	query := `UPDATE tasks SET archived_ts = ? WHERE user_id = ? AND id = ? AND archived_ts IS NULL RETURNING ` + taskColumns
+	args := []any{now(), userID, taskID}
+	actionType := storage.ArchiveTaskType
+	if !archive {
+		query = `UPDATE tasks SET archived_ts = NULL WHERE user_id = ? AND id = ? AND archived_ts IS NOT NULL RETURNING ` + taskColumns
+		args = args[1:]
+		actionType = storage.RestoreTaskType
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	task := &storage.Task{}
+
+	if err := scanTask(tx.QueryRowContext(ctx, query, args...), task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	task.TagIDs = before.TagIDs
+
+	if err := insertTaskAction(ctx, tx, actionType, task.UserID, task.ID, before, task); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return task, nil
+}
+
+func (s *Storage) ArchiveTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.setArchived(ctx, "storage.sqlite.ArchiveTask", taskID, userID, true)
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, taskID, userID int) (*storage.Task, error) {
+	return s.setArchived(ctx, "storage.sqlite.RestoreTask", taskID, userID, false)
+}
+
+func (s *Storage) GetArchivedTasks(ctx context.Context, userID, limit int) ([]storage.Task, error) {
+	const op = "storage.sqlite.GetArchivedTasks"
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE user_id = ? AND archived_ts IS NOT NULL
+		ORDER BY archived_ts DESC, id LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to get archived tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read archived tasks for user [%d]: %w'`, op, userID, err)
+	}
+
+	if err := loadTasksTags(ctx, s.db, tasks); err != nil {
+		return nil, fmt.Errorf(`'%s: failed to read tags for user [%d]: %w'`, op, userID, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) DeleteTask(ctx context.Context, taskID, userID int) error {
+	const op = "storage.sqlite.DeleteTask"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	before, err := lockTask(ctx, tx, taskID, userID)
+	if err != nil {
+		return fmt.Errorf(`'%s: failed to get task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET parent_id = NULL WHERE parent_id = ?`, taskID); err != nil {
+		return fmt.Errorf(`'%s: failed to unlink subtasks of task [%d]: %w'`, op, taskID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE user_id = ? AND id = ?`, userID, taskID); err != nil {
+		return fmt.Errorf(`'%s: failed to delete task [%d] for user [%d]: %w'`, op, taskID, userID, err)
+	}
+
+	if err := insertTaskAction(ctx, tx, storage.DeleteTaskType, userID, taskID, before, nil); err != nil {
+		return fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PurgeArchivedTasks(ctx context.Context, archivedBefore time.Time) (int, error) {
+	const op = "storage.sqlite.PurgeArchivedTasks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to begin transaction: %w'`, op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM tasks WHERE archived_ts IS NOT NULL AND archived_ts < ?)`, archivedBefore.UTC()); err != nil {
+		return 0, fmt.Errorf(`'%s: failed to unlink subtasks of purged tasks: %w'`, op, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `DELETE FROM tasks WHERE archived_ts IS NOT NULL AND archived_ts < ? RETURNING `+taskColumns, archivedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+	}
+
+	purged, err := scanTasks(rows)
+	if err != nil {
+		return 0, fmt.Errorf(`'%s: failed to read purged tasks: %w'`, op, err)
+	}
+
+	for i := range purged {
+		if err := insertTaskAction(ctx, tx, storage.DeleteTaskType, purged[i].UserID, purged[i].ID, &purged[i], nil); err != nil {
+			return 0, fmt.Errorf(`'%s: failed to execute query: %w'`, op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf(`'%s: failed to commit transaction: %w'`, op, err)
+	}
+
+	return len(purged), nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// TaskRepository is implemented by every backend able to persist tasks.
+// All task lookups are scoped by the owner's user id, tasks of other users
+// are reported as ErrNotFound. Archived tasks are hidden from GetTasks and
+// updating them fails with ErrConflict until they are restored.
+type TaskRepository interface {
+	CreateTask(ctx context.Context, newTask *Task) (*Task, error)
+	// CreateTaskWithTags is CreateTask putting the task at position and
+	// attaching newTask.TagIDs along with the tags named tagNames, the
+	// names the user has no tag for yet are created. It all happens in one
+	// transaction logged as a single create action.
+	CreateTaskWithTags(ctx context.Context, newTask *Task, tagNames []string, position TaskPosition) (*Task, error)
+	// UpdateTask fails with ErrInvalidTransition when the workflow of the
+	// task's project does not allow the status change. It applies
+	// closePolicy to the open subtasks when the update finishes the task.
+	// The priority of updatedTask is ignored, done tasks sink to the bottom
+	// and reopened ones go back on top, other moves use UpdateTaskPriority.
+	// The task and the subtasks it closes count towards the closed tasks
+	// metric once the update is committed.
+	UpdateTask(ctx context.Context, updatedTask *Task, closePolicy ClosePolicy) (*Task, error)
+	// UpdateTaskPriority moves an open task within its project to position,
+	// the priority is worked out from the current order with the project
+	// locked against concurrent placements. When the priorities around
+	// leave no room they are renumbered in the same transaction,
+	// renumbering is not logged. Done tasks fail with ErrTaskDone and
+	// anchors other than another open task of the same project with
+	// ErrInvalidAnchor.
+	UpdateTaskPriority(ctx context.Context, taskID, userID int, position TaskPosition) (*Task, error)
+	GetTask(ctx context.Context, taskID, userID int) (*Task, error)
+	GetTasks(ctx context.Context, userID, limit int) ([]Task, error)
+	// ListTasks is GetTasks narrowed down, ordered and paged by filter.
+	ListTasks(ctx context.Context, userID int, filter TaskFilter, limit int) ([]Task, error)
+
+	ArchiveTask(ctx context.Context, taskID, userID int) (*Task, error)
+	RestoreTask(ctx context.Context, taskID, userID int) (*Task, error)
+	GetArchivedTasks(ctx context.Context, userID, limit int) ([]Task, error)
+	DeleteTask(ctx context.Context, taskID, userID int) error
+	// PurgeArchivedTasks deletes tasks of all users archived before the
+	// given moment and returns how many were deleted.
+	PurgeArchivedTasks(ctx context.Context, archivedBefore time.Time) (int, error)
+
+	// GetDueTasks returns the user's unfinished active tasks due in [from, to),
+	// ordered by due date. A nil from leaves the range open to the past.
+	GetDueTasks(ctx context.Context, userID int, from *time.Time, to time.Time, limit int) ([]Task, error)
+
+	// GetTaskHistory returns the user's actions on a task, newest first.
+	GetTaskHistory(ctx context.Context, taskID, userID, limit, offset int) ([]TaskAction, error)
+
+	// UndoTask reverts the user's most recent change of a task, RedoTask
+	// re-applies the most recently undone one. Both are logged as actions.
+	// The task is placed again among the current tasks of its project and
+	// restoring a status the project's workflow no longer has fails with
+	// ErrInvalidTransition. Restoring a done status counts as closing the
+	// task.
+	UndoTask(ctx context.Context, taskID, userID int) (*Task, error)
+	RedoTask(ctx context.Context, taskID, userID int) (*Task, error)
+}
+
+// UserRepository is implemented by every backend able to persist users.
+// CreateUser also creates the user's inbox project. It returns a
+// non-negative user id together with an error wrapping ErrAlreadyExists
+// when the username is already taken and -1 on any other failure.
+type UserRepository interface {
+	CreateUser(ctx context.Context, username, hashedPassword, email string) (int, error)
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByID(ctx context.Context, userID int) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) error
+	UpdateUserTimeZone(ctx context.Context, userID int, timeZone string) error
+}
+
+// Storage is the full set of operations the http handlers rely on.
+type Storage interface {
+	TaskRepository
+	UserRepository
+	TagRepository
+	ProjectRepository
+	SubtaskRepository
+	SearchRepository
+	SavedViewRepository
+
+	Close() error
+}
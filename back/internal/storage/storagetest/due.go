@@ -0,0 +1,130 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+func mustCreateDueTask(t *testing.T, s storage.Storage, userID int, title string, due time.Time) *storage.Task {
+	t.Helper()
+
+	task, err := s.CreateTask(context.Background(), &storage.Task{
+		Title:  title,
+		UserID: userID,
+		DueTs:  &due,
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task
+}
+
+func taskTitles(tasks []storage.Task) []string {
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func testTaskDates(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	start := time.Date(2030, time.March, 1, 9, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	due := start.Add(48 * time.Hour)
+
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "dated", UserID: userID, StartTs: &start, DueTs: &due})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if task.StartTs == nil || !task.StartTs.Equal(start) || task.DueTs == nil || !task.DueTs.Equal(due) {
+		t.Fatalf("CreateTask stored dates %v - %v, want %v - %v", task.StartTs, task.DueTs, start, due)
+	}
+
+	task.DueTs = nil
+	updated, err := s.UpdateTask(ctx, task, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if updated.DueTs != nil || updated.StartTs == nil || !updated.StartTs.Equal(start) {
+		t.Fatalf("UpdateTask stored dates %v - %v", updated.StartTs, updated.DueTs)
+	}
+}
+
+func testGetDueTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	now := time.Now().Truncate(time.Second)
+
+	mustCreateDueTask(t, s, userID, "overdue", now.Add(-2*time.Hour))
+	mustCreateDueTask(t, s, userID, "soon", now.Add(time.Hour))
+	mustCreateDueTask(t, s, userID, "later", now.Add(72*time.Hour))
+	mustCreateTask(t, s, userID, "undated")
+
+	closed := mustCreateDueTask(t, s, userID, "closed", now.Add(-time.Hour))
+	closed.Status = storage.TaskStatusClosed
+	if _, err := s.UpdateTask(ctx, closed, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	archived := mustCreateDueTask(t, s, userID, "archived", now.Add(-time.Hour))
+	if _, err := s.ArchiveTask(ctx, archived.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	overdue, err := s.GetDueTasks(ctx, userID, nil, now, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetDueTasks: %v", err)
+	}
+	if titles := taskTitles(overdue); len(titles) != 1 || titles[0] != "overdue" {
+		t.Fatalf("GetDueTasks(overdue) returned %v, want [overdue]", titles)
+	}
+
+	from := now.Add(-3 * time.Hour)
+	window, err := s.GetDueTasks(ctx, userID, &from, now.Add(24*time.Hour), storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetDueTasks: %v", err)
+	}
+	if titles := taskTitles(window); len(titles) != 2 || titles[0] != "overdue" || titles[1] != "soon" {
+		t.Fatalf("GetDueTasks(window) returned %v, want [overdue soon]", titles)
+	}
+
+	limited, err := s.GetDueTasks(ctx, userID, nil, now.Add(96*time.Hour), 2)
+	if err != nil {
+		t.Fatalf("GetDueTasks: %v", err)
+	}
+	if titles := taskTitles(limited); len(titles) != 2 || titles[1] != "soon" {
+		t.Fatalf("GetDueTasks(limit 2) returned %v, want [overdue soon]", titles)
+	}
+}
+
+func testUpdateUserTimeZone(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.TimeZone != storage.DefaultTimeZone {
+		t.Fatalf("new user has time zone %q, want %q", user.TimeZone, storage.DefaultTimeZone)
+	}
+
+	if err := s.UpdateUserTimeZone(ctx, userID, "Europe/Moscow"); err != nil {
+		t.Fatalf("UpdateUserTimeZone: %v", err)
+	}
+
+	user, err = s.GetUserByUsername(ctx, user.Username)
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %v", err)
+	}
+	if user.TimeZone != "Europe/Moscow" {
+		t.Fatalf("time zone is %q after update, want Europe/Moscow", user.TimeZone)
+	}
+
+	if err := s.UpdateUserTimeZone(ctx, -1, "UTC"); err == nil {
+		t.Fatal("UpdateUserTimeZone succeeded for a missing user")
+	}
+}
@@ -0,0 +1,223 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+// errorCase is a storage call expected to fail with an error kind.
+type errorCase struct {
+	name string
+	call func() error
+}
+
+func checkErrorKind(t *testing.T, kind error, cases []errorCase) {
+	t.Helper()
+
+	for _, tc := range cases {
+		if err := tc.call(); !errors.Is(err, kind) {
+			t.Errorf("%s returned %v, want %v", tc.name, err, kind)
+		}
+	}
+}
+
+func testNotFoundErrors(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	ownerID := mustCreateUser(t, s)
+	otherID := mustCreateUser(t, s)
+
+	task := mustCreateTask(t, s, ownerID, "private")
+	tag := mustCreateTag(t, s, ownerID, "private")
+	project := mustCreateProject(t, s, ownerID, "private")
+	view := mustCreateView(t, s, ownerID, "private", "")
+	foreignTask := mustCreateTask(t, s, otherID, "foreign")
+	foreignProject := mustCreateProject(t, s, otherID, "foreign")
+
+	checkErrorKind(t, storage.ErrNotFound, []errorCase{
+		{"GetUserByUsername", func() error {
+			_, err := s.GetUserByUsername(ctx, uniqueName(t))
+			return err
+		}},
+		{"GetUserByID", func() error {
+			_, err := s.GetUserByID(ctx, -1)
+			return err
+		}},
+		{"UpdateUserPassword", func() error {
+			return s.UpdateUserPassword(ctx, -1, "hashed")
+		}},
+		{"UpdateUserTimeZone", func() error {
+			return s.UpdateUserTimeZone(ctx, -1, "UTC")
+		}},
+		{"GetTask", func() error {
+			_, err := s.GetTask(ctx, task.ID, otherID)
+			return err
+		}},
+		{"CreateTask in a foreign project", func() error {
+			_, err := s.CreateTask(ctx, &storage.Task{Title: "sneaky", UserID: ownerID, ProjectID: foreignProject.ID})
+			return err
+		}},
+		{"UpdateTask", func() error {
+			_, err := s.UpdateTask(ctx, &storage.Task{ID: task.ID, UserID: otherID, Title: "stolen", Status: storage.TaskStatusOpened}, storage.ClosePolicyBlock)
+			return err
+		}},
+		{"UpdateTaskPriority", func() error {
+			_, err := s.UpdateTaskPriority(ctx, task.ID, otherID, storage.TaskPosition{Place: storage.PlaceTop})
+			return err
+		}},
+		{"ArchiveTask", func() error {
+			_, err := s.ArchiveTask(ctx, task.ID, otherID)
+			return err
+		}},
+		{"RestoreTask", func() error {
+			_, err := s.RestoreTask(ctx, task.ID, otherID)
+			return err
+		}},
+		{"DeleteTask", func() error {
+			return s.DeleteTask(ctx, task.ID, otherID)
+		}},
+		{"UndoTask", func() error {
+			_, err := s.UndoTask(ctx, task.ID, otherID)
+			return err
+		}},
+		{"UpdateTag", func() error {
+			_, err := s.UpdateTag(ctx, &storage.Tag{ID: tag.ID, UserID: otherID, Name: "stolen"})
+			return err
+		}},
+		{"DeleteTag", func() error {
+			return s.DeleteTag(ctx, tag.ID, otherID)
+		}},
+		{"AttachTag", func() error {
+			_, err := s.AttachTag(ctx, task.ID, tag.ID, otherID)
+			return err
+		}},
+		{"AttachTag of a foreign tag", func() error {
+			_, err := s.AttachTag(ctx, foreignTask.ID, tag.ID, otherID)
+			return err
+		}},
+		{"UpdateProject", func() error {
+			_, err := s.UpdateProject(ctx, &storage.Project{ID: project.ID, UserID: otherID, Name: "stolen"})
+			return err
+		}},
+		{"DeleteProject", func() error {
+			return s.DeleteProject(ctx, project.ID, otherID)
+		}},
+		{"MoveTask", func() error {
+			_, err := s.MoveTask(ctx, task.ID, project.ID, otherID)
+			return err
+		}},
+		{"MoveTask to a foreign project", func() error {
+			_, err := s.MoveTask(ctx, task.ID, foreignProject.ID, ownerID)
+			return err
+		}},
+		{"SetTaskParent", func() error {
+			_, err := s.SetTaskParent(ctx, task.ID, 0, otherID)
+			return err
+		}},
+		{"SetTaskParent under a foreign task", func() error {
+			_, err := s.SetTaskParent(ctx, task.ID, foreignTask.ID, ownerID)
+			return err
+		}},
+		{"GetSubtasks", func() error {
+			_, err := s.GetSubtasks(ctx, task.ID, otherID)
+			return err
+		}},
+		{"GetSavedView", func() error {
+			_, err := s.GetSavedView(ctx, view.ID, otherID)
+			return err
+		}},
+		{"UpdateSavedView", func() error {
+			_, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: view.ID, UserID: otherID, Name: "stolen"})
+			return err
+		}},
+		{"DeleteSavedView", func() error {
+			return s.DeleteSavedView(ctx, view.ID, otherID)
+		}},
+	})
+}
+
+func testAlreadyExistsErrors(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	username := uniqueName(t)
+	userID, err := s.CreateUser(ctx, username, "hashed", "")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	mustCreateTag(t, s, userID, "taken")
+	tag := mustCreateTag(t, s, userID, "free")
+	mustCreateProject(t, s, userID, "taken")
+	project := mustCreateProject(t, s, userID, "free")
+	mustCreateView(t, s, userID, "taken", "")
+	view := mustCreateView(t, s, userID, "free", "")
+
+	checkErrorKind(t, storage.ErrAlreadyExists, []errorCase{
+		{"CreateUser", func() error {
+			_, err := s.CreateUser(ctx, username, "other", "")
+			return err
+		}},
+		{"CreateTag", func() error {
+			_, err := s.CreateTag(ctx, &storage.Tag{UserID: userID, Name: "taken"})
+			return err
+		}},
+		{"UpdateTag", func() error {
+			_, err := s.UpdateTag(ctx, &storage.Tag{ID: tag.ID, UserID: userID, Name: "taken"})
+			return err
+		}},
+		{"CreateProject", func() error {
+			_, err := s.CreateProject(ctx, &storage.Project{UserID: userID, Name: "taken"})
+			return err
+		}},
+		{"UpdateProject", func() error {
+			_, err := s.UpdateProject(ctx, &storage.Project{ID: project.ID, UserID: userID, Name: "taken"})
+			return err
+		}},
+		{"CreateSavedView", func() error {
+			_, err := s.CreateSavedView(ctx, &storage.SavedView{UserID: userID, Name: "taken"})
+			return err
+		}},
+		{"UpdateSavedView", func() error {
+			_, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: view.ID, UserID: userID, Name: "taken"})
+			return err
+		}},
+	})
+}
+
+func testConflictErrors(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	tag := mustCreateTag(t, s, userID, "work")
+	project := mustCreateProject(t, s, userID, "side")
+	archived := mustCreateTask(t, s, userID, "archived")
+	if _, err := s.ArchiveTask(ctx, archived.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	checkErrorKind(t, storage.ErrConflict, []errorCase{
+		{"UpdateTask of an archived task", func() error {
+			_, err := s.UpdateTask(ctx, &storage.Task{ID: archived.ID, UserID: userID, Title: "changed", Status: storage.TaskStatusOpened}, storage.ClosePolicyBlock)
+			return err
+		}},
+		{"UpdateTaskPriority of an archived task", func() error {
+			_, err := s.UpdateTaskPriority(ctx, archived.ID, userID, storage.TaskPosition{Place: storage.PlaceTop})
+			return err
+		}},
+		{"AttachTag to an archived task", func() error {
+			_, err := s.AttachTag(ctx, archived.ID, tag.ID, userID)
+			return err
+		}},
+		{"MoveTask of an archived task", func() error {
+			_, err := s.MoveTask(ctx, archived.ID, project.ID, userID)
+			return err
+		}},
+	})
+
+	inbox := mustGetInbox(t, s, userID)
+	checkErrorKind(t, storage.ErrForbidden, []errorCase{
+		{"DeleteProject of the inbox", func() error {
+			return s.DeleteProject(ctx, inbox.ID, userID)
+		}},
+	})
+}
@@ -0,0 +1,101 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+func testTaskHistory(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "history")
+
+	task.Title = "history renamed"
+	if _, err := s.UpdateTask(ctx, task, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if _, err := s.ArchiveTask(ctx, task.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+	if err := s.DeleteTask(ctx, task.ID, userID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	history, err := s.GetTaskHistory(ctx, task.ID, userID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+
+	wantTypes := []int{storage.DeleteTaskType, storage.ArchiveTaskType, storage.UpdateTaskType, storage.CreateTaskType}
+	if len(history) != len(wantTypes) {
+		t.Fatalf("GetTaskHistory returned %d actions, want %d", len(history), len(wantTypes))
+	}
+	for i, action := range history {
+		if action.ActionType != wantTypes[i] || action.TaskID != task.ID || action.UserID != userID {
+			t.Fatalf("action %d is %+v, want type %d", i, action, wantTypes[i])
+		}
+	}
+
+	created, updated, archived, deleted := history[3], history[2], history[1], history[0]
+	if created.Before != nil || created.After == nil || created.After.Title != "history" {
+		t.Fatalf("create action has snapshots %+v -> %+v", created.Before, created.After)
+	}
+	if updated.Before == nil || updated.Before.Title != "history" || updated.After == nil || updated.After.Title != "history renamed" {
+		t.Fatalf("update action has snapshots %+v -> %+v", updated.Before, updated.After)
+	}
+	if !slices.Equal(updated.ChangedFields, []string{"title"}) {
+		t.Fatalf("update action changed fields %v, want [title]", updated.ChangedFields)
+	}
+	if archived.Before.ArchivedTs != nil || archived.After.ArchivedTs == nil {
+		t.Fatalf("archive action has snapshots %+v -> %+v", archived.Before, archived.After)
+	}
+	if deleted.Before == nil || deleted.Before.ID != task.ID || deleted.After != nil {
+		t.Fatalf("delete action has snapshots %+v -> %+v", deleted.Before, deleted.After)
+	}
+}
+
+func testTaskHistoryPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "paginated")
+	mustCreateTask(t, s, userID, "neighbour")
+
+	var priorities []int
+	for i := 1; i <= 4; i++ {
+		position := storage.TaskPosition{Place: storage.PlaceTop}
+		if i%2 == 0 {
+			position.Place = storage.PlaceBottom
+		}
+		moved, err := s.UpdateTaskPriority(ctx, task.ID, userID, position)
+		if err != nil {
+			t.Fatalf("UpdateTaskPriority: %v", err)
+		}
+		priorities = append(priorities, moved.Priority)
+	}
+
+	page, err := s.GetTaskHistory(ctx, task.ID, userID, 2, 1)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(page) != 2 || page[0].After.Priority != priorities[2] || page[1].After.Priority != priorities[1] {
+		t.Fatalf("GetTaskHistory(limit 2, offset 1) returned %+v", page)
+	}
+
+	rest, err := s.GetTaskHistory(ctx, task.ID, userID, 10, 4)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(rest) != 1 || rest[0].ActionType != storage.CreateTaskType {
+		t.Fatalf("GetTaskHistory(offset 4) returned %+v", rest)
+	}
+
+	foreign, err := s.GetTaskHistory(ctx, task.ID, mustCreateUser(t, s), 10, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(foreign) != 0 {
+		t.Fatalf("GetTaskHistory returned %d actions of another user's task", len(foreign))
+	}
+}
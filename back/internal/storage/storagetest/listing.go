@@ -0,0 +1,156 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// listPages walks a listing page by page the way the http handler does and
+// returns the ids of every task it saw. between runs after each page.
+func listPages(t *testing.T, s storage.Storage, userID int, filter storage.TaskFilter, limit int, between func()) []int {
+	t.Helper()
+
+	var ids []int
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("listing sorted by %s never ends", filter.OrderBy())
+		}
+
+		tasks, err := s.ListTasks(context.Background(), userID, filter, limit+1)
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+		for i := 1; i < len(tasks); i++ {
+			if storage.CompareTasks(filter.OrderBy(), &tasks[i-1], &tasks[i]) >= 0 {
+				t.Fatalf("tasks %d and %d are out of %s order", tasks[i-1].ID, tasks[i].ID, filter.OrderBy())
+			}
+		}
+		if len(tasks) <= limit {
+			return append(ids, taskIDs(tasks)...)
+		}
+
+		ids = append(ids, taskIDs(tasks[:limit])...)
+
+		// the cursor goes through its string form like it does for clients
+		token := storage.CursorAfter(filter.OrderBy(), &tasks[limit-1]).String()
+		cursor, err := storage.ParseTaskCursor(token, filter.OrderBy())
+		if err != nil {
+			t.Fatalf("ParseTaskCursor: %v", err)
+		}
+		filter.After = cursor
+
+		if between != nil {
+			between()
+		}
+	}
+}
+
+func testListTasksPagination(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	due := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	mustCreateTask(t, s, userID, "undated")
+	mustCreateDueTask(t, s, userID, "due later", due.Add(time.Hour))
+	mustCreateDueTask(t, s, userID, "due first", due)
+	mustCreateDueTask(t, s, userID, "due first too", due)
+	mustCreateTask(t, s, userID, "undated too")
+	// done tasks share the lowest priority
+	mustSetStatus(t, s, mustCreateTask(t, s, userID, "done"), storage.TaskStatusClosed)
+	mustSetStatus(t, s, mustCreateTask(t, s, userID, "done too"), storage.TaskStatusClosed)
+
+	for _, sort := range []storage.TaskSort{storage.SortByPriority, storage.SortByCreation, storage.SortByDue} {
+		filter := storage.TaskFilter{Sort: sort}
+
+		all, err := s.ListTasks(ctx, userID, filter, storage.MaxInt)
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+		want := taskIDs(all)
+		if len(want) != 7 {
+			t.Fatalf("ListTasks sorted by %s returned %d tasks, want 7", sort, len(want))
+		}
+
+		for limit := 1; limit <= 3; limit++ {
+			if got := listPages(t, s, userID, filter, limit, nil); !slices.Equal(got, want) {
+				t.Fatalf("pages of %d sorted by %s hold tasks %v, want %v", limit, sort, got, want)
+			}
+		}
+	}
+
+	// tasks created while paging either show up once or not at all, the
+	// tasks that were there all along show up exactly once
+	for _, sort := range []storage.TaskSort{storage.SortByPriority, storage.SortByCreation, storage.SortByDue} {
+		filter := storage.TaskFilter{Sort: sort}
+
+		all, err := s.ListTasks(ctx, userID, filter, storage.MaxInt)
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+
+		got := listPages(t, s, userID, filter, 2, func() {
+			mustCreateDueTask(t, s, userID, "inserted", due.Add(30*time.Minute))
+		})
+		seen := map[int]int{}
+		for _, id := range got {
+			seen[id]++
+			if seen[id] > 1 {
+				t.Fatalf("pages sorted by %s list task %d twice: %v", sort, id, got)
+			}
+		}
+		for _, task := range all {
+			if seen[task.ID] != 1 {
+				t.Fatalf("pages sorted by %s missed task %d: %v", sort, task.ID, got)
+			}
+		}
+	}
+
+	undated := mustCreateTask(t, s, userID, "undated cursor")
+	token := storage.CursorAfter(storage.SortByDue, undated).String()
+	if _, err := storage.ParseTaskCursor(token, storage.SortByPriority); err == nil {
+		t.Fatal("ParseTaskCursor accepted a due date cursor for a priority listing")
+	}
+	if _, err := storage.ParseTaskCursor("not a cursor", storage.SortByPriority); err == nil {
+		t.Fatal("ParseTaskCursor accepted garbage")
+	}
+}
+
+func testListTasksFilters(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	open := mustCreateTask(t, s, userID, "open")
+	doing := mustSetStatus(t, s, mustCreateTask(t, s, userID, "doing"), storage.TaskStatusInProgress)
+	done := mustSetStatus(t, s, mustCreateTask(t, s, userID, "done"), storage.TaskStatusClosed)
+
+	tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{Statuses: []int8{storage.TaskStatusOpened, storage.TaskStatusInProgress}}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if got := taskIDs(tasks); !slices.Equal(got, []int{doing.ID, open.ID}) {
+		t.Fatalf("ListTasks by status returned tasks %v, want %v", got, []int{doing.ID, open.ID})
+	}
+
+	// creation times may be kept in whole seconds, the bounds stay clear of them
+	past, future := open.CreationTs.Add(-time.Hour), done.CreationTs.Add(time.Hour)
+	for _, tt := range []struct {
+		name     string
+		from, to *time.Time
+		want     int
+	}{
+		{"around", &past, &future, 3},
+		{"before", nil, &past, 0},
+		{"after", &future, nil, 0},
+	} {
+		tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{CreatedFrom: tt.from, CreatedTo: tt.to}, storage.MaxInt)
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+		if len(tasks) != tt.want {
+			t.Fatalf("ListTasks created %s the range returned %d tasks, want %d", tt.name, len(tasks), tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+// checkOrder lists a project and fails unless its tasks come in want order
+// with strictly decreasing priorities strictly inside (MinInt, MaxInt).
+func checkOrder(t *testing.T, s storage.Storage, userID, projectID int, want []int) []storage.Task {
+	t.Helper()
+
+	tasks, err := s.ListTasks(context.Background(), userID, storage.TaskFilter{ProjectID: projectID}, 1000)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if got := taskIDs(tasks); !slices.Equal(got, want) {
+		t.Fatalf("project holds tasks %v, want %v", got, want)
+	}
+	for i, task := range tasks {
+		if task.Priority == storage.MinInt || task.Priority == storage.MaxInt {
+			t.Fatalf("task %d got the reserved priority %d", task.ID, task.Priority)
+		}
+		if i > 0 && task.Priority >= tasks[i-1].Priority {
+			t.Fatalf("task %d has priority %d, not below %d of task %d", task.ID, task.Priority, tasks[i-1].Priority, tasks[i-1].ID)
+		}
+	}
+	return tasks
+}
+
+// moveTo moves the task at from to position to of the listed order, below
+// its new upper neighbour or, with before set, above its new lower one.
+func moveTo(t *testing.T, s storage.Storage, userID int, tasks []storage.Task, from, to int, before bool) []int {
+	t.Helper()
+
+	moved := tasks[from]
+	rest := slices.Delete(slices.Clone(tasks), from, from+1)
+
+	var position storage.TaskPosition
+	switch {
+	case before && to < len(rest):
+		position = storage.TaskPosition{Place: storage.PlaceBefore, AnchorID: rest[to].ID}
+	case to > 0:
+		position = storage.TaskPosition{Place: storage.PlaceAfter, AnchorID: rest[to-1].ID}
+	case len(rest) > 0 && before:
+		position = storage.TaskPosition{Place: storage.PlaceBefore, AnchorID: rest[0].ID}
+	default:
+		position = storage.TaskPosition{Place: storage.PlaceTop}
+	}
+	if _, err := s.UpdateTaskPriority(context.Background(), moved.ID, userID, position); err != nil {
+		t.Fatalf("UpdateTaskPriority to %+v: %v", position, err)
+	}
+
+	return slices.Insert(taskIDs(rest), to, moved.ID)
+}
+
+func mustCreateProjectTasks(t *testing.T, s storage.Storage, userID, n int) (*storage.Project, []int) {
+	t.Helper()
+
+	project := mustCreateProject(t, s, userID, uniqueName(t))
+
+	var ids []int
+	for i := 0; i < n; i++ {
+		task, err := s.CreateTask(context.Background(), &storage.Task{Title: "ordered", UserID: userID, ProjectID: project.ID})
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		ids = slices.Insert(ids, 0, task.ID)
+	}
+	return project, ids
+}
+
+// testRandomTaskMoves is a property test: any sequence of moves leaves
+// the project in the order the moves describe with distinct priorities.
+func testRandomTaskMoves(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+
+	for seed := uint64(1); seed <= 4; seed++ {
+		rnd := rand.New(rand.NewPCG(seed, seed))
+		project, want := mustCreateProjectTasks(t, s, userID, 2+rnd.IntN(7))
+
+		for step := 0; step < 60; step++ {
+			tasks := checkOrder(t, s, userID, project.ID, want)
+			want = moveTo(t, s, userID, tasks, rnd.IntN(len(tasks)), rnd.IntN(len(tasks)), rnd.IntN(2) == 0)
+		}
+		checkOrder(t, s, userID, project.ID, want)
+	}
+}
+
+// pushToEdge keeps moving the task at the other end of the list to place,
+// top or bottom, until one of them gets the priority edge. Pushing to the
+// bottom leaves the top task alone so both edges can be reached.
+func pushToEdge(t *testing.T, s storage.Storage, userID int, want []int, place storage.Placement, edge int) []int {
+	t.Helper()
+
+	// every move gains at most TaskPriorityDelta, then halves the gap
+	limit := (storage.MaxInt/storage.TaskPriorityDelta)*2 + 64
+	for step := 0; step < limit; step++ {
+		var moved int
+		if place == storage.PlaceTop {
+			moved = want[len(want)-1]
+			want = slices.Insert(want[:len(want)-1], 0, moved)
+		} else {
+			moved = want[1]
+			want = append(slices.Delete(slices.Clone(want), 1, 2), moved)
+		}
+
+		task, err := s.UpdateTaskPriority(context.Background(), moved, userID, storage.TaskPosition{Place: place})
+		if err != nil {
+			t.Fatalf("UpdateTaskPriority to %s: %v", place, err)
+		}
+		if task.Priority == edge {
+			return want
+		}
+	}
+
+	t.Fatalf("no task reached priority %d in %d moves to %s", edge, limit, place)
+	return nil
+}
+
+// testTaskMoveExhaustion keeps dropping tasks between the same neighbours
+// and onto the ends of the list, far past what halving the gaps allows.
+func testTaskMoveExhaustion(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	project, want := mustCreateProjectTasks(t, s, userID, 3)
+
+	for step := 0; step < 64; step++ {
+		tasks := checkOrder(t, s, userID, project.ID, want)
+		want = moveTo(t, s, userID, tasks, 2, 1, step%2 == 0)
+	}
+
+	// priorities right at the ends of the range leave no room above or below
+	want = pushToEdge(t, s, userID, want, storage.PlaceTop, storage.MaxInt-1)
+	want = pushToEdge(t, s, userID, want, storage.PlaceBottom, storage.MinInt+1)
+	tasks := checkOrder(t, s, userID, project.ID, want)
+	if tasks[0].Priority != storage.MaxInt-1 || tasks[len(tasks)-1].Priority != storage.MinInt+1 {
+		t.Fatalf("ends of the list have priorities %d and %d", tasks[0].Priority, tasks[len(tasks)-1].Priority)
+	}
+
+	top, err := s.CreateTask(ctx, &storage.Task{Title: "on top", UserID: userID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	want = slices.Insert(want, 0, top.ID)
+
+	for step := 0; step < 64; step++ {
+		tasks := checkOrder(t, s, userID, project.ID, want)
+		if step%2 == 0 {
+			want = moveTo(t, s, userID, tasks, len(tasks)-1, 0, step%4 == 0)
+		} else {
+			want = moveTo(t, s, userID, tasks, 0, len(tasks)-1, false)
+		}
+	}
+	checkOrder(t, s, userID, project.ID, want)
+
+	done := mustSetStatus(t, s, mustCreateTask(t, s, userID, "done"), storage.TaskStatusClosed)
+	if _, err := s.UpdateTaskPriority(ctx, done.ID, userID, storage.TaskPosition{Place: storage.PlaceTop}); !errors.Is(err, storage.ErrTaskDone) {
+		t.Fatalf("UpdateTaskPriority of a done task returned %v, want ErrTaskDone", err)
+	}
+}
+
+// testConcurrentTaskMoves reorders and adds tasks of one project from
+// several goroutines at once, the way two open tabs do, and checks that
+// every call succeeds and the project ends up strictly ordered.
+func testConcurrentTaskMoves(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	project, initial := mustCreateProjectTasks(t, s, userID, 6)
+	empty := mustCreateProject(t, s, userID, uniqueName(t))
+
+	const workers, steps = 4, 8
+
+	var wg sync.WaitGroup
+	for worker := range workers {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewPCG(uint64(worker), 1))
+			for range steps {
+				taskID := initial[rnd.IntN(len(initial))]
+				position := storage.TaskPosition{Place: storage.PlaceTop}
+				switch anchorID := initial[rnd.IntN(len(initial))]; {
+				case anchorID != taskID && rnd.IntN(2) == 0:
+					position = storage.TaskPosition{Place: storage.PlaceBefore, AnchorID: anchorID}
+				case rnd.IntN(2) == 0:
+					position = storage.TaskPosition{Place: storage.PlaceBottom}
+				}
+				if _, err := s.UpdateTaskPriority(ctx, taskID, userID, position); err != nil {
+					t.Errorf("UpdateTaskPriority of task %d to %+v: %v", taskID, position, err)
+					return
+				}
+			}
+		}()
+
+		// one goroutine adds to the reordered project, another one to a
+		// project that starts without tasks
+		for _, projectID := range []int{project.ID, empty.ID} {
+			go func() {
+				defer wg.Done()
+				for range steps {
+					if _, err := s.CreateTask(ctx, &storage.Task{Title: "concurrent", UserID: userID, ProjectID: projectID}); err != nil {
+						t.Errorf("CreateTask in project %d: %v", projectID, err)
+						return
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for _, projectID := range []int{project.ID, empty.ID} {
+		tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{ProjectID: projectID}, 1000)
+		if err != nil {
+			t.Fatalf("ListTasks: %v", err)
+		}
+		want := workers * steps
+		if projectID == project.ID {
+			want += len(initial)
+		}
+		if len(tasks) != want {
+			t.Fatalf("project %d holds %d tasks, want %d", projectID, len(tasks), want)
+		}
+		checkOrder(t, s, userID, projectID, taskIDs(tasks))
+	}
+}
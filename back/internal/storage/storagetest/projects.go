@@ -0,0 +1,187 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+func mustCreateProject(t *testing.T, s storage.Storage, userID int, name string) *storage.Project {
+	t.Helper()
+
+	project, err := s.CreateProject(context.Background(), &storage.Project{UserID: userID, Name: name})
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	return project
+}
+
+func mustGetInbox(t *testing.T, s storage.Storage, userID int) storage.Project {
+	t.Helper()
+
+	projects, err := s.GetProjects(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	if len(projects) == 0 || !projects[0].IsInbox {
+		t.Fatalf("GetProjects returned %+v, want the inbox first", projects)
+	}
+	return projects[0]
+}
+
+func testInboxCreatedWithUser(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+
+	inbox := mustGetInbox(t, s, userID)
+	if inbox.Name != storage.InboxProjectName || inbox.UserID != userID {
+		t.Fatalf("inbox is %+v", inbox)
+	}
+
+	task := mustCreateTask(t, s, userID, "lands in inbox")
+	if task.ProjectID != inbox.ID {
+		t.Fatalf("task created without a project went to project %d, want inbox %d", task.ProjectID, inbox.ID)
+	}
+
+	if err := s.DeleteProject(context.Background(), inbox.ID, userID); err == nil {
+		t.Fatal("DeleteProject deleted the inbox")
+	}
+}
+
+func testProjectsCRUD(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	work := mustCreateProject(t, s, userID, "work")
+	mustCreateProject(t, s, userID, "home")
+	if _, err := s.CreateProject(ctx, &storage.Project{UserID: userID, Name: "work"}); err == nil {
+		t.Fatal("CreateProject created a duplicate project name")
+	}
+
+	renamed, err := s.UpdateProject(ctx, &storage.Project{ID: work.ID, UserID: userID, Name: "office"})
+	if err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if renamed.Name != "office" || renamed.IsInbox {
+		t.Fatalf("UpdateProject returned %+v", renamed)
+	}
+	if _, err := s.UpdateProject(ctx, &storage.Project{ID: work.ID, UserID: mustCreateUser(t, s), Name: "stolen"}); err == nil {
+		t.Fatal("UpdateProject renamed a project owned by another user")
+	}
+
+	projects, err := s.GetProjects(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+	if !slices.Equal(names, []string{storage.InboxProjectName, "home", "office"}) {
+		t.Fatalf("GetProjects returned %v", names)
+	}
+}
+
+func testProjectPriorities(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	project := mustCreateProject(t, s, userID, "scoped")
+
+	mustCreateTask(t, s, userID, "inbox 1")
+	mustCreateTask(t, s, userID, "inbox 2")
+
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "project 1", UserID: userID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if task.ProjectID != project.ID || task.Priority != storage.TaskPriorityDelta {
+		t.Fatalf("first task of a project got project %d priority %d, want %d %d", task.ProjectID, task.Priority, project.ID, storage.TaskPriorityDelta)
+	}
+
+	if _, err := s.CreateTask(ctx, &storage.Task{Title: "foreign", UserID: mustCreateUser(t, s), ProjectID: project.ID}); err == nil {
+		t.Fatal("CreateTask put a task into another user's project")
+	}
+
+	tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{ProjectID: project.ID}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if titles := taskTitles(tasks); !slices.Equal(titles, []string{"project 1"}) {
+		t.Fatalf("ListTasks(project) returned %v", titles)
+	}
+}
+
+func testMoveTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	inbox := mustGetInbox(t, s, userID)
+	project := mustCreateProject(t, s, userID, "target")
+
+	existing, err := s.CreateTask(ctx, &storage.Task{Title: "already there", UserID: userID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	task := mustCreateTask(t, s, userID, "moving")
+
+	moved, err := s.MoveTask(ctx, task.ID, project.ID, userID)
+	if err != nil {
+		t.Fatalf("MoveTask: %v", err)
+	}
+	if moved.ProjectID != project.ID || moved.Priority <= existing.Priority {
+		t.Fatalf("MoveTask returned project %d priority %d, want project %d above %d", moved.ProjectID, moved.Priority, project.ID, existing.Priority)
+	}
+
+	if _, err := s.MoveTask(ctx, task.ID, mustCreateProject(t, s, mustCreateUser(t, s), "foreign").ID, userID); err == nil {
+		t.Fatal("MoveTask moved a task into another user's project")
+	}
+
+	history, err := s.GetTaskHistory(ctx, task.ID, userID, 1, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if history[0].ActionType != storage.MoveTaskType || history[0].Before.ProjectID != inbox.ID {
+		t.Fatalf("move was logged as %+v", history[0])
+	}
+
+	back, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if back.ProjectID != inbox.ID || back.Priority != task.Priority {
+		t.Fatalf("UndoTask of a move returned project %d priority %d, want %d %d", back.ProjectID, back.Priority, inbox.ID, task.Priority)
+	}
+}
+
+func testDeleteProjectMovesTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	inbox := mustGetInbox(t, s, userID)
+	project := mustCreateProject(t, s, userID, "doomed")
+
+	mustCreateTask(t, s, userID, "inbox task")
+	for _, title := range []string{"low", "high"} {
+		if _, err := s.CreateTask(ctx, &storage.Task{Title: title, UserID: userID, ProjectID: project.ID}); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+	}
+
+	if err := s.DeleteProject(ctx, project.ID, userID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+
+	tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{ProjectID: inbox.ID}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if titles := taskTitles(tasks); !slices.Equal(titles, []string{"high", "low", "inbox task"}) {
+		t.Fatalf("inbox after DeleteProject is %v, want [high low inbox task]", titles)
+	}
+
+	projects, err := s.GetProjects(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("GetProjects returned %d projects after delete, want 1", len(projects))
+	}
+}
@@ -0,0 +1,145 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// closeAndFindNext closes a task and returns the open tasks sharing its title.
+func closeAndFindNext(t *testing.T, s storage.Storage, task *storage.Task) []storage.Task {
+	t.Helper()
+	ctx := context.Background()
+
+	task.Status = storage.TaskStatusClosed
+	if _, err := s.UpdateTask(ctx, task, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+
+	tasks, err := s.GetTasks(ctx, task.UserID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	return slices.DeleteFunc(tasks, func(other storage.Task) bool {
+		return other.Title != task.Title || other.Status == storage.TaskStatusClosed
+	})
+}
+
+func testRecurringTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	tag := mustCreateTag(t, s, userID, "bills")
+
+	due := time.Date(2030, time.January, 31, 9, 0, 0, 0, time.UTC)
+	start := due.Add(-24 * time.Hour)
+	task, err := s.CreateTask(ctx, &storage.Task{
+		Title: "pay rent", UserID: userID, StartTs: &start, DueTs: &due, Recurrence: "FREQ=MONTHLY;COUNT=3",
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	task = mustAttachTag(t, s, task.ID, tag.ID, userID)
+
+	next := closeAndFindNext(t, s, task)
+	if len(next) != 1 {
+		t.Fatalf("closing a recurring task left %d open occurrences, want 1", len(next))
+	}
+	second := next[0]
+	wantDue := time.Date(2030, time.March, 31, 9, 0, 0, 0, time.UTC)
+	if second.DueTs == nil || !second.DueTs.Equal(wantDue) {
+		t.Fatalf("next occurrence is due %v, want %v", second.DueTs, wantDue)
+	}
+	if second.StartTs == nil || !second.StartTs.Equal(wantDue.Add(-24*time.Hour)) {
+		t.Fatalf("next occurrence starts %v, want a day before it is due", second.StartTs)
+	}
+	if second.Recurrence != "FREQ=MONTHLY;COUNT=2" || second.ProjectID != task.ProjectID || !slices.Equal(second.TagIDs, []int{tag.ID}) {
+		t.Fatalf("next occurrence is %+v", second)
+	}
+
+	history, err := s.GetTaskHistory(ctx, second.ID, userID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].ActionType != storage.CreateTaskType {
+		t.Fatalf("next occurrence has history %+v, want a single create", history)
+	}
+
+	third := closeAndFindNext(t, s, &second)
+	if len(third) != 1 || third[0].Recurrence != "FREQ=MONTHLY;COUNT=1" {
+		t.Fatalf("second close left %+v", third)
+	}
+	if rest := closeAndFindNext(t, s, &third[0]); len(rest) != 0 {
+		t.Fatalf("exhausted rule still spawned %+v", rest)
+	}
+}
+
+func testRecurrenceFollowsTimeZone(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	if err := s.UpdateUserTimeZone(ctx, userID, "America/New_York"); err != nil {
+		t.Fatalf("UpdateUserTimeZone: %v", err)
+	}
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	// a Friday morning, daylight saving time starts on the following Sunday
+	due := time.Date(2030, time.March, 8, 9, 0, 0, 0, loc)
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "standup", UserID: userID, DueTs: &due, Recurrence: "FREQ=WEEKLY;BYDAY=MO,FR"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	next := closeAndFindNext(t, s, task)
+	wantDue := time.Date(2030, time.March, 11, 9, 0, 0, 0, loc)
+	if len(next) != 1 || next[0].DueTs == nil || !next[0].DueTs.Equal(wantDue) {
+		t.Fatalf("next occurrence is %+v, want due %v", next, wantDue)
+	}
+
+	until := mustCreateDueTask(t, s, userID, "last call", due)
+	until.Recurrence = "FREQ=DAILY;UNTIL=20300308"
+	if _, err := s.UpdateTask(ctx, until, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if rest := closeAndFindNext(t, s, until); len(rest) != 0 {
+		t.Fatalf("rule past its UNTIL still spawned %+v", rest)
+	}
+}
+
+// testRecurringTaskClosedAgain closes an instance again after reopening it
+// and after undoing its close, the series has moved on to the occurrence
+// spawned the first time.
+func testRecurringTaskClosedAgain(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	due := time.Date(2030, time.May, 6, 9, 0, 0, 0, time.UTC)
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "water plants", UserID: userID, DueTs: &due, Recurrence: "FREQ=WEEKLY"})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	next := closeAndFindNext(t, s, task)
+	if len(next) != 1 {
+		t.Fatalf("closing a recurring task left %d open occurrences, want 1", len(next))
+	}
+
+	reopened := mustSetStatus(t, s, task, storage.TaskStatusOpened)
+	if rest := closeAndFindNext(t, s, reopened); len(rest) != 1 || rest[0].ID != next[0].ID {
+		t.Fatalf("closing a reopened instance left open occurrences %v, want only %d", taskIDs(rest), next[0].ID)
+	}
+
+	undone, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if undone.Status != storage.TaskStatusOpened {
+		t.Fatalf("UndoTask of a close left status %d", undone.Status)
+	}
+	if rest := closeAndFindNext(t, s, undone); len(rest) != 1 || rest[0].ID != next[0].ID {
+		t.Fatalf("closing an instance again after an undo left open occurrences %v, want only %d", taskIDs(rest), next[0].ID)
+	}
+}
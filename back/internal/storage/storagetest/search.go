@@ -0,0 +1,120 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+func mustSearch(t *testing.T, s storage.Storage, userID int, query string) []storage.TaskMatch {
+	t.Helper()
+
+	matches, err := s.SearchTasks(context.Background(), userID, query, 100)
+	if err != nil {
+		t.Fatalf("SearchTasks %q: %v", query, err)
+	}
+	return matches
+}
+
+func matchIDs(matches []storage.TaskMatch) []int {
+	ids := []int{}
+	for _, match := range matches {
+		ids = append(ids, match.Task.ID)
+	}
+	return ids
+}
+
+func mustCreateDescribedTask(t *testing.T, s storage.Storage, userID int, title, description string) *storage.Task {
+	t.Helper()
+
+	task, err := s.CreateTask(context.Background(), &storage.Task{Title: title, Description: description, UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task
+}
+
+func testSearchTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	milk := mustCreateDescribedTask(t, s, userID, "Buy milk", "at the corner shop")
+	reports := mustCreateDescribedTask(t, s, userID, "Write reports", "quarterly milk sales")
+	russian := mustCreateDescribedTask(t, s, userID, "Купить молоко", "в магазине у дома")
+	mustCreateDescribedTask(t, s, mustCreateUser(t, s), "Buy milk", "someone else's")
+	archived := mustCreateDescribedTask(t, s, userID, "Spilled milk", "")
+	if _, err := s.ArchiveTask(ctx, archived.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	// the title match ranks above the description one
+	matches := mustSearch(t, s, userID, "milk")
+	if got := matchIDs(matches); !slices.Equal(got, []int{milk.ID, reports.ID}) {
+		t.Fatalf("SearchTasks milk returned tasks %v, want %v", got, []int{milk.ID, reports.ID})
+	}
+	if matches[0].Rank <= matches[1].Rank {
+		t.Fatalf("title match has rank %v, not above %v", matches[0].Rank, matches[1].Rank)
+	}
+	if !strings.Contains(matches[0].TitleSnippet, storage.HighlightStart+"milk"+storage.HighlightStop) {
+		t.Fatalf("title snippet %q does not highlight milk", matches[0].TitleSnippet)
+	}
+	if !strings.Contains(matches[1].DescriptionSnippet, storage.HighlightStart+"milk"+storage.HighlightStop) {
+		t.Fatalf("description snippet %q does not highlight milk", matches[1].DescriptionSnippet)
+	}
+
+	if got := matchIDs(mustSearch(t, s, userID, "report")); !slices.Equal(got, []int{reports.ID}) {
+		t.Fatalf("SearchTasks report returned tasks %v, want %v", got, []int{reports.ID})
+	}
+	if got := matchIDs(mustSearch(t, s, userID, "milk sales")); !slices.Equal(got, []int{reports.ID}) {
+		t.Fatalf("SearchTasks with two words returned tasks %v, want %v", got, []int{reports.ID})
+	}
+	if got := matchIDs(mustSearch(t, s, userID, "молоко")); !slices.Equal(got, []int{russian.ID}) {
+		t.Fatalf("SearchTasks in russian returned tasks %v, want %v", got, []int{russian.ID})
+	}
+	if got := mustSearch(t, s, userID, " ?! "); len(got) != 0 {
+		t.Fatalf("SearchTasks without words returned %+v", got)
+	}
+
+	// the index follows updates and deletes
+	update := *milk
+	update.Title = "Buy bread"
+	if _, err := s.UpdateTask(ctx, &update, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if got := matchIDs(mustSearch(t, s, userID, "bread")); !slices.Equal(got, []int{milk.ID}) {
+		t.Fatalf("SearchTasks after rename returned tasks %v, want %v", got, []int{milk.ID})
+	}
+	if err := s.DeleteTask(ctx, reports.ID, userID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if got := mustSearch(t, s, userID, "milk"); len(got) != 0 {
+		t.Fatalf("SearchTasks after rename and delete returned %v", matchIDs(got))
+	}
+}
+
+// testSearchSnippetsEscaped checks that snippets are safe to render as
+// html, only the highlights are markup.
+func testSearchSnippetsEscaped(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+	mustCreateDescribedTask(t, s, userID, `<img src=x onerror="alert(1)"> payload`, `Tom & Jerry <script>alert(1)</script> payload`)
+
+	matches := mustSearch(t, s, userID, "payload")
+	if len(matches) != 1 {
+		t.Fatalf("SearchTasks payload returned %d matches, want 1", len(matches))
+	}
+
+	for _, snippet := range []string{matches[0].TitleSnippet, matches[0].DescriptionSnippet} {
+		markup := strings.ReplaceAll(strings.ReplaceAll(snippet, storage.HighlightStart, ""), storage.HighlightStop, "")
+		if strings.ContainsAny(markup, `<>"`) {
+			t.Errorf("snippet %q holds markup besides the highlights", snippet)
+		}
+		if !strings.Contains(snippet, storage.HighlightStart+"payload"+storage.HighlightStop) {
+			t.Errorf("snippet %q does not highlight payload", snippet)
+		}
+	}
+	if !strings.Contains(matches[0].TitleSnippet, "&lt;img") {
+		t.Errorf("title snippet %q does not escape the title", matches[0].TitleSnippet)
+	}
+}
@@ -0,0 +1,645 @@
+// Package storagetest contains the behavioural test suite every
+// storage.Storage implementation has to pass.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// Factory returns a ready to use storage. The suite never assumes the
+// storage is empty, so one database may be shared between subtests.
+type Factory func(t *testing.T) storage.Storage
+
+// Run executes the whole conformance suite against the storage returned by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Storage)
+	}{
+		{"CreateUser", testCreateUser},
+		{"CreateUserDuplicate", testCreateUserDuplicate},
+		{"GetUserNotFound", testGetUserNotFound},
+		{"ListUsers", testListUsers},
+		{"UpdateUserPassword", testUpdateUserPassword},
+		{"CreateTask", testCreateTask},
+		{"GetTaskOtherUser", testGetTaskOtherUser},
+		{"GetTasksOrder", testGetTasksOrder},
+		{"GetTasksLimit", testGetTasksLimit},
+		{"UpdateTask", testUpdateTask},
+		{"UpdateTaskClosed", testUpdateTaskClosed},
+		{"UpdateTaskOtherUser", testUpdateTaskOtherUser},
+		{"UpdateTaskPriority", testUpdateTaskPriority},
+		{"ConcurrentUsers", testConcurrentUsers},
+		{"ArchiveRestoreTask", testArchiveRestoreTask},
+		{"GetArchivedTasks", testGetArchivedTasks},
+		{"DeleteTask", testDeleteTask},
+		{"PurgeArchivedTasks", testPurgeArchivedTasks},
+		{"TaskHistory", testTaskHistory},
+		{"TaskHistoryPagination", testTaskHistoryPagination},
+		{"UndoRedoTask", testUndoRedoTask},
+		{"UndoArchive", testUndoArchive},
+		{"UndoTaskOtherUser", testUndoTaskOtherUser},
+		{"UndoPlacesTask", testUndoPlacesTask},
+		{"TaskDates", testTaskDates},
+		{"GetDueTasks", testGetDueTasks},
+		{"UpdateUserTimeZone", testUpdateUserTimeZone},
+		{"TagsCRUD", testTagsCRUD},
+		{"AttachDetachTag", testAttachDetachTag},
+		{"CreateTaskWithTags", testCreateTaskWithTags},
+		{"DeleteTagDetaches", testDeleteTagDetaches},
+		{"ListTasksByTags", testListTasksByTags},
+		{"InboxCreatedWithUser", testInboxCreatedWithUser},
+		{"ProjectsCRUD", testProjectsCRUD},
+		{"ProjectPriorities", testProjectPriorities},
+		{"MoveTask", testMoveTask},
+		{"DeleteProjectMovesTasks", testDeleteProjectMovesTasks},
+		{"CreateSubtask", testCreateSubtask},
+		{"SetTaskParent", testSetTaskParent},
+		{"CloseTaskWithSubtasks", testCloseTaskWithSubtasks},
+		{"ClosedTasksCounted", testClosedTasksCounted},
+		{"DeleteParentUnlinksSubtasks", testDeleteParentUnlinksSubtasks},
+		{"RecurringTask", testRecurringTask},
+		{"RecurrenceFollowsTimeZone", testRecurrenceFollowsTimeZone},
+		{"RecurringTaskClosedAgain", testRecurringTaskClosedAgain},
+		{"DefaultWorkflow", testDefaultWorkflow},
+		{"CancelledTaskIsDone", testCancelledTaskIsDone},
+		{"ProjectWorkflow", testProjectWorkflow},
+		{"BoardColumns", testBoardColumns},
+		{"RandomTaskMoves", testRandomTaskMoves},
+		{"TaskMoveExhaustion", testTaskMoveExhaustion},
+		{"ConcurrentTaskMoves", testConcurrentTaskMoves},
+		{"ListTasksPagination", testListTasksPagination},
+		{"ListTasksFilters", testListTasksFilters},
+		{"SearchTasks", testSearchTasks},
+		{"SearchSnippetsEscaped", testSearchSnippetsEscaped},
+		{"SavedViewsCRUD", testSavedViewsCRUD},
+		{"SavedViewQueries", testSavedViewQueries},
+		{"SavedViewEvaluation", testSavedViewEvaluation},
+		{"NotFoundErrors", testNotFoundErrors},
+		{"AlreadyExistsErrors", testAlreadyExistsErrors},
+		{"ConflictErrors", testConflictErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			tt.fn(t, s)
+		})
+	}
+}
+
+// uniqueName returns a username that does not clash with other runs against
+// the same database.
+func uniqueName(t *testing.T) string {
+	return fmt.Sprintf("%s_%d", t.Name(), time.Now().UnixNano())
+}
+
+func mustCreateUser(t *testing.T, s storage.Storage) int {
+	t.Helper()
+
+	userID, err := s.CreateUser(context.Background(), uniqueName(t), "hashed", "user@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return userID
+}
+
+func mustCreateTask(t *testing.T, s storage.Storage, userID int, title string) *storage.Task {
+	t.Helper()
+
+	task, err := s.CreateTask(context.Background(), &storage.Task{
+		Title:       title,
+		Description: title + " description",
+		UserID:      userID,
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task
+}
+
+func testCreateUser(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	username := uniqueName(t)
+
+	userID, err := s.CreateUser(ctx, username, "hashed", "user@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if userID <= 0 {
+		t.Fatalf("CreateUser returned id %d, want positive", userID)
+	}
+
+	byName, err := s.GetUserByUsername(ctx, username)
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %v", err)
+	}
+	if byName.ID != userID || byName.Password != "hashed" || byName.Email != "user@example.com" {
+		t.Fatalf("GetUserByUsername returned %+v", byName)
+	}
+
+	byID, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if byID.Username != username {
+		t.Fatalf("GetUserByID returned username %q, want %q", byID.Username, username)
+	}
+	if byID.CreationTs.IsZero() {
+		t.Fatal("GetUserByID returned zero creation_ts")
+	}
+}
+
+func testCreateUserDuplicate(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	username := uniqueName(t)
+
+	if _, err := s.CreateUser(ctx, username, "hashed", ""); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	userID, err := s.CreateUser(ctx, username, "other", "")
+	if err == nil {
+		t.Fatal("CreateUser succeeded for a taken username")
+	}
+	if userID < 0 {
+		t.Fatalf("CreateUser returned %d for a taken username, want non-negative", userID)
+	}
+}
+
+func testGetUserNotFound(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	if _, err := s.GetUserByUsername(ctx, uniqueName(t)); err == nil {
+		t.Fatal("GetUserByUsername succeeded for a missing user")
+	}
+	if _, err := s.GetUserByID(ctx, -1); err == nil {
+		t.Fatal("GetUserByID succeeded for a missing user")
+	}
+}
+
+func testListUsers(t *testing.T, s storage.Storage) {
+	first := mustCreateUser(t, s)
+	second := mustCreateUser(t, s)
+
+	users, err := s.ListUsers(context.Background())
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+
+	found := 0
+	for i, user := range users {
+		if i > 0 && users[i-1].ID >= user.ID {
+			t.Fatalf("ListUsers is not ordered by id: %d before %d", users[i-1].ID, user.ID)
+		}
+		if user.ID == first || user.ID == second {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("ListUsers returned %d of 2 created users", found)
+	}
+}
+
+func testUpdateUserPassword(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	if err := s.UpdateUserPassword(ctx, userID, "rehashed"); err != nil {
+		t.Fatalf("UpdateUserPassword: %v", err)
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.Password != "rehashed" {
+		t.Fatalf("password is %q, want %q", user.Password, "rehashed")
+	}
+
+	if err := s.UpdateUserPassword(ctx, -1, "rehashed"); err == nil {
+		t.Fatal("UpdateUserPassword succeeded for a missing user")
+	}
+}
+
+func testCreateTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	task := mustCreateTask(t, s, userID, "first")
+	if task.ID <= 0 || task.UserID != userID || task.Title != "first" || task.Description != "first description" {
+		t.Fatalf("CreateTask returned %+v", task)
+	}
+	if task.Status != storage.TaskStatusOpened {
+		t.Fatalf("CreateTask returned status %d, want %d", task.Status, storage.TaskStatusOpened)
+	}
+	if task.Priority != storage.TaskPriorityDelta {
+		t.Fatalf("first task got priority %d, want %d", task.Priority, storage.TaskPriorityDelta)
+	}
+
+	second := mustCreateTask(t, s, userID, "second")
+	if second.Priority != task.Priority+storage.TaskPriorityDelta {
+		t.Fatalf("second task got priority %d, want %d", second.Priority, task.Priority+storage.TaskPriorityDelta)
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.ID != task.ID || got.Title != task.Title || got.Description != task.Description ||
+		got.Status != task.Status || got.Priority != task.Priority || got.UserID != userID {
+		t.Fatalf("GetTask returned %+v, want %+v", got, task)
+	}
+}
+
+func testGetTaskOtherUser(t *testing.T, s storage.Storage) {
+	owner := mustCreateUser(t, s)
+	other := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, owner, "private")
+
+	if _, err := s.GetTask(context.Background(), task.ID, other); err == nil {
+		t.Fatal("GetTask returned a task owned by another user")
+	}
+}
+
+func testGetTasksOrder(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+	otherID := mustCreateUser(t, s)
+
+	first := mustCreateTask(t, s, userID, "first")
+	second := mustCreateTask(t, s, userID, "second")
+	third := mustCreateTask(t, s, userID, "third")
+	mustCreateTask(t, s, otherID, "foreign")
+
+	tasks, err := s.GetTasks(context.Background(), userID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+
+	want := []int{third.ID, second.ID, first.ID}
+	if len(tasks) != len(want) {
+		t.Fatalf("GetTasks returned %d tasks, want %d", len(tasks), len(want))
+	}
+	for i, task := range tasks {
+		if task.ID != want[i] {
+			t.Fatalf("GetTasks[%d] is task %d, want %d", i, task.ID, want[i])
+		}
+		if task.UserID != userID {
+			t.Fatalf("GetTasks[%d] has user %d, want %d", i, task.UserID, userID)
+		}
+	}
+}
+
+func testGetTasksLimit(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+
+	mustCreateTask(t, s, userID, "first")
+	second := mustCreateTask(t, s, userID, "second")
+
+	tasks, err := s.GetTasks(context.Background(), userID, 1)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != second.ID {
+		t.Fatalf("GetTasks with limit 1 returned %+v", tasks)
+	}
+
+	empty, err := s.GetTasks(context.Background(), mustCreateUser(t, s), storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	if empty == nil || len(empty) != 0 {
+		t.Fatalf("GetTasks for a user without tasks returned %#v, want empty slice", empty)
+	}
+}
+
+func testUpdateTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "before")
+
+	updated, err := s.UpdateTask(ctx, &storage.Task{
+		ID:          task.ID,
+		UserID:      userID,
+		Title:       "after",
+		Description: "new description",
+		Status:      storage.TaskStatusOpened,
+		// priorities only change through UpdateTaskPriority
+		Priority: storage.MaxInt,
+	}, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if updated.Title != "after" || updated.Description != "new description" || updated.Priority != task.Priority {
+		t.Fatalf("UpdateTask returned %+v", updated)
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Title != "after" || got.Description != "new description" {
+		t.Fatalf("GetTask after update returned %+v", got)
+	}
+}
+
+func testUpdateTaskClosed(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "to close")
+
+	task.Status = storage.TaskStatusClosed
+	updated, err := s.UpdateTask(context.Background(), task, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if updated.Status != storage.TaskStatusClosed || updated.Priority != storage.TaskPriorityClosed {
+		t.Fatalf("closed task has status %d and priority %d", updated.Status, updated.Priority)
+	}
+
+	// a reopened task goes back on top of the open ones
+	other := mustCreateTask(t, s, userID, "still open")
+	updated.Status = storage.TaskStatusOpened
+	reopened, err := s.UpdateTask(context.Background(), updated, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if reopened.Priority <= other.Priority || reopened.Priority == storage.MaxInt {
+		t.Fatalf("reopened task has priority %d, want above %d", reopened.Priority, other.Priority)
+	}
+}
+
+func testUpdateTaskOtherUser(t *testing.T, s storage.Storage) {
+	owner := mustCreateUser(t, s)
+	other := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, owner, "private")
+
+	_, err := s.UpdateTask(context.Background(), &storage.Task{
+		ID:     task.ID,
+		UserID: other,
+		Title:  "hijacked",
+		Status: storage.TaskStatusOpened,
+	}, storage.ClosePolicyIgnore)
+	if err == nil {
+		t.Fatal("UpdateTask changed a task owned by another user")
+	}
+
+	got, err := s.GetTask(context.Background(), task.ID, owner)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Title != "private" {
+		t.Fatalf("task title changed to %q", got.Title)
+	}
+}
+
+func testUpdateTaskPriority(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	first := mustCreateTask(t, s, userID, "first")
+	second := mustCreateTask(t, s, userID, "second")
+
+	updated, err := s.UpdateTaskPriority(ctx, first.ID, userID, storage.TaskPosition{Place: storage.PlaceBefore, AnchorID: second.ID})
+	if err != nil {
+		t.Fatalf("UpdateTaskPriority: %v", err)
+	}
+	if updated.Priority <= second.Priority {
+		t.Fatalf("UpdateTaskPriority returned priority %d, want above %d", updated.Priority, second.Priority)
+	}
+
+	tasks, err := s.GetTasks(ctx, userID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != first.ID {
+		t.Fatalf("moved task is not first: %+v", tasks)
+	}
+
+	if _, err := s.UpdateTaskPriority(ctx, first.ID, mustCreateUser(t, s), storage.TaskPosition{Place: storage.PlaceTop}); err == nil {
+		t.Fatal("UpdateTaskPriority changed a task owned by another user")
+	}
+
+	otherUserID := mustCreateUser(t, s)
+	foreign := mustCreateTask(t, s, otherUserID, "foreign")
+	for _, anchorID := range []int{foreign.ID, first.ID, 0} {
+		position := storage.TaskPosition{Place: storage.PlaceAfter, AnchorID: anchorID}
+		if _, err := s.UpdateTaskPriority(ctx, first.ID, userID, position); !errors.Is(err, storage.ErrInvalidAnchor) {
+			t.Fatalf("UpdateTaskPriority after task %d returned %v, want ErrInvalidAnchor", anchorID, err)
+		}
+	}
+}
+
+// testConcurrentUsers has several users sign up and work on their tasks at
+// the same time while their lists are read, meant to be run with -race.
+func testConcurrentUsers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	const users, steps = 4, 10
+
+	userIDs := make([]int, users)
+	var wg sync.WaitGroup
+	for user := range users {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			userID, err := s.CreateUser(ctx, fmt.Sprintf("%s_%d", uniqueName(t), user), "hashed", "user@example.com")
+			if err != nil {
+				t.Errorf("CreateUser: %v", err)
+				return
+			}
+			userIDs[user] = userID
+
+			for step := range steps {
+				task, err := s.CreateTask(ctx, &storage.Task{Title: fmt.Sprintf("task %d", step), UserID: userID})
+				if err != nil {
+					t.Errorf("CreateTask: %v", err)
+					return
+				}
+				task.Title = fmt.Sprintf("updated %d", step)
+				if _, err := s.UpdateTask(ctx, task, storage.ClosePolicyIgnore); err != nil {
+					t.Errorf("UpdateTask of task %d: %v", task.ID, err)
+					return
+				}
+				if _, err := s.UpdateTaskPriority(ctx, task.ID, userID, storage.TaskPosition{Place: storage.PlaceBottom}); err != nil {
+					t.Errorf("UpdateTaskPriority of task %d: %v", task.ID, err)
+					return
+				}
+				if _, err := s.GetTask(ctx, task.ID, userID); err != nil {
+					t.Errorf("GetTask of task %d: %v", task.ID, err)
+					return
+				}
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for range steps {
+				if _, err := s.ListUsers(ctx); err != nil {
+					t.Errorf("ListUsers: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	for _, userID := range userIDs {
+		tasks, err := s.GetTasks(ctx, userID, storage.MaxInt)
+		if err != nil {
+			t.Fatalf("GetTasks: %v", err)
+		}
+		if len(tasks) != steps {
+			t.Fatalf("user %d has %d tasks, want %d", userID, len(tasks), steps)
+		}
+		for i, task := range tasks {
+			if task.UserID != userID {
+				t.Fatalf("user %d lists task %d of user %d", userID, task.ID, task.UserID)
+			}
+			// each task was moved to the bottom right after it was created
+			if want := fmt.Sprintf("updated %d", i); task.Title != want {
+				t.Errorf("task %d of user %d is titled %q, want %q", i, userID, task.Title, want)
+			}
+		}
+	}
+}
+
+func testArchiveRestoreTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "archived")
+
+	archived, err := s.ArchiveTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+	if archived.ArchivedTs == nil {
+		t.Fatal("ArchiveTask returned a task without archived_ts")
+	}
+
+	if _, err := s.ArchiveTask(ctx, task.ID, userID); err == nil {
+		t.Fatal("ArchiveTask archived a task twice")
+	}
+	if _, err := s.UpdateTask(ctx, task, storage.ClosePolicyIgnore); err == nil {
+		t.Fatal("UpdateTask changed an archived task")
+	}
+
+	tasks, err := s.GetTasks(ctx, userID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetTasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("GetTasks returned archived tasks: %+v", tasks)
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.ArchivedTs == nil {
+		t.Fatal("GetTask returned an archived task without archived_ts")
+	}
+
+	restored, err := s.RestoreTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("RestoreTask: %v", err)
+	}
+	if restored.ArchivedTs != nil || restored.Priority != task.Priority {
+		t.Fatalf("RestoreTask returned %+v", restored)
+	}
+
+	if _, err := s.RestoreTask(ctx, task.ID, userID); err == nil {
+		t.Fatal("RestoreTask restored an active task")
+	}
+	if _, err := s.ArchiveTask(ctx, task.ID, mustCreateUser(t, s)); err == nil {
+		t.Fatal("ArchiveTask archived a task owned by another user")
+	}
+}
+
+func testGetArchivedTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	first := mustCreateTask(t, s, userID, "first")
+	second := mustCreateTask(t, s, userID, "second")
+	active := mustCreateTask(t, s, userID, "active")
+
+	for _, task := range []*storage.Task{first, second} {
+		if _, err := s.ArchiveTask(ctx, task.ID, userID); err != nil {
+			t.Fatalf("ArchiveTask: %v", err)
+		}
+	}
+
+	archived, err := s.GetArchivedTasks(ctx, userID, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("GetArchivedTasks: %v", err)
+	}
+	if len(archived) != 2 {
+		t.Fatalf("GetArchivedTasks returned %d tasks, want 2", len(archived))
+	}
+	for _, task := range archived {
+		if task.ID == active.ID {
+			t.Fatal("GetArchivedTasks returned an active task")
+		}
+	}
+}
+
+func testDeleteTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "deleted")
+
+	if err := s.DeleteTask(ctx, task.ID, mustCreateUser(t, s)); err == nil {
+		t.Fatal("DeleteTask deleted a task owned by another user")
+	}
+	if err := s.DeleteTask(ctx, task.ID, userID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if _, err := s.GetTask(ctx, task.ID, userID); err == nil {
+		t.Fatal("GetTask returned a deleted task")
+	}
+	if err := s.DeleteTask(ctx, task.ID, userID); err == nil {
+		t.Fatal("DeleteTask deleted a task twice")
+	}
+}
+
+func testPurgeArchivedTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	archived := mustCreateTask(t, s, userID, "archived")
+	active := mustCreateTask(t, s, userID, "active")
+	if _, err := s.ArchiveTask(ctx, archived.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	if _, err := s.PurgeArchivedTasks(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("PurgeArchivedTasks: %v", err)
+	}
+	if _, err := s.GetTask(ctx, archived.ID, userID); err != nil {
+		t.Fatalf("task archived within retention was purged: %v", err)
+	}
+
+	purged, err := s.PurgeArchivedTasks(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeArchivedTasks: %v", err)
+	}
+	if purged < 1 {
+		t.Fatalf("PurgeArchivedTasks purged %d tasks, want at least 1", purged)
+	}
+	if _, err := s.GetTask(ctx, archived.ID, userID); err == nil {
+		t.Fatal("archived task survived the purge")
+	}
+	if _, err := s.GetTask(ctx, active.ID, userID); err != nil {
+		t.Fatalf("active task was purged: %v", err)
+	}
+}
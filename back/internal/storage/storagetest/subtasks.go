@@ -0,0 +1,262 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"todo_list_service/internal/metrics"
+	"todo_list_service/internal/storage"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func mustCreateSubtask(t *testing.T, s storage.Storage, userID, parentID int, title string) *storage.Task {
+	t.Helper()
+
+	task, err := s.CreateTask(context.Background(), &storage.Task{Title: title, UserID: userID, ParentID: &parentID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task
+}
+
+func testCreateSubtask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	parent := mustCreateTask(t, s, userID, "parent")
+
+	first := mustCreateSubtask(t, s, userID, parent.ID, "first step")
+	mustCreateSubtask(t, s, userID, parent.ID, "second step")
+	if first.ParentTaskID() != parent.ID {
+		t.Fatalf("subtask has parent %v, want %d", first.ParentID, parent.ID)
+	}
+
+	got, err := s.GetTask(ctx, first.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.ParentTaskID() != parent.ID {
+		t.Fatalf("GetTask returned parent %v, want %d", got.ParentID, parent.ID)
+	}
+
+	subtasks, err := s.GetSubtasks(ctx, parent.ID, userID)
+	if err != nil {
+		t.Fatalf("GetSubtasks: %v", err)
+	}
+	if titles := taskTitles(subtasks); !slices.Equal(titles, []string{"second step", "first step"}) {
+		t.Fatalf("GetSubtasks returned %v, want [second step first step]", titles)
+	}
+	if _, err := s.GetSubtasks(ctx, parent.ID, mustCreateUser(t, s)); err == nil {
+		t.Fatal("GetSubtasks listed subtasks of another user's task")
+	}
+
+	if _, err := s.CreateTask(ctx, &storage.Task{Title: "foreign", UserID: mustCreateUser(t, s), ParentID: &parent.ID}); err == nil {
+		t.Fatal("CreateTask nested a task under another user's task")
+	}
+
+	if _, err := s.ArchiveTask(ctx, parent.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+	if _, err := s.CreateTask(ctx, &storage.Task{Title: "late", UserID: userID, ParentID: &parent.ID}); err == nil {
+		t.Fatal("CreateTask nested a task under an archived task")
+	}
+}
+
+func testSetTaskParent(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	root := mustCreateTask(t, s, userID, "root")
+	child := mustCreateSubtask(t, s, userID, root.ID, "child")
+	grandchild := mustCreateSubtask(t, s, userID, child.ID, "grandchild")
+
+	for _, parentID := range []int{root.ID, child.ID, grandchild.ID} {
+		if _, err := s.SetTaskParent(ctx, root.ID, parentID, userID); !errors.Is(err, storage.ErrTaskCycle) {
+			t.Fatalf("SetTaskParent(root under %d) returned %v, want ErrTaskCycle", parentID, err)
+		}
+	}
+
+	moved, err := s.SetTaskParent(ctx, grandchild.ID, root.ID, userID)
+	if err != nil {
+		t.Fatalf("SetTaskParent: %v", err)
+	}
+	if moved.ParentTaskID() != root.ID {
+		t.Fatalf("SetTaskParent left parent %v, want %d", moved.ParentID, root.ID)
+	}
+
+	history, err := s.GetTaskHistory(ctx, grandchild.ID, userID, 1, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if history[0].ActionType != storage.SetTaskParentType || !slices.Equal(history[0].ChangedFields, []string{"parent_id"}) {
+		t.Fatalf("SetTaskParent was logged as %+v", history[0])
+	}
+
+	detached, err := s.SetTaskParent(ctx, grandchild.ID, 0, userID)
+	if err != nil {
+		t.Fatalf("SetTaskParent: %v", err)
+	}
+	if detached.ParentID != nil {
+		t.Fatalf("SetTaskParent(0) left parent %v", *detached.ParentID)
+	}
+
+	restored, err := s.UndoTask(ctx, grandchild.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if restored.ParentTaskID() != root.ID {
+		t.Fatalf("UndoTask restored parent %v, want %d", restored.ParentID, root.ID)
+	}
+
+	if _, err := s.SetTaskParent(ctx, grandchild.ID, mustCreateTask(t, s, mustCreateUser(t, s), "foreign").ID, userID); err == nil {
+		t.Fatal("SetTaskParent nested a task under another user's task")
+	}
+}
+
+func testCloseTaskWithSubtasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	closeTask := func(task *storage.Task, policy storage.ClosePolicy) (*storage.Task, error) {
+		task.Status = storage.TaskStatusClosed
+		return s.UpdateTask(ctx, task, policy)
+	}
+	status := func(taskID int) int8 {
+		task, err := s.GetTask(ctx, taskID, userID)
+		if err != nil {
+			t.Fatalf("GetTask: %v", err)
+		}
+		return task.Status
+	}
+
+	blocked := mustCreateTask(t, s, userID, "blocked")
+	blockedChild := mustCreateSubtask(t, s, userID, blocked.ID, "open step")
+	if _, err := closeTask(blocked, storage.ClosePolicyBlock); !errors.Is(err, storage.ErrOpenSubtasks) {
+		t.Fatalf("UpdateTask(block) returned %v, want ErrOpenSubtasks", err)
+	}
+	if status(blocked.ID) != storage.TaskStatusOpened {
+		t.Fatal("UpdateTask(block) closed a task with open subtasks")
+	}
+	if _, err := closeTask(blockedChild, storage.ClosePolicyBlock); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if _, err := closeTask(blocked, storage.ClosePolicyBlock); err != nil {
+		t.Fatalf("UpdateTask(block) failed once subtasks are closed: %v", err)
+	}
+
+	cascaded := mustCreateTask(t, s, userID, "cascaded")
+	child := mustCreateSubtask(t, s, userID, cascaded.ID, "child")
+	grandchild := mustCreateSubtask(t, s, userID, child.ID, "grandchild")
+	if _, err := closeTask(cascaded, storage.ClosePolicyCascade); err != nil {
+		t.Fatalf("UpdateTask(cascade): %v", err)
+	}
+	for _, taskID := range []int{child.ID, grandchild.ID} {
+		if status(taskID) != storage.TaskStatusClosed {
+			t.Fatalf("UpdateTask(cascade) left subtask [%d] open", taskID)
+		}
+	}
+	history, err := s.GetTaskHistory(ctx, grandchild.ID, userID, 1, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if history[0].ActionType != storage.UpdateTaskType || history[0].After.Priority != storage.TaskPriorityClosed {
+		t.Fatalf("cascaded close was logged as %+v", history[0])
+	}
+
+	ignored := mustCreateTask(t, s, userID, "ignored")
+	ignoredChild := mustCreateSubtask(t, s, userID, ignored.ID, "stays open")
+	if _, err := closeTask(ignored, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask(ignore): %v", err)
+	}
+	if status(ignoredChild.ID) != storage.TaskStatusOpened {
+		t.Fatal("UpdateTask(ignore) closed a subtask")
+	}
+}
+
+// testClosedTasksCounted checks that every close is counted once, whether
+// the task is updated, closed along with its parent or closed again by
+// undo and redo.
+func testClosedTasksCounted(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	update := func(task *storage.Task, status int8) {
+		t.Helper()
+		task.Status = status
+		if _, err := s.UpdateTask(ctx, task, storage.ClosePolicyCascade); err != nil {
+			t.Fatalf("UpdateTask to status %d: %v", status, err)
+		}
+	}
+	counted := func(step string, want float64, do func() error) {
+		t.Helper()
+		closed := testutil.ToFloat64(metrics.TasksClosed)
+		if err := do(); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if got := testutil.ToFloat64(metrics.TasksClosed) - closed; got != want {
+			t.Errorf("%s counted %v closed tasks, want %v", step, got, want)
+		}
+	}
+
+	parent := mustCreateTask(t, s, userID, "parent")
+	child := mustCreateSubtask(t, s, userID, parent.ID, "child")
+	mustCreateSubtask(t, s, userID, child.ID, "grandchild")
+
+	counted("cascaded close", 3, func() error {
+		update(parent, storage.TaskStatusClosed)
+		return nil
+	})
+	counted("update of a done task", 0, func() error {
+		update(parent, storage.TaskStatusClosed)
+		return nil
+	})
+	counted("undo of the update", 0, func() error {
+		_, err := s.UndoTask(ctx, parent.ID, userID)
+		return err
+	})
+	counted("undo of the close", 0, func() error {
+		_, err := s.UndoTask(ctx, parent.ID, userID)
+		return err
+	})
+	counted("redo of the close", 1, func() error {
+		_, err := s.RedoTask(ctx, parent.ID, userID)
+		return err
+	})
+
+	task := mustCreateTask(t, s, userID, "task")
+	counted("cancel", 1, func() error {
+		update(task, storage.TaskStatusCancelled)
+		return nil
+	})
+	counted("reopen", 0, func() error {
+		update(task, storage.TaskStatusOpened)
+		return nil
+	})
+	counted("undo of a reopen", 1, func() error {
+		_, err := s.UndoTask(ctx, task.ID, userID)
+		return err
+	})
+	counted("redo of a reopen", 0, func() error {
+		_, err := s.RedoTask(ctx, task.ID, userID)
+		return err
+	})
+}
+
+func testDeleteParentUnlinksSubtasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	parent := mustCreateTask(t, s, userID, "parent")
+	child := mustCreateSubtask(t, s, userID, parent.ID, "orphan")
+
+	if err := s.DeleteTask(ctx, parent.ID, userID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	got, err := s.GetTask(ctx, child.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.ParentID != nil {
+		t.Fatalf("subtask of a deleted task kept parent %d", *got.ParentID)
+	}
+}
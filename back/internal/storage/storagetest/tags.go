@@ -0,0 +1,246 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+func mustCreateTag(t *testing.T, s storage.Storage, userID int, name string) *storage.Tag {
+	t.Helper()
+
+	tag, err := s.CreateTag(context.Background(), &storage.Tag{UserID: userID, Name: name, Color: "#00ff00"})
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	return tag
+}
+
+func mustAttachTag(t *testing.T, s storage.Storage, taskID, tagID, userID int) *storage.Task {
+	t.Helper()
+
+	task, err := s.AttachTag(context.Background(), taskID, tagID, userID)
+	if err != nil {
+		t.Fatalf("AttachTag: %v", err)
+	}
+	return task
+}
+
+func testTagsCRUD(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	work := mustCreateTag(t, s, userID, "work")
+	mustCreateTag(t, s, userID, "home")
+	if work.ID <= 0 || work.UserID != userID || work.Color != "#00ff00" {
+		t.Fatalf("CreateTag returned %+v", work)
+	}
+	if _, err := s.CreateTag(ctx, &storage.Tag{UserID: userID, Name: "work"}); err == nil {
+		t.Fatal("CreateTag created a duplicate tag name")
+	}
+	// names are scoped by user
+	mustCreateTag(t, s, mustCreateUser(t, s), "work")
+
+	renamed, err := s.UpdateTag(ctx, &storage.Tag{ID: work.ID, UserID: userID, Name: "office", Color: "#0000ff"})
+	if err != nil {
+		t.Fatalf("UpdateTag: %v", err)
+	}
+	if renamed.Name != "office" || renamed.Color != "#0000ff" {
+		t.Fatalf("UpdateTag returned %+v", renamed)
+	}
+	if _, err := s.UpdateTag(ctx, &storage.Tag{ID: work.ID, UserID: mustCreateUser(t, s), Name: "stolen"}); err == nil {
+		t.Fatal("UpdateTag renamed a tag owned by another user")
+	}
+
+	tags, err := s.GetTags(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "home" || tags[1].Name != "office" {
+		t.Fatalf("GetTags returned %+v", tags)
+	}
+
+	if err := s.DeleteTag(ctx, work.ID, userID); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	if err := s.DeleteTag(ctx, work.ID, userID); err == nil {
+		t.Fatal("DeleteTag deleted a tag twice")
+	}
+}
+
+func testAttachDetachTag(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "tagged")
+	tag := mustCreateTag(t, s, userID, "errands")
+
+	attached := mustAttachTag(t, s, task.ID, tag.ID, userID)
+	if !slices.Equal(attached.TagIDs, []int{tag.ID}) {
+		t.Fatalf("AttachTag returned tags %v", attached.TagIDs)
+	}
+	// attaching twice changes nothing and logs nothing
+	mustAttachTag(t, s, task.ID, tag.ID, userID)
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if !slices.Equal(got.TagIDs, []int{tag.ID}) {
+		t.Fatalf("GetTask returned tags %v", got.TagIDs)
+	}
+
+	got.Title = "tagged renamed"
+	updated, err := s.UpdateTask(ctx, got, storage.ClosePolicyIgnore)
+	if err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if !slices.Equal(updated.TagIDs, []int{tag.ID}) {
+		t.Fatalf("UpdateTask returned tags %v", updated.TagIDs)
+	}
+
+	if _, err := s.AttachTag(ctx, task.ID, mustCreateTag(t, s, mustCreateUser(t, s), "foreign").ID, userID); err == nil {
+		t.Fatal("AttachTag attached a tag owned by another user")
+	}
+
+	detached, err := s.DetachTag(ctx, task.ID, tag.ID, userID)
+	if err != nil {
+		t.Fatalf("DetachTag: %v", err)
+	}
+	if len(detached.TagIDs) != 0 {
+		t.Fatalf("DetachTag left tags %v", detached.TagIDs)
+	}
+
+	history, err := s.GetTaskHistory(ctx, task.ID, userID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	wantTypes := []int{storage.DetachTagType, storage.UpdateTaskType, storage.AttachTagType, storage.CreateTaskType}
+	if len(history) != len(wantTypes) {
+		t.Fatalf("GetTaskHistory returned %d actions, want %d", len(history), len(wantTypes))
+	}
+	for i, action := range history {
+		if action.ActionType != wantTypes[i] {
+			t.Fatalf("action %d has type %d, want %d", i, action.ActionType, wantTypes[i])
+		}
+	}
+	if !slices.Equal(history[0].ChangedFields, []string{"tag_ids"}) {
+		t.Fatalf("detach changed fields %v, want [tag_ids]", history[0].ChangedFields)
+	}
+
+	restored, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if !slices.Equal(restored.TagIDs, []int{tag.ID}) {
+		t.Fatalf("UndoTask of a detach left tags %v", restored.TagIDs)
+	}
+}
+
+func testCreateTaskWithTags(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	top := mustCreateTask(t, s, userID, "on top")
+	work := mustCreateTag(t, s, userID, "work")
+	home := mustCreateTag(t, s, userID, "home")
+
+	task, err := s.CreateTaskWithTags(ctx, &storage.Task{Title: "tagged", UserID: userID, TagIDs: []int{work.ID}},
+		[]string{"home", "errands"}, storage.TaskPosition{Place: storage.PlaceBottom})
+	if err != nil {
+		t.Fatalf("CreateTaskWithTags: %v", err)
+	}
+	if task.Priority >= top.Priority {
+		t.Fatalf("task placed at the bottom has priority %d, not below %d", task.Priority, top.Priority)
+	}
+
+	tags, err := s.GetTags(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+	if len(tags) != 3 || tags[0].Name != "errands" {
+		t.Fatalf("GetTags returned %+v, want errands created next to home and work", tags)
+	}
+	if want := []int{work.ID, home.ID, tags[0].ID}; !slices.Equal(task.TagIDs, slices.Sorted(slices.Values(want))) {
+		t.Fatalf("CreateTaskWithTags returned tags %v, want %v", task.TagIDs, want)
+	}
+
+	history, err := s.GetTaskHistory(ctx, task.ID, userID, 10, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].ActionType != storage.CreateTaskType || !slices.Equal(history[0].After.TagIDs, task.TagIDs) {
+		t.Fatalf("GetTaskHistory returned %+v, want a single create action with the tags", history)
+	}
+
+	// a failing task leaves no tags behind
+	_, err = s.CreateTaskWithTags(ctx, &storage.Task{Title: "misplaced", UserID: userID},
+		[]string{"orphan"}, storage.TaskPosition{Place: storage.PlaceAfter, AnchorID: -1})
+	if !errors.Is(err, storage.ErrInvalidAnchor) {
+		t.Fatalf("CreateTaskWithTags after a missing anchor returned %v, want ErrInvalidAnchor", err)
+	}
+	if tags, err = s.GetTags(ctx, userID); err != nil || len(tags) != 3 {
+		t.Fatalf("GetTags after a failed create returned %+v, %v", tags, err)
+	}
+}
+
+func testDeleteTagDetaches(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "loses tag")
+	tag := mustCreateTag(t, s, userID, "temporary")
+	mustAttachTag(t, s, task.ID, tag.ID, userID)
+
+	if err := s.DeleteTag(ctx, tag.ID, userID); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if len(got.TagIDs) != 0 {
+		t.Fatalf("task kept tags %v of a deleted tag", got.TagIDs)
+	}
+}
+
+func testListTasksByTags(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	home := mustCreateTag(t, s, userID, "home")
+	work := mustCreateTag(t, s, userID, "work")
+
+	both := mustCreateTask(t, s, userID, "both")
+	onlyHome := mustCreateTask(t, s, userID, "only home")
+	mustCreateTask(t, s, userID, "untagged")
+	mustAttachTag(t, s, both.ID, home.ID, userID)
+	mustAttachTag(t, s, both.ID, work.ID, userID)
+	mustAttachTag(t, s, onlyHome.ID, home.ID, userID)
+
+	all, err := s.ListTasks(ctx, userID, storage.TaskFilter{}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("ListTasks without filter returned %v", taskTitles(all))
+	}
+
+	anyTags, err := s.ListTasks(ctx, userID, storage.TaskFilter{TagIDs: []int{work.ID, home.ID}}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if titles := taskTitles(anyTags); !slices.Equal(titles, []string{"only home", "both"}) {
+		t.Fatalf("ListTasks(any) returned %v, want [only home both]", titles)
+	}
+
+	allTags, err := s.ListTasks(ctx, userID, storage.TaskFilter{TagIDs: []int{work.ID, home.ID, work.ID}, MatchAllTags: true}, storage.MaxInt)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if titles := taskTitles(allTags); !slices.Equal(titles, []string{"both"}) {
+		t.Fatalf("ListTasks(all) returned %v, want [both]", titles)
+	}
+	if !slices.Equal(allTags[0].TagIDs, []int{home.ID, work.ID}) {
+		t.Fatalf("ListTasks returned tags %v", allTags[0].TagIDs)
+	}
+}
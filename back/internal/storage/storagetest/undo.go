@@ -0,0 +1,208 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"todo_list_service/internal/storage"
+)
+
+func mustUpdateTitle(t *testing.T, s storage.Storage, task *storage.Task, title string) {
+	t.Helper()
+
+	task.Title = title
+	if _, err := s.UpdateTask(context.Background(), task, storage.ClosePolicyIgnore); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+}
+
+func testUndoRedoTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "v1")
+
+	if _, err := s.UndoTask(ctx, task.ID, userID); !errors.Is(err, storage.ErrNothingToUndo) {
+		t.Fatalf("UndoTask of a fresh task returned %v, want ErrNothingToUndo", err)
+	}
+
+	mustUpdateTitle(t, s, task, "v2")
+	mustUpdateTitle(t, s, task, "v3")
+
+	undone, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if undone.Title != "v2" {
+		t.Fatalf("UndoTask restored title %q, want v2", undone.Title)
+	}
+
+	undone, err = s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if undone.Title != "v1" || undone.Description != task.Description || undone.Priority != task.Priority {
+		t.Fatalf("second UndoTask returned %+v", undone)
+	}
+	if _, err := s.UndoTask(ctx, task.ID, userID); !errors.Is(err, storage.ErrNothingToUndo) {
+		t.Fatalf("UndoTask past the creation returned %v, want ErrNothingToUndo", err)
+	}
+
+	redone, err := s.RedoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("RedoTask: %v", err)
+	}
+	if redone.Title != "v2" {
+		t.Fatalf("RedoTask restored title %q, want v2", redone.Title)
+	}
+
+	// a new change forgets the undone v3
+	mustUpdateTitle(t, s, task, "v4")
+	if _, err := s.RedoTask(ctx, task.ID, userID); !errors.Is(err, storage.ErrNothingToRedo) {
+		t.Fatalf("RedoTask after a new change returned %v, want ErrNothingToRedo", err)
+	}
+
+	undone, err = s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if undone.Title != "v2" {
+		t.Fatalf("UndoTask restored title %q, want v2", undone.Title)
+	}
+
+	history, err := s.GetTaskHistory(ctx, task.ID, userID, 1, 0)
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	last := history[0]
+	if last.ActionType != storage.UndoTaskType || last.TargetActionID == nil {
+		t.Fatalf("undo was logged as %+v", last)
+	}
+	if last.Before.Title != "v4" || last.After.Title != "v2" {
+		t.Fatalf("undo action has snapshots %q -> %q", last.Before.Title, last.After.Title)
+	}
+}
+
+func testUndoArchive(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "undo archive")
+
+	if _, err := s.ArchiveTask(ctx, task.ID, userID); err != nil {
+		t.Fatalf("ArchiveTask: %v", err)
+	}
+
+	restored, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if restored.ArchivedTs != nil {
+		t.Fatal("UndoTask left the task archived")
+	}
+
+	archived, err := s.RedoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("RedoTask: %v", err)
+	}
+	if archived.ArchivedTs == nil {
+		t.Fatal("RedoTask did not archive the task again")
+	}
+}
+
+func testUndoTaskOtherUser(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	task := mustCreateTask(t, s, userID, "foreign undo")
+	mustUpdateTitle(t, s, task, "changed")
+
+	if _, err := s.UndoTask(ctx, task.ID, mustCreateUser(t, s)); err == nil {
+		t.Fatal("UndoTask reverted a task owned by another user")
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Title != "changed" {
+		t.Fatalf("task title is %q after a foreign undo", got.Title)
+	}
+}
+
+// testUndoPlacesTask checks that undo puts a task back among the tasks moved
+// since its snapshot instead of reusing the old priority, and that it cannot
+// restore a status the project's workflow no longer has.
+func testUndoPlacesTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	project, want := mustCreateProjectTasks(t, s, userID, 3)
+	tasks := checkOrder(t, s, userID, project.ID, want)
+	first := tasks[0]
+
+	// the top task sinks, the others take the top priorities over
+	want = moveTo(t, s, userID, tasks, 0, 2, false)
+	tasks = checkOrder(t, s, userID, project.ID, want)
+	want = moveTo(t, s, userID, tasks, 1, 0, false)
+	tasks = checkOrder(t, s, userID, project.ID, want)
+
+	undone, err := s.UndoTask(ctx, first.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	rest := slices.DeleteFunc(tasks, func(task storage.Task) bool { return task.ID == first.ID })
+	at := slices.IndexFunc(rest, func(task storage.Task) bool { return task.Priority < first.Priority })
+	if at < 0 {
+		at = len(rest)
+	}
+	checkOrder(t, s, userID, project.ID, slices.Insert(taskIDs(rest), at, first.ID))
+	if undone.Priority == storage.TaskPriorityClosed {
+		t.Fatalf("UndoTask left the open task at the closed priority")
+	}
+
+	// a task created in the inbox after a move out of it takes the top
+	inbox := mustGetInbox(t, s, userID)
+	moving := mustCreateTask(t, s, userID, "moving out")
+	if _, err := s.MoveTask(ctx, moving.ID, project.ID, userID); err != nil {
+		t.Fatalf("MoveTask: %v", err)
+	}
+	newcomer := mustCreateTask(t, s, userID, "newcomer")
+	back, err := s.UndoTask(ctx, moving.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if back.ProjectID != inbox.ID {
+		t.Fatalf("UndoTask of a move left project %d, want %d", back.ProjectID, inbox.ID)
+	}
+	if back.Priority == newcomer.Priority {
+		t.Fatalf("UndoTask of a move gave priority %d of task %d to task %d", back.Priority, newcomer.ID, back.ID)
+	}
+	checkOrder(t, s, userID, inbox.ID, []int{newcomer.ID, back.ID})
+
+	// in progress is dropped from the workflow between the change and the undo
+	flow := mustCreateProject(t, s, userID, uniqueName(t))
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "flow", UserID: userID, ProjectID: flow.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	task = mustSetStatus(t, s, task, storage.TaskStatusInProgress)
+	task = mustSetStatus(t, s, task, storage.TaskStatusOpened)
+
+	workflow := storage.Workflow{
+		Statuses:    []int8{storage.TaskStatusOpened, storage.TaskStatusClosed},
+		Transitions: map[int8][]int8{storage.TaskStatusOpened: {storage.TaskStatusClosed}, storage.TaskStatusClosed: {storage.TaskStatusOpened}},
+	}
+	if _, err := s.UpdateProject(ctx, &storage.Project{ID: flow.ID, UserID: userID, Name: flow.Name, Workflow: workflow}); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+
+	if _, err := s.UndoTask(ctx, task.ID, userID); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UndoTask to a status outside the workflow returned %v, want ErrInvalidTransition", err)
+	}
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Status != storage.TaskStatusOpened {
+		t.Fatalf("failed undo left status %d", got.Status)
+	}
+}
@@ -0,0 +1,206 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+func mustCreateView(t *testing.T, s storage.Storage, userID int, name, query string) *storage.SavedView {
+	t.Helper()
+
+	view, err := s.CreateSavedView(context.Background(), &storage.SavedView{UserID: userID, Name: name, Query: query})
+	if err != nil {
+		t.Fatalf("CreateSavedView: %v", err)
+	}
+	return view
+}
+
+// mustListView compiles a view query against the user's tags and projects
+// and lists the tasks it matches.
+func mustListView(t *testing.T, s storage.Storage, userID int, query string, now time.Time) []int {
+	t.Helper()
+	ctx := context.Background()
+
+	tags, err := s.GetTags(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	}
+	projects, err := s.GetProjects(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+
+	filter, err := storage.CompileView(query, storage.ViewScope{Tags: tags, Projects: projects, Location: time.UTC, Now: now})
+	if err != nil {
+		t.Fatalf("CompileView(%q): %v", query, err)
+	}
+	tasks, err := s.ListTasks(ctx, userID, filter, 100)
+	if err != nil {
+		t.Fatalf("ListTasks(%q): %v", query, err)
+	}
+	return taskIDs(tasks)
+}
+
+func testSavedViewsCRUD(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	week := mustCreateView(t, s, userID, "this week", "due:week status:open")
+	mustCreateView(t, s, userID, "backlog", "status:todo sort:creation")
+	if week.ID <= 0 || week.UserID != userID || week.Query != "due:week status:open" || week.CreationTs.IsZero() {
+		t.Fatalf("CreateSavedView returned %+v", week)
+	}
+	if _, err := s.CreateSavedView(ctx, &storage.SavedView{UserID: userID, Name: "this week"}); err == nil {
+		t.Fatal("CreateSavedView created a duplicate view name")
+	}
+	// names are scoped by user
+	otherID := mustCreateUser(t, s)
+	mustCreateView(t, s, otherID, "this week", "")
+
+	got, err := s.GetSavedView(ctx, week.ID, userID)
+	if err != nil {
+		t.Fatalf("GetSavedView: %v", err)
+	}
+	if got.Name != week.Name || got.Query != week.Query {
+		t.Fatalf("GetSavedView returned %+v, want %+v", got, week)
+	}
+	if _, err := s.GetSavedView(ctx, week.ID, otherID); err == nil {
+		t.Fatal("GetSavedView returned a view of another user")
+	}
+
+	updated, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: week.ID, UserID: userID, Name: "soon", Query: "due:today"})
+	if err != nil {
+		t.Fatalf("UpdateSavedView: %v", err)
+	}
+	if updated.Name != "soon" || updated.Query != "due:today" {
+		t.Fatalf("UpdateSavedView returned %+v", updated)
+	}
+	if _, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: week.ID, UserID: userID, Name: "backlog"}); err == nil {
+		t.Fatal("UpdateSavedView took the name of another view")
+	}
+	if _, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: week.ID, UserID: otherID, Name: "stolen"}); err == nil {
+		t.Fatal("UpdateSavedView changed a view of another user")
+	}
+
+	views, err := s.GetSavedViews(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetSavedViews: %v", err)
+	}
+	if len(views) != 2 || views[0].Name != "backlog" || views[1].Name != "soon" {
+		t.Fatalf("GetSavedViews returned %+v", views)
+	}
+
+	if err := s.DeleteSavedView(ctx, week.ID, otherID); err == nil {
+		t.Fatal("DeleteSavedView deleted a view of another user")
+	}
+	if err := s.DeleteSavedView(ctx, week.ID, userID); err != nil {
+		t.Fatalf("DeleteSavedView: %v", err)
+	}
+	if _, err := s.GetSavedView(ctx, week.ID, userID); err == nil {
+		t.Fatal("GetSavedView returned a deleted view")
+	}
+}
+
+func testSavedViewQueries(t *testing.T, s storage.Storage) {
+	userID := mustCreateUser(t, s)
+	now := time.Now().Truncate(time.Second)
+
+	work := mustCreateTag(t, s, userID, "work")
+	home := mustCreateTag(t, s, userID, "home")
+	project := mustCreateProject(t, s, userID, "Side project")
+
+	late := mustCreateDueTask(t, s, userID, "late", now.Add(-time.Hour))
+	mustAttachTag(t, s, late.ID, work.ID, userID)
+	lateDone := mustCreateDueTask(t, s, userID, "late and done", now.Add(-2*time.Hour))
+	mustAttachTag(t, s, lateDone.ID, work.ID, userID)
+	mustSetStatus(t, s, lateDone, storage.TaskStatusClosed)
+	lateHome := mustCreateDueTask(t, s, userID, "late at home", now.Add(-3*time.Hour))
+	mustAttachTag(t, s, lateHome.ID, home.ID, userID)
+	later := mustCreateDueTask(t, s, userID, "later", now.Add(30*24*time.Hour))
+	mustAttachTag(t, s, later.ID, work.ID, userID)
+	mustAttachTag(t, s, later.ID, home.ID, userID)
+
+	side, err := s.CreateTask(context.Background(), &storage.Task{Title: "side", UserID: userID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  []int
+	}{
+		{"due:overdue tag:work status:open", []int{late.ID}},
+		{"due:overdue status:done", []int{lateDone.ID}},
+		{"tag:work,home status:open sort:due", []int{lateHome.ID, late.ID, later.ID}},
+		{"tag:work tag:home", []int{later.ID}},
+		{`project:"Side project"`, []int{side.ID}},
+		{"project:inbox status:todo", []int{later.ID, lateHome.ID, late.ID}},
+	} {
+		if got := mustListView(t, s, userID, tt.query, now); !slices.Equal(got, tt.want) {
+			t.Errorf("view %q lists %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	scope := storage.ViewScope{Tags: []storage.Tag{*work}, Projects: []storage.Project{*project}, Location: time.UTC, Now: now}
+	for _, query := range []string{"tag:travel", "project:Work", "tag:work tag:home"} {
+		if _, err := storage.CompileView(query, scope); !errors.Is(err, storage.ErrUnknownViewName) {
+			t.Errorf("CompileView(%q) returned %v, want ErrUnknownViewName", query, err)
+		}
+	}
+	for _, query := range []string{"status:later", "due:someday", "sort:title", "color:red", "tag:", `project:"Side`, "tag:work,home tag:work", "sort:due sort:priority", "created_from:01.02.2024"} {
+		if _, err := storage.CompileView(query, scope); err == nil || errors.Is(err, storage.ErrUnknownViewName) {
+			t.Errorf("CompileView(%q) returned %v, want a syntax error", query, err)
+		}
+	}
+}
+
+// testSavedViewEvaluation lists the tasks of stored views, due windows are
+// placed around a fixed moment and creation bounds around today.
+func testSavedViewEvaluation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	today := time.Now().UTC().Format(time.DateOnly)
+	// a Wednesday
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	dueToday := mustCreateDueTask(t, s, userID, "today", now.Add(6*time.Hour))
+	dueSunday := mustCreateDueTask(t, s, userID, "sunday", now.AddDate(0, 0, 4))
+	dueLater := mustCreateDueTask(t, s, userID, "later", now.AddDate(0, 0, 9))
+	overdue := mustCreateDueTask(t, s, userID, "overdue", now.AddDate(0, 0, -1))
+	undated := mustCreateTask(t, s, userID, "undated")
+
+	for _, tt := range []struct {
+		query string
+		want  []int
+	}{
+		{"due:today", []int{dueToday.ID}},
+		{"due:week sort:due", []int{dueToday.ID, dueSunday.ID}},
+		{"due:overdue", []int{overdue.ID}},
+		{"sort:due", []int{overdue.ID, dueToday.ID, dueSunday.ID, dueLater.ID, undated.ID}},
+		{"created_from:" + today + " sort:creation", []int{undated.ID, overdue.ID, dueLater.ID, dueSunday.ID, dueToday.ID}},
+		{"created_to:" + today, []int{}},
+	} {
+		view := mustCreateView(t, s, userID, tt.query, tt.query)
+		stored, err := s.GetSavedView(ctx, view.ID, userID)
+		if err != nil {
+			t.Fatalf("GetSavedView: %v", err)
+		}
+		if got := mustListView(t, s, userID, stored.Query, now); !slices.Equal(got, tt.want) {
+			t.Errorf("view %q lists %v, want %v", stored.Query, got, tt.want)
+		}
+	}
+
+	// changing the query of a view changes what it lists
+	view := mustCreateView(t, s, userID, "changing", "due:today")
+	updated, err := s.UpdateSavedView(ctx, &storage.SavedView{ID: view.ID, UserID: userID, Name: view.Name, Query: "due:overdue"})
+	if err != nil {
+		t.Fatalf("UpdateSavedView: %v", err)
+	}
+	if got := mustListView(t, s, userID, updated.Query, now); !slices.Equal(got, []int{overdue.ID}) {
+		t.Errorf("updated view %q lists %v, want %v", updated.Query, got, []int{overdue.ID})
+	}
+}
@@ -0,0 +1,214 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"todo_list_service/internal/storage"
+)
+
+// setStatus moves a task to status through UpdateTask.
+func setStatus(t *testing.T, s storage.Storage, task *storage.Task, status int8) (*storage.Task, error) {
+	t.Helper()
+
+	update := *task
+	update.Status = status
+	return s.UpdateTask(context.Background(), &update, storage.ClosePolicyIgnore)
+}
+
+func mustSetStatus(t *testing.T, s storage.Storage, task *storage.Task, status int8) *storage.Task {
+	t.Helper()
+
+	updated, err := setStatus(t, s, task, status)
+	if err != nil {
+		t.Fatalf("UpdateTask to status %d: %v", status, err)
+	}
+	if updated.Status != status {
+		t.Fatalf("UpdateTask left status %d, want %d", updated.Status, status)
+	}
+	return updated
+}
+
+func taskIDs(tasks []storage.Task) []int {
+	ids := []int{}
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func testDefaultWorkflow(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	inbox := mustGetInbox(t, s, userID)
+	if !slices.Equal(inbox.Workflow.Statuses, storage.DefaultWorkflow().Statuses) {
+		t.Fatalf("inbox has workflow statuses %v, want the default ones", inbox.Workflow.Statuses)
+	}
+
+	task := mustCreateTask(t, s, userID, "flow")
+	if _, ok := task.StatusTs[storage.TaskStatusOpened]; !ok {
+		t.Fatalf("created task has status times %v, want the opened status", task.StatusTs)
+	}
+
+	if _, err := setStatus(t, s, task, storage.TaskStatusReview); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UpdateTask from todo to review returned %v, want ErrInvalidTransition", err)
+	}
+	if _, err := setStatus(t, s, task, 42); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UpdateTask to an unknown status returned %v, want ErrInvalidTransition", err)
+	}
+
+	inProgress := mustSetStatus(t, s, task, storage.TaskStatusInProgress)
+	review := mustSetStatus(t, s, inProgress, storage.TaskStatusReview)
+	done := mustSetStatus(t, s, review, storage.TaskStatusClosed)
+	if done.Priority != storage.TaskPriorityClosed {
+		t.Fatalf("done task has priority %d, want %d", done.Priority, storage.TaskPriorityClosed)
+	}
+	for _, status := range []int8{storage.TaskStatusOpened, storage.TaskStatusInProgress, storage.TaskStatusReview, storage.TaskStatusClosed} {
+		if _, ok := done.StatusTs[status]; !ok {
+			t.Fatalf("done task has status times %v, missing status %d", done.StatusTs, status)
+		}
+	}
+	if done.StatusTs[storage.TaskStatusClosed].Before(done.StatusTs[storage.TaskStatusOpened]) {
+		t.Fatalf("task entered done at %v, before it was opened at %v", done.StatusTs[storage.TaskStatusClosed], done.StatusTs[storage.TaskStatusOpened])
+	}
+
+	got, err := s.GetTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if len(got.StatusTs) != len(done.StatusTs) {
+		t.Fatalf("GetTask returned status times %v, want %v", got.StatusTs, done.StatusTs)
+	}
+
+	undone, err := s.UndoTask(ctx, task.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoTask: %v", err)
+	}
+	if undone.Status != storage.TaskStatusReview {
+		t.Fatalf("UndoTask restored status %d, want review", undone.Status)
+	}
+	if _, ok := undone.StatusTs[storage.TaskStatusClosed]; ok {
+		t.Fatalf("UndoTask kept the done time in %v", undone.StatusTs)
+	}
+}
+
+func testCancelledTaskIsDone(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	task := mustCreateDueTask(t, s, userID, "cancel me", time.Now().Add(time.Hour))
+	cancelled := mustSetStatus(t, s, task, storage.TaskStatusCancelled)
+	if cancelled.Priority != storage.TaskPriorityClosed {
+		t.Fatalf("cancelled task has priority %d, want %d", cancelled.Priority, storage.TaskPriorityClosed)
+	}
+
+	due, err := s.GetDueTasks(ctx, userID, nil, time.Now().Add(24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetDueTasks: %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("GetDueTasks returned cancelled tasks %+v", due)
+	}
+
+	parent := mustCreateTask(t, s, userID, "parent")
+	mustCreateSubtask(t, s, userID, parent.ID, "child")
+	update := *parent
+	update.Status = storage.TaskStatusCancelled
+	if _, err := s.UpdateTask(ctx, &update, storage.ClosePolicyBlock); !errors.Is(err, storage.ErrOpenSubtasks) {
+		t.Fatalf("cancelling a task with open subtasks returned %v, want ErrOpenSubtasks", err)
+	}
+}
+
+func testProjectWorkflow(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+
+	workflow := storage.Workflow{
+		Statuses: []int8{storage.TaskStatusOpened, storage.TaskStatusInProgress, storage.TaskStatusClosed},
+		Transitions: map[int8][]int8{
+			storage.TaskStatusOpened:     {storage.TaskStatusInProgress},
+			storage.TaskStatusInProgress: {storage.TaskStatusClosed},
+		},
+	}
+	project, err := s.CreateProject(ctx, &storage.Project{UserID: userID, Name: "strict", Workflow: workflow})
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if !slices.Equal(project.Workflow.Statuses, workflow.Statuses) {
+		t.Fatalf("CreateProject stored statuses %v, want %v", project.Workflow.Statuses, workflow.Statuses)
+	}
+
+	renamed, err := s.UpdateProject(ctx, &storage.Project{ID: project.ID, UserID: userID, Name: "strict renamed"})
+	if err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if !slices.Equal(renamed.Workflow.Statuses, workflow.Statuses) {
+		t.Fatalf("UpdateProject without a workflow changed statuses to %v", renamed.Workflow.Statuses)
+	}
+
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "strict task", UserID: userID, ProjectID: project.ID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if _, err := setStatus(t, s, task, storage.TaskStatusClosed); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UpdateTask skipping in progress returned %v, want ErrInvalidTransition", err)
+	}
+	if _, err := setStatus(t, s, task, storage.TaskStatusReview); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UpdateTask to a status outside the workflow returned %v, want ErrInvalidTransition", err)
+	}
+	task = mustSetStatus(t, s, task, storage.TaskStatusInProgress)
+
+	// dropping the status a task is in lets it move to any remaining status
+	workflow = storage.Workflow{
+		Statuses:    []int8{storage.TaskStatusOpened, storage.TaskStatusClosed},
+		Transitions: map[int8][]int8{storage.TaskStatusOpened: {storage.TaskStatusClosed}},
+	}
+	if _, err := s.UpdateProject(ctx, &storage.Project{ID: project.ID, UserID: userID, Name: "strict renamed", Workflow: workflow}); err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	task = mustSetStatus(t, s, task, storage.TaskStatusClosed)
+	if _, err := setStatus(t, s, task, storage.TaskStatusOpened); !errors.Is(err, storage.ErrInvalidTransition) {
+		t.Fatalf("UpdateTask reopening against the workflow returned %v, want ErrInvalidTransition", err)
+	}
+}
+
+func testBoardColumns(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustCreateUser(t, s)
+	inbox := mustGetInbox(t, s, userID)
+
+	low := mustCreateTask(t, s, userID, "low")
+	high := mustCreateTask(t, s, userID, "high")
+	doing := mustSetStatus(t, s, mustCreateTask(t, s, userID, "doing"), storage.TaskStatusInProgress)
+	firstDone := mustSetStatus(t, s, mustCreateTask(t, s, userID, "first done"), storage.TaskStatusClosed)
+	lastDone := mustSetStatus(t, s, mustCreateTask(t, s, userID, "last done"), storage.TaskStatusClosed)
+
+	tasks, err := s.ListTasks(ctx, userID, storage.TaskFilter{ProjectID: inbox.ID}, 100)
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+
+	columns := storage.GroupByStatus(inbox.Workflow, tasks)
+	if len(columns) != len(inbox.Workflow.Statuses) {
+		t.Fatalf("GroupByStatus returned %d columns, want %d", len(columns), len(inbox.Workflow.Statuses))
+	}
+
+	want := map[int8][]int{
+		storage.TaskStatusOpened:     {high.ID, low.ID},
+		storage.TaskStatusInProgress: {doing.ID},
+		storage.TaskStatusReview:     {},
+		storage.TaskStatusClosed:     {lastDone.ID, firstDone.ID},
+		storage.TaskStatusCancelled:  {},
+	}
+	for i, column := range columns {
+		if column.Status != inbox.Workflow.Statuses[i] {
+			t.Fatalf("column %d has status %d, want %d", i, column.Status, inbox.Workflow.Statuses[i])
+		}
+		if got := taskIDs(column.Tasks); !slices.Equal(got, want[column.Status]) {
+			t.Fatalf("column %s holds tasks %v, want %v", column.Name, got, want[column.Status])
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ClosePolicy decides what closing a task does to its open subtasks.
+type ClosePolicy string
+
+const (
+	// ClosePolicyBlock refuses to close a task while it has open subtasks.
+	ClosePolicyBlock ClosePolicy = "block"
+	// ClosePolicyCascade closes the open subtasks together with the task.
+	ClosePolicyCascade ClosePolicy = "cascade"
+	// ClosePolicyIgnore closes the task and leaves its subtasks as they are.
+	ClosePolicyIgnore ClosePolicy = "ignore"
+)
+
+var (
+	ErrOpenSubtasks = errors.New("task has open subtasks")
+	ErrTaskCycle    = errors.New("task cannot become a subtask of itself or of its subtasks")
+)
+
+func ParseClosePolicy(name string) (ClosePolicy, error) {
+	switch policy := ClosePolicy(name); policy {
+	case ClosePolicyBlock, ClosePolicyCascade, ClosePolicyIgnore:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown close policy [%s]", name)
+}
+
+// SubtaskRepository is implemented by every backend able to nest tasks. A
+// parent belongs to the same user as its subtasks, archived tasks cannot
+// get new subtasks and deleting a parent turns its subtasks into top level
+// tasks. Subtasks keep their own project and priority, siblings are ordered
+// by priority like any other tasks.
+type SubtaskRepository interface {
+	// SetTaskParent makes a task a subtask of parentID, zero parentID turns
+	// it into a top level task. It fails with ErrTaskCycle when parentID is
+	// the task itself or one of its subtasks.
+	SetTaskParent(ctx context.Context, taskID, parentID, userID int) (*Task, error)
+	// GetSubtasks returns the direct active subtasks of a task ordered by
+	// priority.
+	GetSubtasks(ctx context.Context, taskID, userID int) ([]Task, error)
+}
+
+// ParentTaskID returns the id of the task's parent, zero for a top level task.
+func (task *Task) ParentTaskID() int {
+	if task.ParentID == nil {
+		return 0
+	}
+	return *task.ParentID
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"slices"
+)
+
+// Tag is a user-scoped label, names are unique per user.
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+}
+
+// TagRepository is implemented by every backend able to persist tags.
+// Attaching and detaching tags is logged in task_actions like any other
+// change of a task.
+type TagRepository interface {
+	CreateTag(ctx context.Context, newTag *Tag) (*Tag, error)
+	UpdateTag(ctx context.Context, updatedTag *Tag) (*Tag, error)
+	DeleteTag(ctx context.Context, tagID, userID int) error
+	GetTags(ctx context.Context, userID int) ([]Tag, error)
+
+	AttachTag(ctx context.Context, taskID, tagID, userID int) (*Task, error)
+	DetachTag(ctx context.Context, taskID, tagID, userID int) (*Task, error)
+}
+
+// withTag returns tagIDs with tagID added or removed, keeping them sorted.
+func withTag(tagIDs []int, tagID int, attach bool) []int {
+	result := slices.DeleteFunc(slices.Clone(tagIDs), func(id int) bool { return id == tagID })
+	if attach {
+		result = append(result, tagID)
+		slices.Sort(result)
+	}
+	return result
+}
+
+// TaskWithTag returns a copy of task with tagID attached or detached.
+func TaskWithTag(task *Task, tagID int, attach bool) *Task {
+	taskCopy := *task
+	taskCopy.TagIDs = withTag(task.TagIDs, tagID, attach)
+	return &taskCopy
+}
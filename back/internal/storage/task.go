@@ -6,19 +6,30 @@ const (
 	MinInt = -2147483648
 	MaxInt = 2147483647
 
-	TaskStatusOpened = 1
-	TaskStatusClosed = 2
+	TaskStatusOpened     = 1
+	TaskStatusClosed     = 2
+	TaskStatusInProgress = 3
+	TaskStatusReview     = 4
+	TaskStatusCancelled  = 5
 
 	TaskPriorityDelta  = 1000000
 	TaskPriorityClosed = MinInt
 )
 
 type Task struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      int8      `json:"status"`
-	UserID      int       `json:"user_id"`
-	Priority    int       `json:"priority"`
-	CreationTs  time.Time `json:"creation_ts"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Status      int8        `json:"status"`
+	StatusTs    StatusTimes `json:"status_ts,omitempty"`
+	UserID      int         `json:"user_id"`
+	ProjectID   int         `json:"project_id"`
+	ParentID    *int        `json:"parent_id,omitempty"`
+	Priority    int         `json:"priority"`
+	CreationTs  time.Time   `json:"creation_ts"`
+	ArchivedTs  *time.Time  `json:"archived_ts,omitempty"`
+	StartTs     *time.Time  `json:"start_ts,omitempty"`
+	DueTs       *time.Time  `json:"due_ts,omitempty"`
+	Recurrence  string      `json:"recurrence,omitempty"`
+	TagIDs      []int       `json:"tag_ids,omitempty"`
 }
@@ -1,7 +1,96 @@
 package storage
 
+import (
+	"slices"
+	"time"
+)
+
 const (
 	CreateTaskType         = 0
 	UpdateTaskType         = 1
 	UpdateTaskPriorityType = 2
+	ArchiveTaskType        = 3
+	RestoreTaskType        = 4
+	DeleteTaskType         = 5
+	UndoTaskType           = 6
+	RedoTaskType           = 7
+	AttachTagType          = 8
+	DetachTagType          = 9
+	MoveTaskType           = 10
+	SetTaskParentType      = 11
 )
+
+// TaskAction is a row of the task_actions audit log. Before and After hold
+// the task as it was around the action, either of them is nil when the task
+// did not exist at that moment. Undo and redo actions point at the action
+// they revert with TargetActionID.
+type TaskAction struct {
+	ID             int       `json:"id"`
+	ActionType     int       `json:"action_type"`
+	UserID         int       `json:"user_id"`
+	TaskID         int       `json:"task_id"`
+	Ts             time.Time `json:"ts"`
+	Before         *Task     `json:"before,omitempty"`
+	After          *Task     `json:"after,omitempty"`
+	TargetActionID *int      `json:"target_action_id,omitempty"`
+	ChangedFields  []string  `json:"changed_fields,omitempty"`
+}
+
+// ChangedFields lists the json names of the task fields that differ between
+// two snapshots of the same task.
+func ChangedFields(before, after *Task) []string {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	var fields []string
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+	}
+	if before.Description != after.Description {
+		fields = append(fields, "description")
+	}
+	if before.Status != after.Status {
+		fields = append(fields, "status")
+	}
+	if before.ProjectID != after.ProjectID {
+		fields = append(fields, "project_id")
+	}
+	if !equalID(before.ParentID, after.ParentID) {
+		fields = append(fields, "parent_id")
+	}
+	if before.Priority != after.Priority {
+		fields = append(fields, "priority")
+	}
+	if !equalTs(before.ArchivedTs, after.ArchivedTs) {
+		fields = append(fields, "archived_ts")
+	}
+	if !equalTs(before.StartTs, after.StartTs) {
+		fields = append(fields, "start_ts")
+	}
+	if !equalTs(before.DueTs, after.DueTs) {
+		fields = append(fields, "due_ts")
+	}
+	if before.Recurrence != after.Recurrence {
+		fields = append(fields, "recurrence")
+	}
+	if !slices.Equal(before.TagIDs, after.TagIDs) {
+		fields = append(fields, "tag_ids")
+	}
+
+	return fields
+}
+
+func equalTs(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
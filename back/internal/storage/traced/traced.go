@@ -0,0 +1,351 @@
+// Package traced wraps a storage with an OpenTelemetry span around every
+// call, the spans of the sql queries a call makes nest under it.
+package traced
+
+import (
+	"context"
+	"time"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ storage.Storage = (*Storage)(nil)
+
+const tracerName = "todo_list_service/internal/storage/traced"
+
+var (
+	taskIDKey    = attribute.Key("task.id")
+	projectIDKey = attribute.Key("project.id")
+	tagIDKey     = attribute.Key("tag.id")
+	viewIDKey    = attribute.Key("view.id")
+	parentIDKey  = attribute.Key("task.parent_id")
+)
+
+type Storage struct {
+	next   storage.Storage
+	tracer trace.Tracer
+	system attribute.KeyValue
+}
+
+// New wraps next, driver is the storage driver name spans are tagged with.
+func New(next storage.Storage, driver string) *Storage {
+	return &Storage{
+		next:   next,
+		tracer: otel.Tracer(tracerName),
+		system: semconv.DBSystemKey.String(driver),
+	}
+}
+
+func (s *Storage) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "storage."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(s.system),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records a failed call on the span and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *Storage) Close() error {
+	return s.next.Close()
+}
+
+func (s *Storage) CreateProject(ctx context.Context, newProject *storage.Project) (project *storage.Project, err error) {
+	ctx, span := s.start(ctx, "CreateProject", tracing.UserID(newProject.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.CreateProject(ctx, newProject)
+}
+
+func (s *Storage) UpdateProject(ctx context.Context, updatedProject *storage.Project) (project *storage.Project, err error) {
+	ctx, span := s.start(ctx, "UpdateProject", projectIDKey.Int(updatedProject.ID), tracing.UserID(updatedProject.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateProject(ctx, updatedProject)
+}
+
+func (s *Storage) DeleteProject(ctx context.Context, projectID, userID int) (err error) {
+	ctx, span := s.start(ctx, "DeleteProject", projectIDKey.Int(projectID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.DeleteProject(ctx, projectID, userID)
+}
+
+func (s *Storage) GetProjects(ctx context.Context, userID int) (projects []storage.Project, err error) {
+	ctx, span := s.start(ctx, "GetProjects", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetProjects(ctx, userID)
+}
+
+func (s *Storage) MoveTask(ctx context.Context, taskID, projectID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "MoveTask", taskIDKey.Int(taskID), projectIDKey.Int(projectID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.MoveTask(ctx, taskID, projectID, userID)
+}
+
+func (s *Storage) SearchTasks(ctx context.Context, userID int, query string, limit int) (matches []storage.TaskMatch, err error) {
+	ctx, span := s.start(ctx, "SearchTasks", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.SearchTasks(ctx, userID, query, limit)
+}
+
+func (s *Storage) CreateTask(ctx context.Context, newTask *storage.Task) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "CreateTask", tracing.UserID(newTask.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.CreateTask(ctx, newTask)
+}
+
+func (s *Storage) CreateTaskWithTags(ctx context.Context, newTask *storage.Task, tagNames []string, position storage.TaskPosition) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "CreateTaskWithTags", tracing.UserID(newTask.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.CreateTaskWithTags(ctx, newTask, tagNames, position)
+}
+
+func (s *Storage) UpdateTask(ctx context.Context, updatedTask *storage.Task, closePolicy storage.ClosePolicy) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "UpdateTask", taskIDKey.Int(updatedTask.ID), tracing.UserID(updatedTask.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateTask(ctx, updatedTask, closePolicy)
+}
+
+func (s *Storage) UpdateTaskPriority(ctx context.Context, taskID, userID int, position storage.TaskPosition) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "UpdateTaskPriority", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateTaskPriority(ctx, taskID, userID, position)
+}
+
+func (s *Storage) GetTask(ctx context.Context, taskID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "GetTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetTask(ctx, taskID, userID)
+}
+
+func (s *Storage) GetTasks(ctx context.Context, userID, limit int) (tasks []storage.Task, err error) {
+	ctx, span := s.start(ctx, "GetTasks", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetTasks(ctx, userID, limit)
+}
+
+func (s *Storage) ListTasks(ctx context.Context, userID int, filter storage.TaskFilter, limit int) (tasks []storage.Task, err error) {
+	ctx, span := s.start(ctx, "ListTasks", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.ListTasks(ctx, userID, filter, limit)
+}
+
+func (s *Storage) ArchiveTask(ctx context.Context, taskID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "ArchiveTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.ArchiveTask(ctx, taskID, userID)
+}
+
+func (s *Storage) RestoreTask(ctx context.Context, taskID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "RestoreTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.RestoreTask(ctx, taskID, userID)
+}
+
+func (s *Storage) GetArchivedTasks(ctx context.Context, userID, limit int) (tasks []storage.Task, err error) {
+	ctx, span := s.start(ctx, "GetArchivedTasks", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetArchivedTasks(ctx, userID, limit)
+}
+
+func (s *Storage) DeleteTask(ctx context.Context, taskID, userID int) (err error) {
+	ctx, span := s.start(ctx, "DeleteTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.DeleteTask(ctx, taskID, userID)
+}
+
+func (s *Storage) PurgeArchivedTasks(ctx context.Context, archivedBefore time.Time) (n int, err error) {
+	ctx, span := s.start(ctx, "PurgeArchivedTasks")
+	defer func() { end(span, err) }()
+
+	return s.next.PurgeArchivedTasks(ctx, archivedBefore)
+}
+
+func (s *Storage) GetDueTasks(ctx context.Context, userID int, from *time.Time, to time.Time, limit int) (tasks []storage.Task, err error) {
+	ctx, span := s.start(ctx, "GetDueTasks", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetDueTasks(ctx, userID, from, to, limit)
+}
+
+func (s *Storage) GetTaskHistory(ctx context.Context, taskID, userID, limit, offset int) (actions []storage.TaskAction, err error) {
+	ctx, span := s.start(ctx, "GetTaskHistory", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetTaskHistory(ctx, taskID, userID, limit, offset)
+}
+
+func (s *Storage) UndoTask(ctx context.Context, taskID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "UndoTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.UndoTask(ctx, taskID, userID)
+}
+
+func (s *Storage) RedoTask(ctx context.Context, taskID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "RedoTask", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.RedoTask(ctx, taskID, userID)
+}
+
+func (s *Storage) CreateUser(ctx context.Context, username, hashedPassword, email string) (userID int, err error) {
+	ctx, span := s.start(ctx, "CreateUser")
+	defer func() { end(span, err) }()
+
+	return s.next.CreateUser(ctx, username, hashedPassword, email)
+}
+
+func (s *Storage) GetUserByUsername(ctx context.Context, username string) (user *storage.User, err error) {
+	ctx, span := s.start(ctx, "GetUserByUsername")
+	defer func() { end(span, err) }()
+
+	return s.next.GetUserByUsername(ctx, username)
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, userID int) (user *storage.User, err error) {
+	ctx, span := s.start(ctx, "GetUserByID", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetUserByID(ctx, userID)
+}
+
+func (s *Storage) ListUsers(ctx context.Context) (users []storage.User, err error) {
+	ctx, span := s.start(ctx, "ListUsers")
+	defer func() { end(span, err) }()
+
+	return s.next.ListUsers(ctx)
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, userID int, hashedPassword string) (err error) {
+	ctx, span := s.start(ctx, "UpdateUserPassword", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateUserPassword(ctx, userID, hashedPassword)
+}
+
+func (s *Storage) UpdateUserTimeZone(ctx context.Context, userID int, timeZone string) (err error) {
+	ctx, span := s.start(ctx, "UpdateUserTimeZone", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateUserTimeZone(ctx, userID, timeZone)
+}
+
+func (s *Storage) SetTaskParent(ctx context.Context, taskID, parentID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "SetTaskParent", taskIDKey.Int(taskID), parentIDKey.Int(parentID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.SetTaskParent(ctx, taskID, parentID, userID)
+}
+
+func (s *Storage) GetSubtasks(ctx context.Context, taskID, userID int) (tasks []storage.Task, err error) {
+	ctx, span := s.start(ctx, "GetSubtasks", taskIDKey.Int(taskID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetSubtasks(ctx, taskID, userID)
+}
+
+func (s *Storage) CreateTag(ctx context.Context, newTag *storage.Tag) (tag *storage.Tag, err error) {
+	ctx, span := s.start(ctx, "CreateTag", tracing.UserID(newTag.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.CreateTag(ctx, newTag)
+}
+
+func (s *Storage) UpdateTag(ctx context.Context, updatedTag *storage.Tag) (tag *storage.Tag, err error) {
+	ctx, span := s.start(ctx, "UpdateTag", tagIDKey.Int(updatedTag.ID), tracing.UserID(updatedTag.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateTag(ctx, updatedTag)
+}
+
+func (s *Storage) DeleteTag(ctx context.Context, tagID, userID int) (err error) {
+	ctx, span := s.start(ctx, "DeleteTag", tagIDKey.Int(tagID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.DeleteTag(ctx, tagID, userID)
+}
+
+func (s *Storage) GetTags(ctx context.Context, userID int) (tags []storage.Tag, err error) {
+	ctx, span := s.start(ctx, "GetTags", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetTags(ctx, userID)
+}
+
+func (s *Storage) AttachTag(ctx context.Context, taskID, tagID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "AttachTag", taskIDKey.Int(taskID), tagIDKey.Int(tagID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.AttachTag(ctx, taskID, tagID, userID)
+}
+
+func (s *Storage) DetachTag(ctx context.Context, taskID, tagID, userID int) (task *storage.Task, err error) {
+	ctx, span := s.start(ctx, "DetachTag", taskIDKey.Int(taskID), tagIDKey.Int(tagID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.DetachTag(ctx, taskID, tagID, userID)
+}
+
+func (s *Storage) CreateSavedView(ctx context.Context, newView *storage.SavedView) (view *storage.SavedView, err error) {
+	ctx, span := s.start(ctx, "CreateSavedView", tracing.UserID(newView.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.CreateSavedView(ctx, newView)
+}
+
+func (s *Storage) UpdateSavedView(ctx context.Context, updatedView *storage.SavedView) (view *storage.SavedView, err error) {
+	ctx, span := s.start(ctx, "UpdateSavedView", viewIDKey.Int(updatedView.ID), tracing.UserID(updatedView.UserID))
+	defer func() { end(span, err) }()
+
+	return s.next.UpdateSavedView(ctx, updatedView)
+}
+
+func (s *Storage) DeleteSavedView(ctx context.Context, viewID, userID int) (err error) {
+	ctx, span := s.start(ctx, "DeleteSavedView", viewIDKey.Int(viewID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.DeleteSavedView(ctx, viewID, userID)
+}
+
+func (s *Storage) GetSavedView(ctx context.Context, viewID, userID int) (view *storage.SavedView, err error) {
+	ctx, span := s.start(ctx, "GetSavedView", viewIDKey.Int(viewID), tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetSavedView(ctx, viewID, userID)
+}
+
+func (s *Storage) GetSavedViews(ctx context.Context, userID int) (views []storage.SavedView, err error) {
+	ctx, span := s.start(ctx, "GetSavedViews", tracing.UserID(userID))
+	defer func() { end(span, err) }()
+
+	return s.next.GetSavedViews(ctx, userID)
+}
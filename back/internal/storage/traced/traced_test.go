@@ -0,0 +1,85 @@
+package traced
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo_list_service/internal/storage"
+	"todo_list_service/internal/storage/memory"
+	"todo_list_service/internal/storage/storagetest"
+	"todo_list_service/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return New(memory.New(), "memory")
+	})
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx := context.Background()
+	s := New(memory.New(), "memory")
+
+	userID, err := s.CreateUser(ctx, "user", "hashed", "user@example.com")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	task, err := s.CreateTask(ctx, &storage.Task{Title: "traced", UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if _, err := s.GetTask(ctx, task.ID+1, userID); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("GetTask of a missing task returned %v, want ErrNotFound", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want one per call", len(spans))
+	}
+
+	for i, name := range []string{"storage.CreateUser", "storage.CreateTask", "storage.GetTask"} {
+		if spans[i].Name() != name {
+			t.Errorf("span %d named %q, want %q", i, spans[i].Name(), name)
+		}
+	}
+
+	created := spans[1]
+	want := []attribute.KeyValue{semconv.DBSystemKey.String("memory"), tracing.UserID(userID)}
+	for _, attr := range want {
+		if !hasAttribute(created, attr) {
+			t.Errorf("CreateTask span %v lacks %v", created.Attributes(), attr)
+		}
+	}
+	if created.Status().Code == codes.Error {
+		t.Errorf("CreateTask span failed: %v", created.Status())
+	}
+
+	missing := spans[2]
+	if !hasAttribute(missing, taskIDKey.Int(task.ID+1)) || !hasAttribute(missing, tracing.UserID(userID)) {
+		t.Errorf("GetTask span %v lacks the task or the user", missing.Attributes())
+	}
+	if missing.Status().Code != codes.Error || len(missing.Events()) == 0 {
+		t.Errorf("failed GetTask recorded status %v and %d events, want the error", missing.Status(), len(missing.Events()))
+	}
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, want attribute.KeyValue) bool {
+	for _, attr := range span.Attributes() {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
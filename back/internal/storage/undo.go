@@ -0,0 +1,65 @@
+package storage
+
+import "errors"
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// undoable reports whether an action of the given type changes a task in a
+// way undo can revert. Creating and deleting a task are not revertible.
+func undoable(actionType int) bool {
+	switch actionType {
+	case UpdateTaskType, UpdateTaskPriorityType, ArchiveTaskType, RestoreTaskType, AttachTagType, DetachTagType, MoveTaskType, SetTaskParentType:
+		return true
+	}
+	return false
+}
+
+// PlanRevert replays the user's log of a task, given oldest first, and picks
+// the action the next undo (or redo, when redo is set) reverts together with
+// the task state it has to restore.
+//
+// Undo and redo actions are part of the log themselves: an undo moves its
+// target from the undo stack to the redo stack, a redo moves it back, and any
+// other change clears the redo stack.
+func PlanRevert(actions []TaskAction, redo bool) (*TaskAction, *Task, error) {
+	var undoStack, redoStack []*TaskAction
+
+	for i := range actions {
+		action := &actions[i]
+
+		switch {
+		case action.ActionType == UndoTaskType && action.TargetActionID != nil:
+			if n := len(undoStack); n > 0 && undoStack[n-1].ID == *action.TargetActionID {
+				redoStack = append(redoStack, undoStack[n-1])
+				undoStack = undoStack[:n-1]
+			}
+		case action.ActionType == RedoTaskType && action.TargetActionID != nil:
+			if n := len(redoStack); n > 0 && redoStack[n-1].ID == *action.TargetActionID {
+				undoStack = append(undoStack, redoStack[n-1])
+				redoStack = redoStack[:n-1]
+			}
+		case undoable(action.ActionType):
+			undoStack = append(undoStack, action)
+			redoStack = nil
+		default:
+			undoStack, redoStack = nil, nil
+		}
+	}
+
+	if redo {
+		if len(redoStack) == 0 || redoStack[len(redoStack)-1].After == nil {
+			return nil, nil, ErrNothingToRedo
+		}
+		target := redoStack[len(redoStack)-1]
+		return target, target.After, nil
+	}
+
+	if len(undoStack) == 0 || undoStack[len(undoStack)-1].Before == nil {
+		return nil, nil, ErrNothingToUndo
+	}
+	target := undoStack[len(undoStack)-1]
+	return target, target.Before, nil
+}
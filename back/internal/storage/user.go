@@ -1,6 +1,12 @@
 package storage
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultTimeZone is the zone of users that never picked one.
+const DefaultTimeZone = "UTC"
 
 type User struct {
 	ID         int       `json:"id"`
@@ -8,4 +14,20 @@ type User struct {
 	Password   string    `json:"password"`
 	Email      string    `json:"email"`
 	CreationTs time.Time `json:"creation_ts"`
+	TimeZone   string    `json:"time_zone"`
+}
+
+// LoadTimeZone resolves an IANA time zone name, an empty name means
+// DefaultTimeZone.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimeZone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone [%s]: %w", name, err)
+	}
+
+	return loc, nil
 }
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todo_list_service/internal/viewquery"
+)
+
+var ErrUnknownViewName = errors.New("view query names a tag or project the user does not have")
+
+// SavedView is a named task filter of a user, Query is an expression of the
+// viewquery language. Names are unique per user.
+type SavedView struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	Query      string    `json:"query"`
+	CreationTs time.Time `json:"creation_ts"`
+}
+
+// SavedViewRepository is implemented by every backend able to persist
+// saved views.
+type SavedViewRepository interface {
+	CreateSavedView(ctx context.Context, newView *SavedView) (*SavedView, error)
+	UpdateSavedView(ctx context.Context, updatedView *SavedView) (*SavedView, error)
+	DeleteSavedView(ctx context.Context, viewID, userID int) error
+	GetSavedView(ctx context.Context, viewID, userID int) (*SavedView, error)
+	GetSavedViews(ctx context.Context, userID int) ([]SavedView, error)
+}
+
+// ViewScope is what a view query is compiled against: the user's tags and
+// projects to look names up in, and the user's time zone and the current
+// moment to place due windows and dates in.
+type ViewScope struct {
+	Tags     []Tag
+	Projects []Project
+	Location *time.Location
+	Now      time.Time
+}
+
+// CompileView turns a view query into the listing filter it stands for. It
+// fails with ErrUnknownViewName when the query names a tag or project
+// missing from scope, and with a plain error when the query is malformed.
+func CompileView(expr string, scope ViewScope) (TaskFilter, error) {
+	var filter TaskFilter
+
+	query, err := viewquery.Parse(expr)
+	if err != nil {
+		return filter, err
+	}
+
+	for _, name := range query.Statuses {
+		statuses, err := statusesByName(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, statuses...)
+	}
+
+	for _, name := range query.Tags {
+		tagID, ok := tagByName(scope.Tags, name)
+		if !ok {
+			return filter, fmt.Errorf("tag [%s]: %w", name, ErrUnknownViewName)
+		}
+		filter.TagIDs = append(filter.TagIDs, tagID)
+	}
+	filter.MatchAllTags = query.AllTags
+
+	if query.Project != "" {
+		projectID, ok := projectByName(scope.Projects, query.Project)
+		if !ok {
+			return filter, fmt.Errorf("project [%s]: %w", query.Project, ErrUnknownViewName)
+		}
+		filter.ProjectID = projectID
+	}
+
+	if query.Due != "" {
+		from, to, err := DueRange(query.Due, scope.Now, scope.Location)
+		if err != nil {
+			return filter, err
+		}
+		filter.DueFrom, filter.DueTo = from, &to
+	}
+
+	if filter.CreatedFrom, err = startOfDate(query.CreatedFrom, scope.Location); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = startOfDate(query.CreatedTo, scope.Location); err != nil {
+		return filter, err
+	}
+
+	filter.Sort, err = ParseTaskSort(query.Sort)
+	return filter, err
+}
+
+// statusesByName resolves a status of a view query, given by name or
+// number. "open" stands for every status that is not done.
+func statusesByName(name string) ([]int8, error) {
+	name = strings.ToLower(name)
+	if name == "open" {
+		return []int8{TaskStatusOpened, TaskStatusInProgress, TaskStatusReview}, nil
+	}
+
+	for status, statusName := range statusNames {
+		if name == statusName {
+			return []int8{status}, nil
+		}
+	}
+	if status, err := strconv.ParseInt(name, 10, 8); err == nil && StatusName(int8(status)) != "" {
+		return []int8{int8(status)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown status [%s]", name)
+}
+
+func tagByName(tags []Tag, name string) (int, bool) {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag.ID, true
+		}
+	}
+	return 0, false
+}
+
+// projectByName looks a project up by name, "inbox" also finds the inbox
+// whatever it is called.
+func projectByName(projects []Project, name string) (int, bool) {
+	for _, project := range projects {
+		if project.Name == name {
+			return project.ID, true
+		}
+	}
+	for _, project := range projects {
+		if project.IsInbox && strings.EqualFold(name, "inbox") {
+			return project.ID, true
+		}
+	}
+	return 0, false
+}
+
+// startOfDate returns the first moment of a viewquery date in loc, nil for
+// an empty date.
+func startOfDate(date string, loc *time.Location) (*time.Time, error) {
+	if date == "" {
+		return nil, nil
+	}
+
+	ts, err := time.ParseInLocation(viewquery.DateLayout, date, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &ts, nil
+}
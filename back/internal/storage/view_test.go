@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestCompileView(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	// a Wednesday afternoon in Tokyo
+	now := time.Date(2024, 5, 1, 15, 30, 0, 0, loc)
+	day := func(month time.Month, day int) *time.Time {
+		ts := time.Date(2024, month, day, 0, 0, 0, 0, loc)
+		return &ts
+	}
+
+	scope := ViewScope{
+		Tags:     []Tag{{ID: 11, Name: "work"}, {ID: 12, Name: "home"}},
+		Projects: []Project{{ID: 21, Name: "Inbox", IsInbox: true}, {ID: 22, Name: "Side project"}, {ID: 23, Name: "inbox zero"}},
+		Location: loc,
+		Now:      now,
+	}
+
+	tests := []struct {
+		expr string
+		want TaskFilter
+	}{
+		{"", TaskFilter{Sort: SortByPriority}},
+		{"status:todo,review", TaskFilter{Statuses: []int8{TaskStatusOpened, TaskStatusReview}, Sort: SortByPriority}},
+		{"status:open", TaskFilter{Statuses: []int8{TaskStatusOpened, TaskStatusInProgress, TaskStatusReview}, Sort: SortByPriority}},
+		{"status:DONE,5", TaskFilter{Statuses: []int8{TaskStatusClosed, TaskStatusCancelled}, Sort: SortByPriority}},
+		{"tag:work,home", TaskFilter{TagIDs: []int{11, 12}, Sort: SortByPriority}},
+		{"tag:work tag:home", TaskFilter{TagIDs: []int{11, 12}, MatchAllTags: true, Sort: SortByPriority}},
+		{`project:"Side project"`, TaskFilter{ProjectID: 22, Sort: SortByPriority}},
+		{"project:inbox", TaskFilter{ProjectID: 21, Sort: SortByPriority}},
+		{`project:"inbox zero"`, TaskFilter{ProjectID: 23, Sort: SortByPriority}},
+		{"due:overdue", TaskFilter{DueTo: &now, Sort: SortByPriority}},
+		{"due:today", TaskFilter{DueFrom: day(time.May, 1), DueTo: day(time.May, 2), Sort: SortByPriority}},
+		{"due:week", TaskFilter{DueFrom: &now, DueTo: day(time.May, 6), Sort: SortByPriority}},
+		{"created_from:2024-04-01 created_to:2024-05-01", TaskFilter{CreatedFrom: day(time.April, 1), CreatedTo: day(time.May, 1), Sort: SortByPriority}},
+		{"sort:creation", TaskFilter{Sort: SortByCreation}},
+		{"sort:due status:todo", TaskFilter{Statuses: []int8{TaskStatusOpened}, Sort: SortByDue}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := CompileView(tt.expr, scope)
+			if err != nil {
+				t.Fatalf("CompileView: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompileView returned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileViewErrors(t *testing.T) {
+	scope := ViewScope{
+		Tags:     []Tag{{ID: 11, Name: "work"}},
+		Projects: []Project{{ID: 21, Name: "Inbox", IsInbox: true}},
+		Location: time.UTC,
+		Now:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	for _, expr := range []string{"tag:Work", "tag:work tag:travel", "project:Work"} {
+		if _, err := CompileView(expr, scope); !errors.Is(err, ErrUnknownViewName) {
+			t.Errorf("CompileView(%q) returned %v, want ErrUnknownViewName", expr, err)
+		}
+	}
+
+	for _, expr := range []string{"status:later", "status:9", "due:someday", "sort:title", "color:red", `project:"Inbox`} {
+		if _, err := CompileView(expr, scope); err == nil || errors.Is(err, ErrUnknownViewName) {
+			t.Errorf("CompileView(%q) returned %v, want a malformed query error", expr, err)
+		}
+	}
+}
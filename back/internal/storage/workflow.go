@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+var ErrInvalidTransition = errors.New("status transition is not allowed by the project workflow")
+
+var statusNames = map[int8]string{
+	TaskStatusOpened:     "todo",
+	TaskStatusClosed:     "done",
+	TaskStatusInProgress: "in_progress",
+	TaskStatusReview:     "review",
+	TaskStatusCancelled:  "cancelled",
+}
+
+// StatusName returns the name of a known status and an empty string for
+// anything else.
+func StatusName(status int8) string {
+	return statusNames[status]
+}
+
+// IsDoneStatus reports whether a task in status is finished. Done tasks sink
+// to the bottom of their project, are left out of due lists and closing a
+// task into any of them applies the subtask close policy and spawns the next
+// occurrence of a recurring task.
+func IsDoneStatus(status int8) bool {
+	return status == TaskStatusClosed || status == TaskStatusCancelled
+}
+
+// Workflow lists the statuses a project's tasks go through, in board column
+// order, and which status each of them may move to. The statuses are the
+// five fixed ones named by StatusName: a workflow picks a subset of them and
+// orders it, it cannot add statuses of its own or rename the fixed ones.
+type Workflow struct {
+	Statuses    []int8          `json:"statuses"`
+	Transitions map[int8][]int8 `json:"transitions"`
+}
+
+// DefaultWorkflow is used by projects that never set their own: todo, in
+// progress, review, done and cancelled. Tasks may be finished from any
+// active status and finished tasks may be reopened.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []int8{TaskStatusOpened, TaskStatusInProgress, TaskStatusReview, TaskStatusClosed, TaskStatusCancelled},
+		Transitions: map[int8][]int8{
+			TaskStatusOpened:     {TaskStatusInProgress, TaskStatusClosed, TaskStatusCancelled},
+			TaskStatusInProgress: {TaskStatusOpened, TaskStatusReview, TaskStatusClosed, TaskStatusCancelled},
+			TaskStatusReview:     {TaskStatusInProgress, TaskStatusClosed, TaskStatusCancelled},
+			TaskStatusClosed:     {TaskStatusOpened},
+			TaskStatusCancelled:  {TaskStatusOpened},
+		},
+	}
+}
+
+// Validate checks that the workflow only uses known statuses, lists each of
+// them once, contains the opened status new tasks start in and lets tasks
+// move into at least one done status, so that they can be finished.
+func (w Workflow) Validate() error {
+	if !slices.Contains(w.Statuses, TaskStatusOpened) {
+		return fmt.Errorf("workflow has no status [%d] for new tasks", TaskStatusOpened)
+	}
+
+	seen := map[int8]bool{}
+	for _, status := range w.Statuses {
+		if StatusName(status) == "" {
+			return fmt.Errorf("unknown status [%d]", status)
+		}
+		if seen[status] {
+			return fmt.Errorf("duplicate status [%d]", status)
+		}
+		seen[status] = true
+	}
+
+	finishable := false
+	for from, targets := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("transition from status [%d] outside the workflow", from)
+		}
+		for _, to := range targets {
+			if !seen[to] || to == from {
+				return fmt.Errorf("invalid transition from status [%d] to [%d]", from, to)
+			}
+			finishable = finishable || IsDoneStatus(to)
+		}
+	}
+	if !finishable {
+		return errors.New("workflow has no transition into a done status")
+	}
+
+	return nil
+}
+
+func (w Workflow) Has(status int8) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+// Allows reports whether a task may move from one status to another. Keeping
+// the status is always allowed. A task left in a status the workflow no
+// longer has may move to any status of the workflow.
+func (w Workflow) Allows(from, to int8) bool {
+	if from == to {
+		return true
+	}
+	if !w.Has(to) {
+		return false
+	}
+	if !w.Has(from) {
+		return true
+	}
+	return slices.Contains(w.Transitions[from], to)
+}
+
+// Value stores the workflow as json, a workflow without statuses is stored
+// as an empty string and reads back as DefaultWorkflow.
+func (w Workflow) Value() (driver.Value, error) {
+	if len(w.Statuses) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (w *Workflow) Scan(src any) error {
+	data, err := scanText(src)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		*w = DefaultWorkflow()
+		return nil
+	}
+	return json.Unmarshal(data, w)
+}
+
+// StatusTimes keeps when a task last entered each of the statuses it has
+// been in.
+type StatusTimes map[int8]time.Time
+
+// With returns a copy of the times with status entered at ts.
+func (times StatusTimes) With(status int8, ts time.Time) StatusTimes {
+	entered := maps.Clone(times)
+	if entered == nil {
+		entered = StatusTimes{}
+	}
+	entered[status] = ts
+	return entered
+}
+
+func (times StatusTimes) Value() (driver.Value, error) {
+	if times == nil {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(times)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (times *StatusTimes) Scan(src any) error {
+	data, err := scanText(src)
+	if err != nil {
+		return err
+	}
+
+	*times = nil
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, times)
+}
+
+func scanText(src any) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return src, nil
+	case string:
+		return []byte(src), nil
+	}
+	return nil, fmt.Errorf("cannot scan %T as text", src)
+}
+
+// BoardColumn holds the tasks of a project that are in one status.
+type BoardColumn struct {
+	Status int8   `json:"status"`
+	Name   string `json:"name"`
+	Tasks  []Task `json:"tasks"`
+}
+
+// GroupByStatus lays tasks out as a kanban board with a column per workflow
+// status, followed by columns for statuses the workflow no longer has.
+// Active columns keep the tasks' priority order, done columns put the most
+// recently finished tasks first.
+func GroupByStatus(workflow Workflow, tasks []Task) []BoardColumn {
+	statuses := slices.Clone(workflow.Statuses)
+	for _, task := range tasks {
+		if !slices.Contains(statuses, task.Status) {
+			statuses = append(statuses, task.Status)
+		}
+	}
+	slices.Sort(statuses[len(workflow.Statuses):])
+
+	columns := make([]BoardColumn, 0, len(statuses))
+	for _, status := range statuses {
+		column := BoardColumn{Status: status, Name: StatusName(status), Tasks: []Task{}}
+		for _, task := range tasks {
+			if task.Status == status {
+				column.Tasks = append(column.Tasks, task)
+			}
+		}
+		if IsDoneStatus(status) {
+			sort.SliceStable(column.Tasks, func(i, j int) bool {
+				return column.Tasks[i].StatusTs[status].After(column.Tasks[j].StatusTs[status])
+			})
+		}
+		columns = append(columns, column)
+	}
+
+	return columns
+}
@@ -0,0 +1,64 @@
+package storage
+
+import "testing"
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		workflow Workflow
+		valid    bool
+	}{
+		{"default", DefaultWorkflow(), true},
+		{"straight line", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusInProgress, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusInProgress}, TaskStatusInProgress: {TaskStatusClosed}},
+		}, true},
+		{"cancel only", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusCancelled},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusCancelled}},
+		}, true},
+		{"no opened status", Workflow{
+			Statuses:    []int8{TaskStatusInProgress, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusInProgress: {TaskStatusClosed}},
+		}, false},
+		{"unknown status", Workflow{
+			Statuses:    []int8{TaskStatusOpened, 42, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusClosed}},
+		}, false},
+		{"duplicate status", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusClosed, TaskStatusOpened},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusClosed}},
+		}, false},
+		{"transition to itself", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusOpened, TaskStatusClosed}},
+		}, false},
+		{"transition outside the workflow", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusReview, TaskStatusClosed}},
+		}, false},
+		{"no done status", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusInProgress},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusInProgress}, TaskStatusInProgress: {TaskStatusOpened}},
+		}, false},
+		{"done status out of reach", Workflow{
+			Statuses:    []int8{TaskStatusOpened, TaskStatusInProgress, TaskStatusClosed},
+			Transitions: map[int8][]int8{TaskStatusOpened: {TaskStatusInProgress}, TaskStatusClosed: {TaskStatusOpened}},
+		}, false},
+		{"no transitions", Workflow{
+			Statuses: []int8{TaskStatusOpened, TaskStatusClosed},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.workflow.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("Validate accepted the workflow")
+			}
+		})
+	}
+}
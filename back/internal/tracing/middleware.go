@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "todo_list_service/internal/tracing"
+
+	// RequestIDKey and UserIDKey tag spans with the chi request id and the
+	// signed in user.
+	RequestIDKey = attribute.Key("request.id")
+	UserIDKey    = semconv.EnduserIDKey
+)
+
+// UserID tags a span with the user it works for, the http spans and the
+// storage spans of a request use the same attribute.
+func UserID(userID int) attribute.KeyValue {
+	return UserIDKey.String(strconv.Itoa(userID))
+}
+
+// Middleware opens the server span of a request, continuing the trace of
+// the caller when the request carries W3C trace context. The span is named
+// after the chi route pattern once the request is routed, it has to run
+// after middleware.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				RequestIDKey.String(middleware.GetReqID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if pattern := chi.RouteContext(r.Context()).RoutePattern(); pattern != "" {
+			span.SetName(fmt.Sprintf("%s %s", r.Method, pattern))
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// Handler opens a span around the handler of a route, apart from the
+// middlewares run before it. It is meant for route groups, where chi has
+// already matched the route when the group's middlewares run.
+func Handler(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), "handler "+chi.RouteContext(r.Context()).RoutePattern())
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
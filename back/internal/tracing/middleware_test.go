@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider keeping the ended spans in memory
+// for the rest of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(Middleware)
+	router.Group(func(r chi.Router) {
+		// stands in for the auth middleware
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				trace.SpanFromContext(r.Context()).SetAttributes(UserID(7))
+				next.ServeHTTP(w, r)
+			})
+		})
+		r.Use(Handler)
+		r.Get("/tasks/{task_id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Post("/tasks/{task_id}/undo", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodGet, "/tasks/42", nil)
+	r.Header.Set(middleware.RequestIDHeader, "req-1")
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want the handler and server spans", len(spans))
+	}
+	handler, server := spans[0], spans[1]
+
+	if name := server.Name(); name != "GET /tasks/{task_id}" {
+		t.Errorf("server span named %q", name)
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span of kind %v", server.SpanKind())
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("server span in trace %s, want the caller's %s", got, traceID)
+	}
+
+	attrs := attributes(server)
+	want := map[attribute.Key]string{
+		semconv.HTTPRouteKey:         "/tasks/{task_id}",
+		semconv.URLPathKey:           "/tasks/42",
+		semconv.HTTPRequestMethodKey: http.MethodGet,
+		RequestIDKey:                 "req-1",
+		UserIDKey:                    "7",
+	}
+	for key, value := range want {
+		if got := attrs[key].Emit(); got != value {
+			t.Errorf("server span %s = %q, want %q", key, got, value)
+		}
+	}
+	if status := attrs[semconv.HTTPResponseStatusCodeKey].AsInt64(); status != http.StatusOK {
+		t.Errorf("server span status code %d, want %d", status, http.StatusOK)
+	}
+
+	if name := handler.Name(); name != "handler /tasks/{task_id}" {
+		t.Errorf("handler span named %q", name)
+	}
+	if handler.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("handler span is not a child of the server span")
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/tasks/42/undo", nil))
+	failed := recorder.Ended()[3]
+	if failed.Status().Code != codes.Error {
+		t.Errorf("span of a 500 answer has status %v, want an error", failed.Status())
+	}
+	if got := attributes(failed)[RequestIDKey].AsString(); got == "" {
+		t.Error("server span has no generated request id")
+	}
+}
@@ -0,0 +1,205 @@
+// Package viewquery implements the filter expression language of saved
+// views. An expression is a space separated list of key:value terms that
+// must all hold, values holding spaces are double quoted:
+//
+//	status:todo,review      the task is in any of the statuses, "open" stands
+//	                        for every status that is not done
+//	tag:work                the task has the tag, tag:work,home has any of
+//	                        them and several tag terms need all of them
+//	project:"Side project"  the task is in the project
+//	due:week                the task is due overdue, today or this week
+//	created_from:2024-01-01 the task was created on that day or later
+//	created_to:2024-02-01   the task was created before that day
+//	sort:due                tasks are listed by priority, creation or due
+//
+// e.g. `due:week tag:work status:open`. Parse only checks the syntax, names
+// of statuses, tags and projects are resolved when the query is compiled.
+package viewquery
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	KeyStatus      = "status"
+	KeyTag         = "tag"
+	KeyProject     = "project"
+	KeyDue         = "due"
+	KeyCreatedFrom = "created_from"
+	KeyCreatedTo   = "created_to"
+	KeySort        = "sort"
+
+	// DateLayout is the layout of created_from and created_to.
+	DateLayout = time.DateOnly
+
+	maxLength = 1024
+)
+
+// keyOrder is the order String writes terms in.
+var keyOrder = []string{KeyStatus, KeyTag, KeyProject, KeyDue, KeyCreatedFrom, KeyCreatedTo, KeySort}
+
+// Query is a parsed expression. Tags holds the names of a tag:a,b term, or
+// of several tag terms with AllTags set. Dates are calendar days, they are
+// placed in the user's time zone when the query is compiled.
+type Query struct {
+	Statuses    []string
+	Tags        []string
+	AllTags     bool
+	Project     string
+	Due         string
+	CreatedFrom string
+	CreatedTo   string
+	Sort        string
+}
+
+// Parse reads an expression, an empty one matches every task.
+func Parse(expr string) (*Query, error) {
+	if len(expr) > maxLength {
+		return nil, fmt.Errorf("view query is longer than %d bytes", maxLength)
+	}
+
+	terms, err := split(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+	seen := map[string]bool{}
+	tagTerms, tagList := 0, false
+
+	for _, term := range terms {
+		key, value, ok := strings.Cut(term, ":")
+		key = strings.ToLower(key)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid view query term [%s], want key:value", term)
+		}
+		if seen[key] && key != KeyTag {
+			return nil, fmt.Errorf("view query key [%s] is given twice", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case KeyStatus:
+			query.Statuses, err = list(key, value)
+		case KeyTag:
+			var tags []string
+			if tags, err = list(key, value); err != nil {
+				break
+			}
+			tagTerms++
+			tagList = tagList || len(tags) > 1
+			if tagTerms > 1 && tagList {
+				err = fmt.Errorf("view query cannot mix tag:a,b with several tag terms")
+			}
+			query.Tags = append(query.Tags, tags...)
+			query.AllTags = tagTerms > 1
+		case KeyProject:
+			query.Project = value
+		case KeyDue:
+			query.Due = strings.ToLower(value)
+		case KeyCreatedFrom, KeyCreatedTo:
+			if _, err = time.Parse(DateLayout, value); err != nil {
+				err = fmt.Errorf("invalid %s date [%s], want YYYY-MM-DD", key, value)
+			}
+			if key == KeyCreatedFrom {
+				query.CreatedFrom = value
+			} else {
+				query.CreatedTo = value
+			}
+		case KeySort:
+			query.Sort = strings.ToLower(value)
+		default:
+			err = fmt.Errorf("unknown view query key [%s]", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+// split cuts an expression into terms at spaces outside double quotes and
+// drops the quotes.
+func split(expr string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted, started := false, false
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				terms = append(terms, term.String())
+			}
+			term.Reset()
+			started = false
+		default:
+			term.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("view query has an unterminated quote")
+	}
+	if started {
+		terms = append(terms, term.String())
+	}
+
+	return terms, nil
+}
+
+// list splits a comma separated value, empty items are not allowed.
+func list(key, value string) ([]string, error) {
+	items := strings.Split(value, ",")
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("view query key [%s] has an empty item in [%s]", key, value)
+		}
+	}
+	return items, nil
+}
+
+// quote wraps a value holding spaces, values never hold quotes since Parse
+// drops them.
+func quote(value string) string {
+	if strings.Contains(value, " ") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// String writes the query back as an expression in a fixed key order.
+func (q *Query) String() string {
+	values := map[string][]string{
+		KeyProject:     {q.Project},
+		KeyDue:         {q.Due},
+		KeyCreatedFrom: {q.CreatedFrom},
+		KeyCreatedTo:   {q.CreatedTo},
+		KeySort:        {q.Sort},
+	}
+	if len(q.Statuses) > 0 {
+		values[KeyStatus] = []string{strings.Join(q.Statuses, ",")}
+	}
+	if q.AllTags {
+		values[KeyTag] = slices.Clone(q.Tags)
+	} else if len(q.Tags) > 0 {
+		values[KeyTag] = []string{strings.Join(q.Tags, ",")}
+	}
+
+	var terms []string
+	for _, key := range keyOrder {
+		for _, value := range values[key] {
+			if value != "" {
+				terms = append(terms, key+":"+quote(value))
+			}
+		}
+	}
+	return strings.Join(terms, " ")
+}
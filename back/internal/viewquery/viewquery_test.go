@@ -0,0 +1,86 @@
+package viewquery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Query
+		// canonical is what String writes back, the expression itself when empty
+		canonical string
+	}{
+		{"", Query{}, ""},
+		{"   ", Query{}, ""},
+		{"status:todo,review", Query{Statuses: []string{"todo", "review"}}, ""},
+		{"tag:work", Query{Tags: []string{"work"}}, ""},
+		{"tag:work,home", Query{Tags: []string{"work", "home"}}, ""},
+		{"tag:work tag:home", Query{Tags: []string{"work", "home"}, AllTags: true}, ""},
+		{`project:"Side project"`, Query{Project: "Side project"}, ""},
+		{`"project:Side project"`, Query{Project: "Side project"}, `project:"Side project"`},
+		{"due:Week", Query{Due: "week"}, "due:week"},
+		{"created_from:2024-01-01 created_to:2024-02-01", Query{CreatedFrom: "2024-01-01", CreatedTo: "2024-02-01"}, ""},
+		{"SORT:Due", Query{Sort: "due"}, "sort:due"},
+		{
+			"sort:creation  due:week tag:work status:open",
+			Query{Statuses: []string{"open"}, Tags: []string{"work"}, Due: "week", Sort: "creation"},
+			"status:open tag:work due:week sort:creation",
+		},
+		// names are kept as typed, they are resolved when compiling
+		{"tag:Work project:a:b", Query{Tags: []string{"Work"}, Project: "a:b"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse returned %+v, want %+v", *got, tt.want)
+			}
+
+			canonical := tt.canonical
+			if canonical == "" {
+				canonical = strings.TrimSpace(tt.expr)
+			}
+			if got.String() != canonical {
+				t.Errorf("String returned %q, want %q", got.String(), canonical)
+			}
+
+			// the canonical form parses back to the same query
+			again, err := Parse(got.String())
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("Parse(%q) returned %+v, %v, want %+v", got.String(), again, err, *got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"work",
+		"tag:",
+		":work",
+		"color:red",
+		"assignee:me",
+		`project:"Side project`,
+		`"`,
+		"status:todo,,review",
+		"tag:work,",
+		"sort:due sort:priority",
+		"due:week DUE:today",
+		"tag:work,home tag:errands",
+		"tag:errands tag:work,home",
+		"created_from:01.02.2024",
+		"created_to:2024-13-01",
+		strings.Repeat("tag:a ", maxLength/6+1),
+	} {
+		if got, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) returned %+v, want an error", expr, *got)
+		}
+	}
+}
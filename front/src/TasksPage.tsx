@@ -32,9 +32,6 @@ function reorder(list: Task[], startIndex: number, endIndex: number): Task[] {
   return result;
 }
 
-const INT_MAX = 2147483647;
-const INT_MIN = -2147483648;
-
 const TasksPage: React.FC<TasksPageProps> = ({ onLogout }) => {
   const [tasks, setTasks] = useState<Task[]>([]);
   const [showCreateModal, setShowCreateModal] = useState(false);
@@ -85,17 +82,14 @@ const TasksPage: React.FC<TasksPageProps> = ({ onLogout }) => {
     const prevTask = newOrder[destination.index - 1];
     const nextTask = newOrder[destination.index + 1];
 
-    let prevPriority = prevTask?.priority;
-    if (prevPriority === undefined) {
-      prevPriority = INT_MAX;
-    }
-    let nextPriority = nextTask?.priority;
-    if (nextPriority === undefined) {
-      nextPriority = INT_MIN;
-    }
-
     try {
-      await updatePriority(movedTask, prevPriority, nextPriority);
+      if (prevTask) {
+        await updatePriority(movedTask.id, 'after', prevTask.id);
+      } else if (nextTask) {
+        await updatePriority(movedTask.id, 'before', nextTask.id);
+      } else {
+        await updatePriority(movedTask.id, 'top');
+      }
     } catch (err) {
       console.error('Failed to update priority:', err);
     }
@@ -126,16 +120,7 @@ const TasksPage: React.FC<TasksPageProps> = ({ onLogout }) => {
 
       // If the task is now open, adjust its priority
       if (wasClosed) {
-        const openTasks = tasks.filter((t) => t.status !== 2 && t.id !== updated.id);
-        const sortedOpen = [...openTasks].sort((a, b) => b.priority - a.priority);
-
-        const topTask = sortedOpen[0];
-        let nextPriority = INT_MIN;
-        if (topTask) {
-          nextPriority = topTask.priority;
-        }
-
-        await updatePriority(updated, INT_MAX, nextPriority);
+        await updatePriority(updated.id, 'top');
 
         // Refetch tasks to ensure consistency
         const refetched = await getTasks();
@@ -5,8 +5,45 @@ export interface Task {
   description: string;
   priority: number;
   status: number;
+  status_ts?: Record<string, string>;
   creation_ts: string;
   user_id: number;
+  project_id: number;
+  parent_id?: number;
+  archived_ts?: string;
+  start_ts?: string;
+  due_ts?: string;
+  recurrence?: string;
+  tag_ids?: number[];
+}
+
+// Тело ответа с ошибкой, одинаковое для всех запросов
+export interface ApiErrorBody {
+  code: string;
+  message: string;
+  fields?: Record<string, string>;
+  request_id?: string;
+}
+
+export class ApiError extends Error {
+  status: number;
+  body?: ApiErrorBody;
+
+  constructor(prefix: string, status: number, body?: ApiErrorBody) {
+    super(`${prefix}: ${body?.message ?? status}`);
+    this.status = status;
+    this.body = body;
+  }
+}
+
+// Разбирает ошибку из ответа; если тело не JSON, остается только статус
+async function apiError(res: Response, prefix: string): Promise<ApiError> {
+  try {
+    const data = await res.json();
+    return new ApiError(prefix, res.status, data.error);
+  } catch {
+    return new ApiError(prefix, res.status);
+  }
 }
 
 /**
@@ -26,7 +63,7 @@ export async function checkAuth(): Promise<boolean> {
   } else if (res.status === 401) {
     return false;
   } else {
-    throw new Error(`checkAuth failed: ${res.statusText}`);
+    throw await apiError(res, 'checkAuth failed');
   }
 }
 
@@ -38,7 +75,7 @@ export async function signIn(username: string, password: string): Promise<void>
     body: JSON.stringify({ username, password }),
   });
   if (!res.ok) {
-    throw new Error(`Sign in failed: ${res.statusText}`);
+    throw await apiError(res, 'Sign in failed');
   }
 }
 
@@ -50,7 +87,7 @@ export async function signUp(username: string, password: string, email: string):
     body: JSON.stringify({ username, password, email }),
   });
   if (!res.ok) {
-    throw new Error(`Sign up failed: ${res.statusText}`);
+    throw await apiError(res, 'Sign up failed');
   }
 }
 
@@ -60,20 +97,28 @@ export async function logout(): Promise<void> {
     credentials: 'include',
   });
   if (!res.ok) {
-    throw new Error(`Logout failed: ${res.statusText}`);
+    throw await apiError(res, 'Logout failed');
   }
 }
 
+// Сервер отдает задачи страницами, next_cursor указывает на следующую
 export async function getTasks(): Promise<Task[]> {
-  const res = await fetch('/get_tasks', {
-    method: 'GET',
-    credentials: 'include',
-  });
-  if (!res.ok) {
-    throw new Error(`getTasks failed: ${res.statusText}`);
-  }
-  const data = await res.json();
-  return data.tasks;
+  const tasks: Task[] = [];
+  let cursor: string | undefined;
+  do {
+    const query = cursor ? `?cursor=${encodeURIComponent(cursor)}` : '';
+    const res = await fetch(`/get_tasks${query}`, {
+      method: 'GET',
+      credentials: 'include',
+    });
+    if (!res.ok) {
+      throw await apiError(res, 'getTasks failed');
+    }
+    const data = await res.json();
+    tasks.push(...data.tasks);
+    cursor = data.next_cursor;
+  } while (cursor);
+  return tasks;
 }
 
 export async function createTask(title: string, description: string): Promise<Task> {
@@ -87,7 +132,7 @@ export async function createTask(title: string, description: string): Promise<Ta
     body: JSON.stringify(body),
   });
   if (!res.ok) {
-    throw new Error(`createTask failed: ${res.statusText}`);
+    throw await apiError(res, 'createTask failed');
   }
   const data = await res.json();
   // data.task — это новый Task
@@ -98,10 +143,12 @@ export async function createTask(title: string, description: string): Promise<Ta
 // src/api.ts
 
 // Описание тела запроса
+export type TaskPlacement = 'top' | 'bottom' | 'before' | 'after';
+
 interface UpdatePriorityRequest {
-  target_task: Task;
-  prev_task_priority?: number;
-  next_task_priority?: number;
+  task_id: number;
+  position: TaskPlacement;
+  anchor_id?: number;
 }
 
 // Описание тела ответа
@@ -110,18 +157,19 @@ interface UpdatePriorityResponse {
 }
 
 /**
- * Функция для обновления приоритета задачи
- * (вызывается при Drag & Drop)
+ * Функция для перемещения задачи (вызывается при Drag & Drop):
+ * before/after ставят задачу над или под задачей anchorId,
+ * новый приоритет вычисляет сервер
  */
 export async function updatePriority(
-  targetTask: Task,
-  prevPriority?: number,
-  nextPriority?: number
+  taskId: number,
+  position: TaskPlacement,
+  anchorId?: number
 ): Promise<Task> {
   const reqBody: UpdatePriorityRequest = {
-    target_task: targetTask,
-    prev_task_priority: prevPriority,
-    next_task_priority: nextPriority,
+    task_id: taskId,
+    position,
+    anchor_id: anchorId,
   };
 
   const res = await fetch('/update_priority', {
@@ -134,7 +182,7 @@ export async function updatePriority(
   });
 
   if (!res.ok) {
-    throw new Error(`updatePriority failed: ${res.statusText}`);
+    throw await apiError(res, 'updatePriority failed');
   }
 
   // По OpenAPI, эндпоинт возвращает handlers.UpdatePriorityResponse
@@ -153,10 +201,140 @@ export async function updateTask(updatedTask: Task): Promise<Task> {
     body: JSON.stringify(reqBody),
   });
   if (!res.ok) {
-    throw new Error(`updateTask failed: ${res.statusText}`);
+    throw await apiError(res, 'updateTask failed');
   }
 
 
   const data = await res.json();
   return data.task; // по OpenAPI: handlers.UpdateTaskResponse
 }
+
+// Найденная задача: сниппеты - это HTML, текст задачи в них экранирован,
+// совпавшие слова обернуты в <mark>
+export interface TaskMatch {
+  task: Task;
+  rank: number;
+  title_snippet: string;
+  description_snippet: string;
+}
+
+export async function searchTasks(query: string, limit?: number): Promise<TaskMatch[]> {
+  const params = new URLSearchParams({ q: query });
+  if (limit) {
+    params.set('limit', String(limit));
+  }
+  const res = await fetch(`/search_tasks?${params}`, {
+    method: 'GET',
+    credentials: 'include',
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'searchTasks failed');
+  }
+  const data = await res.json();
+  return data.results;
+}
+
+// Сохраненный фильтр, query пишется на языке вида `due:week tag:work status:open`
+export interface SavedView {
+  id: number;
+  name: string;
+  query: string;
+  creation_ts: string;
+}
+
+export async function getViews(): Promise<SavedView[]> {
+  const res = await fetch('/get_views', {
+    method: 'GET',
+    credentials: 'include',
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'getViews failed');
+  }
+  const data = await res.json();
+  return data.views;
+}
+
+export async function saveView(view: Partial<SavedView>): Promise<SavedView> {
+  const res = await fetch(view.id ? '/update_view' : '/create_view', {
+    method: 'POST',
+    credentials: 'include',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ view }),
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'saveView failed');
+  }
+  const data = await res.json();
+  return data.view;
+}
+
+export async function deleteView(viewId: number): Promise<void> {
+  const res = await fetch('/delete_view', {
+    method: 'POST',
+    credentials: 'include',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ view_id: viewId }),
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'deleteView failed');
+  }
+}
+
+// Задачи представления со всех страниц
+export async function getViewTasks(viewId: number): Promise<Task[]> {
+  const tasks: Task[] = [];
+  let cursor = '';
+  do {
+    const params = new URLSearchParams({ view_id: String(viewId) });
+    if (cursor) {
+      params.set('cursor', cursor);
+    }
+    const res = await fetch(`/get_view_tasks?${params}`, {
+      method: 'GET',
+      credentials: 'include',
+    });
+    if (!res.ok) {
+      throw await apiError(res, 'getViewTasks failed');
+    }
+    const data = await res.json();
+    tasks.push(...data.tasks);
+    cursor = data.next_cursor ?? '';
+  } while (cursor);
+  return tasks;
+}
+
+export interface QuickAddPreview {
+  task: Task;
+  new_tags: string[] | null;
+  priority: '' | 'high' | 'low';
+}
+
+// Разбирает строку вида "Call bank tomorrow 10am !high #finance every monday"
+// и создает задачу
+export async function quickAdd(text: string, projectId?: number): Promise<Task> {
+  const res = await fetch('/quick_add', {
+    method: 'POST',
+    credentials: 'include',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ text, project_id: projectId }),
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'quickAdd failed');
+  }
+  const data = await res.json();
+  return data.task;
+}
+
+// Показывает, что получится из строки, ничего не сохраняя
+export async function previewQuickAdd(text: string, projectId?: number): Promise<QuickAddPreview> {
+  const res = await fetch('/quick_add', {
+    method: 'POST',
+    credentials: 'include',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ text, project_id: projectId, preview: true }),
+  });
+  if (!res.ok) {
+    throw await apiError(res, 'previewQuickAdd failed');
+  }
+  return (await res.json()) as QuickAddPreview;
+}